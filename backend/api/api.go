@@ -13,6 +13,7 @@ import (
 	"github.com/mujhtech/b0/config"
 	"github.com/mujhtech/b0/database/store"
 	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/artifact"
 	"github.com/mujhtech/b0/internal/pkg/billing/stripe"
 	"github.com/mujhtech/b0/internal/pkg/container"
 	"github.com/mujhtech/b0/internal/pkg/sse"
@@ -42,9 +43,10 @@ func New(
 	docker *container.Container,
 	billing stripe.Stripe,
 	secretManager secretmanager.SecretManager,
+	artifacts artifact.Store,
 ) (*Api, error) {
 
-	h, err := handler.New(cfg, ctx, store, cache, agent, sse, job, docker, billing, secretManager)
+	h, err := handler.New(cfg, ctx, store, cache, agent, sse, job, docker, billing, secretManager, artifacts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create handler: %w", err)
 	}
@@ -107,6 +109,17 @@ func (a *Api) BuildRouter() *chi.Mux {
 				r.Get(fmt.Sprintf("/{%s}/log", handler.ProjectParamId), a.handler.ProjectLog)
 				r.Get(fmt.Sprintf("/{%s}/sse", handler.ProjectParamId), a.handler.ProjectEvent)
 				r.Get(fmt.Sprintf("/{%s}/secrets", handler.ProjectParamId), a.handler.GetScret)
+				r.Get(fmt.Sprintf("/{%s}/openapi", handler.ProjectParamId), a.handler.GetProjectOpenAPI)
+				r.Get(fmt.Sprintf("/{%s}/git-remote", handler.ProjectParamId), a.handler.GetGitRemote)
+				r.Put(fmt.Sprintf("/{%s}/git-remote", handler.ProjectParamId), a.handler.UpdateGitRemote)
+				r.Get(fmt.Sprintf("/{%s}/jobs", handler.ProjectParamId), a.handler.GetJobs)
+				r.Get(fmt.Sprintf("/{%s}/jobs/{%s}", handler.ProjectParamId, handler.JobParamId), a.handler.GetJob)
+				r.Get(fmt.Sprintf("/{%s}/webhooks", handler.ProjectParamId), a.handler.GetWebhooks)
+				r.Post(fmt.Sprintf("/{%s}/webhooks", handler.ProjectParamId), a.handler.CreateWebhook)
+				r.Put(fmt.Sprintf("/{%s}/webhooks/{%s}", handler.ProjectParamId, handler.WebhookParamId), a.handler.UpdateWebhook)
+				r.Delete(fmt.Sprintf("/{%s}/webhooks/{%s}", handler.ProjectParamId, handler.WebhookParamId), a.handler.DeleteWebhook)
+				r.Get(fmt.Sprintf("/{%s}/webhooks/{%s}/deliveries", handler.ProjectParamId, handler.WebhookParamId), a.handler.GetWebhookDeliveries)
+				r.Post(fmt.Sprintf("/{%s}/webhooks/{%s}/deliveries/{%s}/redeliver", handler.ProjectParamId, handler.WebhookParamId, handler.WebhookDeliveryParamId), a.handler.RedeliverWebhookDelivery)
 				r.Put(fmt.Sprintf("/{%s}", handler.ProjectParamId), a.handler.UpdateProject)
 				r.Post(fmt.Sprintf("/{%s}/action", handler.ProjectParamId), a.handler.ProjectAction)
 				r.Post(fmt.Sprintf("/{%s}/secrets", handler.ProjectParamId), a.handler.CreateOrUpdateScret)
@@ -118,12 +131,20 @@ func (a *Api) BuildRouter() *chi.Mux {
 				r.Get("/", a.handler.GetEndpoints)
 				r.Post("/", a.handler.CreateEndpoint)
 				r.Put(fmt.Sprintf("/{%s}", handler.EndpointParamId), a.handler.UpdateEndpoint)
+				r.Get(fmt.Sprintf("/{%s}/diagram", handler.EndpointParamId), a.handler.GetEndpointDiagram)
 				r.Put(fmt.Sprintf("/{%s}/workflows", handler.EndpointParamId), a.handler.UpdateEndpointWorkflow)
+				r.HandleFunc(fmt.Sprintf("/{%s}/preview", handler.EndpointParamId), a.handler.PreviewEndpoint)
+				r.HandleFunc(fmt.Sprintf("/{%s}/preview/*", handler.EndpointParamId), a.handler.PreviewEndpoint)
+				r.Put(fmt.Sprintf("/{%s}/code", handler.EndpointParamId), a.handler.UpdateEndpointCode)
+				r.Get(fmt.Sprintf("/{%s}/versions", handler.EndpointParamId), a.handler.GetCodeVersions)
+				r.Get(fmt.Sprintf("/{%s}/versions/diff", handler.EndpointParamId), a.handler.GetCodeVersionDiff)
+				r.Post(fmt.Sprintf("/{%s}/versions/{%s}/rollback", handler.EndpointParamId, handler.CodeVersionParamId), a.handler.RollbackCodeVersion)
 			})
 
 			// chat route
 			r.Route("/chat", func(r chi.Router) {
 				r.Post(fmt.Sprintf("/{%s}", handler.ProjectParamId), a.handler.Chat)
+				r.Get(fmt.Sprintf("/{%s}", handler.ProjectParamId), a.handler.GetChatMessages)
 			})
 
 			// billing route
@@ -131,6 +152,11 @@ func (a *Api) BuildRouter() *chi.Mux {
 				r.Get("/usage", a.handler.GetUsage)
 				r.Post("/upgrade", a.handler.UpgradePlan)
 			})
+
+			// artifact route, the signed link authorizes the download
+			r.Route("/artifacts", func(r chi.Router) {
+				r.Get(fmt.Sprintf("/{%s}", handler.ArtifactParamName), a.handler.DownloadArtifact)
+			})
 		})
 	})
 
@@ -149,6 +175,11 @@ var guestRoutes = []string{
 	"/features",
 }
 
+// guestRoutePrefixes are the guest routes with a path parameter
+var guestRoutePrefixes = []string{
+	artifact.DownloadPath + "/",
+}
+
 func shouldAllowAuth(r *http.Request) bool {
 
 	for _, route := range guestRoutes {
@@ -157,5 +188,11 @@ func shouldAllowAuth(r *http.Request) bool {
 		}
 	}
 
+	for _, prefix := range guestRoutePrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+
 	return true
 }
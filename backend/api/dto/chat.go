@@ -1,6 +1,31 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/mujhtech/b0/database/models"
+)
+
 type ChatRequestDto struct {
 	Text  string `json:"text"`
 	Model string `json:"model,omitempty"`
 }
+
+type GetChatMessagesQuery struct {
+	ProjectID  string    `json:"project_id,omitempty"`
+	EndpointID string    `json:"endpoint_id,omitempty"`
+	Before     time.Time `json:"before,omitempty"`
+	Limit      int       `json:"limit,omitempty"`
+}
+
+type ChatMessagesResponseDto struct {
+	Messages []*models.ChatMessage `json:"messages"`
+	// Before is the cursor of the older messages, it is only set when there may be more of them
+	Before *time.Time `json:"before,omitempty"`
+}
+
+// ChatResponseDto is the message of the user and the job answering it
+type ChatResponseDto struct {
+	*models.ChatMessage
+	JobID string `json:"job_id,omitempty"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+)
+
+type UpdateEndpointCodeRequestDto struct {
+	// Files are created or replaced, the other files are kept
+	Files        []agent.FileContent `json:"files"`
+	DeletedFiles []string            `json:"deleted_files,omitempty"`
+	Message      string              `json:"message,omitempty"`
+}
+
+type GetCodeVersionDiffQuery struct {
+	From string `json:"from"`
+	// To is the latest version when empty
+	To string `json:"to,omitempty"`
+}
+
+type CodeVersionDiffResponseDto struct {
+	From  *models.CodeVersion `json:"from"`
+	To    *models.CodeVersion `json:"to"`
+	Files []agent.FileDiff    `json:"files"`
+}
+
+type RollbackCodeVersionResponseDto struct {
+	Version   *models.CodeVersion `json:"version"`
+	Deploying bool                `json:"deploying"`
+	// JobID is the id of the deploy job to poll
+	JobID string `json:"job_id,omitempty"`
+}
@@ -1,8 +1,9 @@
 package dto
 
 import (
+	"encoding/json"
+
 	"github.com/mujhtech/b0/database/models"
-	"github.com/mujhtech/b0/internal/pkg/agent"
 )
 
 type CreateEndpointRequestDto struct {
@@ -20,5 +21,11 @@ type GetEndpointQuery struct {
 }
 
 type UpdateEndpointWorkflowRequestDto struct {
-	Workflows []*agent.Workflow `json:"workflows"`
+	// Workflows is kept raw so it can be validated before it is decoded
+	Workflows json.RawMessage `json:"workflows"`
+}
+
+type EndpointDiagramResponseDto struct {
+	Format  string `json:"format"`
+	Diagram string `json:"diagram"`
 }
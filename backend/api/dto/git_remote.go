@@ -0,0 +1,22 @@
+package dto
+
+// GitRemoteRequestDto replaces the git remote of the project, the credentials are kept in the secret manager
+type GitRemoteRequestDto struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch,omitempty"`
+	// Username and Password authenticate over HTTPS, the password is usually an access token
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// SSHKey is the PEM private key used over SSH, KnownHosts are the known_hosts lines of the host
+	SSHKey        string `json:"ssh_key,omitempty"`
+	SSHPassphrase string `json:"ssh_passphrase,omitempty"`
+	KnownHosts    string `json:"known_hosts,omitempty"`
+}
+
+// GitRemoteResponseDto is the git remote of the project without its credentials
+type GitRemoteResponseDto struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch"`
+	// Auth is how the push authenticates: none, password or ssh_key
+	Auth string `json:"auth"`
+}
@@ -9,6 +9,15 @@ type CreateProjectRequestDto struct {
 
 type ProjectActionRequestDto struct {
 	Action string `json:"action"`
+	// Spec is the OpenAPI 3.x document in YAML or JSON of the import action
+	Spec string `json:"spec,omitempty"`
+	// GenerateWorkflows asks the agent to fill in the imported workflows from the operation descriptions
+	GenerateWorkflows bool `json:"generate_workflows,omitempty"`
+}
+
+// ProjectActionResponseDto is the job running the action, it is polled by its id
+type ProjectActionResponseDto struct {
+	JobID string `json:"job_id,omitempty"`
 }
 
 type DeleteProjectRequestDto struct {
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+)
+
+type CreateWebhookRequestDto struct {
+	URL         string              `json:"url"`
+	Description string              `json:"description,omitempty"`
+	Events      []webhook.EventType `json:"events"`
+}
+
+// UpdateWebhookRequestDto changes the fields it sets, RotateSecret replaces the signing secret
+type UpdateWebhookRequestDto struct {
+	URL          string              `json:"url,omitempty"`
+	Description  *string             `json:"description,omitempty"`
+	Events       []webhook.EventType `json:"events,omitempty"`
+	IsActive     *bool               `json:"is_active,omitempty"`
+	RotateSecret bool                `json:"rotate_secret,omitempty"`
+}
+
+// WebhookSecretResponseDto is a webhook and its signing secret, the secret is only returned when it is created
+type WebhookSecretResponseDto struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
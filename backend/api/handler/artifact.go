@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mujhtech/b0/internal/pkg/artifact"
+	"github.com/mujhtech/b0/internal/pkg/response"
+	"github.com/rs/zerolog"
+)
+
+const (
+	ArtifactParamName = "artifact_name"
+)
+
+// DownloadArtifact serves an artifact behind a signed link, the link stands in for the session
+func (h *Handler) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	name, err := pathParamOrError(r, ArtifactParamName)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	if err := artifact.NewSigner(h.cfg.EncryptionKey).Verify(name, query.Get("expires"), query.Get("signature")); err != nil {
+		if errors.Is(err, artifact.ErrLinkExpired) {
+			_ = response.Gone(w, r, err)
+			return
+		}
+
+		_ = response.Forbidden(w, r, err)
+		return
+	}
+
+	file, err := h.artifacts.Open(ctx, name)
+
+	if errors.Is(err, artifact.ErrNotFound) {
+		_ = response.NotFound(w, r, err)
+		return
+	}
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, file); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to send artifact")
+	}
+}
@@ -3,12 +3,11 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/mujhtech/b0/api/dto"
 	"github.com/mujhtech/b0/api/middleware"
-	"github.com/mujhtech/b0/database/models"
 	"github.com/mujhtech/b0/database/store"
-	"github.com/mujhtech/b0/internal/pkg/agent"
 	"github.com/mujhtech/b0/internal/pkg/request"
 	"github.com/mujhtech/b0/internal/pkg/response"
 	"github.com/mujhtech/b0/internal/util"
@@ -47,7 +46,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 	if dst.Model != "" {
 
 		var agentModelErr error
-		catalog, agentModelErr := agent.GetModelCatalog(dst.Model)
+		catalog, agentModelErr := h.agent.GetModelCatalog(dst.Model)
 		if agentModelErr != nil {
 			_ = response.BadRequest(w, r, agentModelErr)
 			return
@@ -97,8 +96,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var endpoint *models.Endpoint
-
+	// without an endpoint the chat is about the whole project, e.g to add new endpoints
 	if endpointId != "" {
 
 		findEndpointService := services.FindEndpointService{
@@ -107,19 +105,39 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 			User:         session.User,
 		}
 
-		endpoint, err = findEndpointService.Run(ctx)
+		endpoint, err := findEndpointService.Run(ctx)
 
 		if err != nil {
 			_ = response.InternalServerError(w, r, err)
 			return
 		}
 
+		if endpoint.ProjectID != project.ID {
+			_ = response.BadRequest(w, r, fmt.Errorf("endpoint %s doesn't belong to the project", endpointId))
+			return
+		}
+	}
+
+	createChatMessageService := services.CreateChatMessageService{
+		ChatMessageRepo: h.store.ChatMessageRepo,
+		User:            session.User,
+		ProjectID:       project.ID,
+		EndpointID:      endpointId,
+		Content:         dst.Text,
+	}
+
+	chatMessage, err := createChatMessageService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
 	}
 
 	payload := handlers.UpdateWorkflowPayload{
-		ProjectId:  project.ID,
-		EndpointId: endpoint.ID,
-		Prompt:     dst.Text,
+		ProjectId:     project.ID,
+		EndpointId:    endpointId,
+		Prompt:        dst.Text,
+		ChatMessageId: chatMessage.ID,
 	}
 
 	var payloadRaw []byte
@@ -129,10 +147,21 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = h.job.Client.Enqueue(job.QueueNameDefault, job.JobNameWorkflowUpdate, &job.ClientPayload{
-		Data: payloadRaw,
-	}); err != nil {
+	jobId, err := h.job.Client.Enqueue(ctx, job.QueueNameDefault, job.JobNameWorkflowUpdate, &job.ClientPayload{
+		Data:      payloadRaw,
+		ProjectID: project.ID,
+	})
+
+	if err != nil {
 		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to enqueue job")
+
+		// the message is never answered without its job, it isn't kept in the conversation
+		if err := h.store.ChatMessageRepo.DeleteChatMessage(ctx, chatMessage.ID); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to delete chat message")
+		}
+
+		_ = response.InternalServerError(w, r, err)
+		return
 	}
 
 	// id := uuid.New().String()
@@ -202,5 +231,59 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 
 	//_ = response.Ok(w, r, "file uploaded", nil)
 
-	_ = response.Ok(w, r, "ok", nil)
+	_ = response.Ok(w, r, "ok", dto.ChatResponseDto{
+		ChatMessage: chatMessage,
+		JobID:       jobId,
+	})
+}
+
+// GetChatMessages pages through the conversation about the project, or one of its endpoints with the endpoint
+// query parameter, from the most recent messages to the oldest with the before cursor
+func (h *Handler) GetChatMessages(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	projectId, err := getProjectIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	query := dto.GetChatMessagesQuery{
+		ProjectID:  projectId,
+		EndpointID: queryParamOrDefault(r, "endpoint", ""),
+		Limit:      ParsePerPage(r),
+	}
+
+	if before := queryParamOrDefault(r, "before", ""); before != "" {
+		query.Before, err = time.Parse(time.RFC3339Nano, before)
+
+		if err != nil {
+			_ = response.BadRequest(w, r, fmt.Errorf("before must be an RFC 3339 timestamp: %w", err))
+			return
+		}
+	}
+
+	findChatMessagesService := services.FindChatMessagesService{
+		ChatMessageRepo: h.store.ChatMessageRepo,
+		ProjectRepo:     h.store.ProjectRepo,
+		User:            session.User,
+		Query:           query,
+	}
+
+	messages, err := findChatMessagesService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "chat messages retrieved", messages)
 }
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mujhtech/b0/api/dto"
+	"github.com/mujhtech/b0/api/middleware"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/request"
+	"github.com/mujhtech/b0/internal/pkg/response"
+	"github.com/mujhtech/b0/job"
+	"github.com/mujhtech/b0/services"
+	"github.com/rs/zerolog"
+)
+
+const (
+	CodeVersionParamId = "version_id"
+)
+
+func getCodeVersionIdFromPath(r *http.Request) (string, error) {
+	rawRef, err := pathParamOrError(r, CodeVersionParamId)
+	if err != nil {
+		return "", err
+	}
+
+	return url.PathUnescape(rawRef)
+}
+
+// GetCodeVersions lists the latest versions of the code of the endpoint newest first, without their content
+func (h *Handler) GetCodeVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	endpointID, err := getEndpointIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	findCodeVersionsService := services.FindCodeVersionsService{
+		EndpointRepo:    h.store.EndpointRepo,
+		CodeVersionRepo: h.store.CodeVersionRepo,
+		User:            session.User,
+		EndpointID:      endpointID,
+		Limit:           uint64(ParsePerPage(r)),
+	}
+
+	versions, err := findCodeVersionsService.Run(ctx)
+
+	if err != nil {
+		codeVersionError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "code versions retrieved", versions)
+}
+
+// GetCodeVersionDiff diffs the files of the from version of the code and of the to version, the latest version by default
+func (h *Handler) GetCodeVersionDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	endpointID, err := getEndpointIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	query := dto.GetCodeVersionDiffQuery{
+		From: queryParamOrDefault(r, "from", ""),
+		To:   queryParamOrDefault(r, "to", ""),
+	}
+
+	if query.From == "" {
+		_ = response.BadRequest(w, r, fmt.Errorf("from is required"))
+		return
+	}
+
+	diffCodeVersionsService := services.DiffCodeVersionsService{
+		EndpointRepo:    h.store.EndpointRepo,
+		CodeVersionRepo: h.store.CodeVersionRepo,
+		User:            session.User,
+		EndpointID:      endpointID,
+		Query:           query,
+	}
+
+	diff, err := diffCodeVersionsService.Run(ctx)
+
+	if err != nil {
+		codeVersionError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "code versions diffed", diff)
+}
+
+// UpdateEndpointCode creates, replaces and deletes files of the generated code, the edit is saved as a version
+func (h *Handler) UpdateEndpointCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	endpointID, err := getEndpointIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	dst := new(dto.UpdateEndpointCodeRequestDto)
+
+	if err := request.ReadBody(r, dst); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	if len(dst.Files) == 0 && len(dst.DeletedFiles) == 0 {
+		_ = response.BadRequest(w, r, fmt.Errorf("the edit has no files"))
+		return
+	}
+
+	for _, file := range dst.Files {
+		if strings.TrimSpace(file.Filename) == "" {
+			_ = response.BadRequest(w, r, fmt.Errorf("every file needs a filename"))
+			return
+		}
+	}
+
+	updateEndpointCodeService := services.UpdateEndpointCodeService{
+		EndpointRepo:    h.store.EndpointRepo,
+		CodeVersionRepo: h.store.CodeVersionRepo,
+		User:            session.User,
+		EndpointID:      endpointID,
+		Body:            dst,
+	}
+
+	version, err := updateEndpointCodeService.Run(ctx)
+
+	if err != nil {
+		codeVersionError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "endpoint code updated successfully", version)
+}
+
+// RollbackCodeVersion restores the code of the version and redeploys the project
+func (h *Handler) RollbackCodeVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	endpointID, err := getEndpointIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	versionID, err := getCodeVersionIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	rollbackCodeVersionService := services.RollbackCodeVersionService{
+		EndpointRepo:    h.store.EndpointRepo,
+		CodeVersionRepo: h.store.CodeVersionRepo,
+		User:            session.User,
+		EndpointID:      endpointID,
+		VersionID:       versionID,
+	}
+
+	version, err := rollbackCodeVersionService.Run(ctx)
+
+	if err != nil {
+		codeVersionError(w, r, err)
+		return
+	}
+
+	deploying := true
+
+	jobId, err := h.job.Client.Enqueue(ctx, job.QueueNameDefault, job.JobNameProjectDeploy, &job.ClientPayload{
+		Data:      []byte(version.ProjectID),
+		ProjectID: version.ProjectID,
+	})
+
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to enqueue job")
+		deploying = false
+	}
+
+	_ = response.Ok(w, r, "endpoint code rolled back", dto.RollbackCodeVersionResponseDto{
+		Version:   version,
+		Deploying: deploying,
+		JobID:     jobId,
+	})
+}
+
+func codeVersionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		_ = response.NotFound(w, r, err)
+	case errors.Is(err, services.ErrNoCode):
+		_ = response.BadRequest(w, r, err)
+	default:
+		_ = response.InternalServerError(w, r, err)
+	}
+}
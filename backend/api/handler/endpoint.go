@@ -1,10 +1,15 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/guregu/null"
 	"github.com/mujhtech/b0/api/dto"
 	"github.com/mujhtech/b0/api/middleware"
@@ -12,7 +17,10 @@ import (
 	"github.com/mujhtech/b0/internal/pkg/agent"
 	"github.com/mujhtech/b0/internal/pkg/request"
 	"github.com/mujhtech/b0/internal/pkg/response"
+	"github.com/mujhtech/b0/internal/pkg/workflow"
+	"github.com/mujhtech/b0/internal/util"
 	"github.com/mujhtech/b0/services"
+	"github.com/rs/zerolog"
 )
 
 const (
@@ -157,6 +165,18 @@ func (h *Handler) UpdateEndpointWorkflow(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := agent.ValidateWorkflowsJSON(dst.Workflows); err != nil {
+		_ = response.ValidationError(w, r, err, agent.WorkflowDiagnostics(err))
+		return
+	}
+
+	var workflows []*agent.Workflow
+
+	if err := util.UnmarshalJSON(dst.Workflows, &workflows); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
 	findEndpointService := services.FindEndpointService{
 		EndpointID:   endpointID,
 		EndpointRepo: h.store.EndpointRepo,
@@ -183,9 +203,9 @@ func (h *Handler) UpdateEndpointWorkflow(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// the generated code is kept, the next deploy only patches the files affected by the workflow changes
 	if err := h.store.EndpointRepo.UpdateEndpoint(ctx, endpoint.ID, &models.Endpoint{
-		Workflows:      dst.Workflows,
-		CodeGeneration: &agent.CodeGeneration{},
+		Workflows: workflows,
 	}); err != nil {
 		_ = response.InternalServerError(w, r, err)
 		return
@@ -232,3 +252,171 @@ func (h *Handler) UpdateEndpointWorkflow(w http.ResponseWriter, r *http.Request)
 
 	_ = response.Ok(w, r, "endpoint workflow updated successfully", endpoint)
 }
+
+// GetEndpointDiagram renders the endpoint workflows as a flowchart, the format query parameter is mermaid (default) or dot
+func (h *Handler) GetEndpointDiagram(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	endpointID, err := getEndpointIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	format := queryParamOrDefault(r, "format", string(agent.DiagramFormatMermaid))
+
+	findEndpointService := services.FindEndpointService{
+		EndpointID:   endpointID,
+		EndpointRepo: h.store.EndpointRepo,
+		User:         session.User,
+	}
+
+	endpoint, err := findEndpointService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	diagram, err := agent.RenderDiagram(endpoint.Workflows, agent.DiagramFormat(format))
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "endpoint diagram rendered", dto.EndpointDiagramResponseDto{
+		Format:  format,
+		Diagram: diagram,
+	})
+}
+
+// PreviewEndpoint executes the endpoint workflows with the workflow interpreter, the path after /preview
+// is matched against the endpoint path e.g /preview/todos/1 for /todos/:id
+func (h *Handler) PreviewEndpoint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	endpointID, err := getEndpointIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	findEndpointService := services.FindEndpointService{
+		EndpointID:   endpointID,
+		EndpointRepo: h.store.EndpointRepo,
+		User:         session.User,
+	}
+
+	endpoint, err := findEndpointService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	if !strings.EqualFold(string(endpoint.Method), r.Method) {
+		_ = response.BadRequest(w, r, fmt.Errorf("endpoint expects %s, got %s", endpoint.Method, r.Method))
+		return
+	}
+
+	path := "/" + chi.URLParam(r, "*")
+
+	params, ok := workflow.MatchPath(endpoint.Path, path)
+
+	if !ok {
+		_ = response.BadRequest(w, r, fmt.Errorf("path %s doesn't match the endpoint path %s", path, endpoint.Path))
+		return
+	}
+
+	var body interface{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	env := map[string]string{}
+
+	findEnvVarsService := services.FindEnvVarsService{
+		SecretManager: h.secretManager,
+		ProjectID:     endpoint.ProjectID,
+		EndpointID:    endpoint.ID,
+	}
+
+	secrets, err := findEnvVarsService.Run(ctx)
+
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("endpoint_id", endpoint.ID).Msg("failed to load secrets for the endpoint preview")
+	}
+
+	for _, secret := range secrets {
+		env[secret.Name] = secret.Value
+	}
+
+	query := map[string]string{}
+
+	for key := range r.URL.Query() {
+		query[key] = r.URL.Query().Get(key)
+	}
+
+	headers := map[string]string{}
+
+	for key := range r.Header {
+		headers[strings.ToLower(key)] = r.Header.Get(key)
+	}
+
+	res, err := workflow.New().Run(ctx, endpoint.Workflows, &workflow.Request{
+		Method:  r.Method,
+		Path:    path,
+		Params:  params,
+		Query:   query,
+		Headers: headers,
+		Body:    body,
+		Env:     env,
+	})
+
+	var (
+		unsupportedErr *workflow.UnsupportedNodeError
+		expressionErr  *agent.ExpressionError
+	)
+
+	switch {
+	case errors.As(err, &unsupportedErr), errors.As(err, &expressionErr), errors.Is(err, workflow.ErrMaxIterations), errors.Is(err, workflow.ErrMaxSteps):
+		_ = response.BadRequest(w, r, err)
+		return
+	case err != nil:
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	for key, value := range res.Headers {
+		w.Header().Set(key, value)
+	}
+
+	if res.Body == nil {
+		w.WriteHeader(res.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Status)
+
+	_ = json.NewEncoder(w).Encode(res.Body)
+}
@@ -31,7 +31,7 @@ func (h *Handler) GetFeatures(w http.ResponseWriter, r *http.Request) {
 		IsGoogleAuthEnabled: isGoogleAuthEnabled,
 		IsAwsConfigured:     isAwsConfigured,
 		Version:             "0.0.1",
-		AvailableModels:     agent.AvailableCatalogs,
+		AvailableModels:     h.agent.Catalogs(),
 		AvailableLanguages:  agent.AvailableCodeGenerationOptions,
 	})
 }
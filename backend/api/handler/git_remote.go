@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/mujhtech/b0/api/dto"
+	"github.com/mujhtech/b0/api/middleware"
+	"github.com/mujhtech/b0/internal/pkg/gitremote"
+	"github.com/mujhtech/b0/internal/pkg/request"
+	"github.com/mujhtech/b0/internal/pkg/response"
+	"github.com/mujhtech/b0/internal/util"
+	jobHandlers "github.com/mujhtech/b0/job/handlers"
+	"github.com/mujhtech/b0/services"
+)
+
+// GetGitRemote returns the git remote of the project without its credentials
+func (h *Handler) GetGitRemote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	projectId, err := getProjectIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	findProjectService := services.FindProjectService{
+		ProjectID:   projectId,
+		ProjectRepo: h.store.ProjectRepo,
+		User:        session.User,
+	}
+
+	project, err := findProjectService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	remote, err := jobHandlers.GetGitRemote(ctx, h.secretManager, project.ID)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	if remote == nil {
+		_ = response.NotFound(w, r, errors.New("the project has no git remote"))
+		return
+	}
+
+	_ = response.Ok(w, r, "git remote retrieved", toGitRemoteResponse(remote))
+}
+
+// UpdateGitRemote replaces the git remote of the project and its credentials, the code is pushed by the push action
+func (h *Handler) UpdateGitRemote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	projectId, err := getProjectIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	dst := new(dto.GitRemoteRequestDto)
+
+	if err := request.ReadBody(r, dst); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	remote := &gitremote.Remote{
+		URL:           dst.URL,
+		Branch:        dst.Branch,
+		Username:      dst.Username,
+		Password:      dst.Password,
+		SSHKey:        dst.SSHKey,
+		SSHPassphrase: dst.SSHPassphrase,
+		KnownHosts:    dst.KnownHosts,
+	}
+
+	if err := remote.Validate(); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	findProjectService := services.FindProjectService{
+		ProjectID:   projectId,
+		ProjectRepo: h.store.ProjectRepo,
+		User:        session.User,
+	}
+
+	project, err := findProjectService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	rawSecret, err := util.MarshalJSON(remote)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.secretManager.SetSecret(ctx, jobHandlers.GitRemoteSecretName(project.ID), rawSecret); err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "git remote saved", toGitRemoteResponse(remote))
+}
+
+func toGitRemoteResponse(remote *gitremote.Remote) dto.GitRemoteResponseDto {
+	auth := "none"
+
+	switch {
+	case remote.SSHKey != "":
+		auth = "ssh_key"
+	case remote.Password != "":
+		auth = "password"
+	}
+
+	remoteURL := remote.URL
+
+	// a password written in the url is a credential too
+	if u, err := url.Parse(remote.URL); err == nil {
+		remoteURL = u.Redacted()
+	}
+
+	return dto.GitRemoteResponseDto{
+		URL:    remoteURL,
+		Branch: remote.BranchOrDefault(),
+		Auth:   auth,
+	}
+}
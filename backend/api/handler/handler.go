@@ -7,6 +7,7 @@ import (
 	"github.com/mujhtech/b0/config"
 	"github.com/mujhtech/b0/database/store"
 	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/artifact"
 	"github.com/mujhtech/b0/internal/pkg/billing/stripe"
 	"github.com/mujhtech/b0/internal/pkg/container"
 	secretmanager "github.com/mujhtech/b0/internal/pkg/secret_manager"
@@ -25,6 +26,7 @@ type Handler struct {
 	docker        *container.Container
 	billing       stripe.Stripe
 	secretManager secretmanager.SecretManager
+	artifacts     artifact.Store
 }
 
 func New(
@@ -38,6 +40,7 @@ func New(
 	docker *container.Container,
 	billing stripe.Stripe,
 	secretManager secretmanager.SecretManager,
+	artifacts artifact.Store,
 ) (*Handler, error) {
 
 	return &Handler{
@@ -51,5 +54,6 @@ func New(
 		docker:        docker,
 		billing:       billing,
 		secretManager: secretManager,
+		artifacts:     artifacts,
 	}, nil
 }
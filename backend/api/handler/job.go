@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/response"
+)
+
+const (
+	JobParamId = "job_id"
+)
+
+func getJobIdFromPath(r *http.Request) (string, error) {
+	rawRef, err := pathParamOrError(r, JobParamId)
+	if err != nil {
+		return "", err
+	}
+
+	return url.PathUnescape(rawRef)
+}
+
+// GetJobs lists the latest jobs of the project newest first
+func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project, ok := h.findProjectFromPath(w, r)
+
+	if !ok {
+		return
+	}
+
+	jobs, err := h.store.JobRepo.FindJobs(ctx, store.JobFilter{
+		ProjectID: project.ID,
+		Limit:     uint64(ParsePerPage(r)),
+	})
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "jobs retrieved", jobs)
+}
+
+// GetJob returns the state of a job of the project, the callers of the project actions and the chat poll it
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jobId, err := getJobIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	project, ok := h.findProjectFromPath(w, r)
+
+	if !ok {
+		return
+	}
+
+	job, err := h.store.JobRepo.FindJobByID(ctx, jobId)
+
+	if err != nil {
+		projectError(w, r, err)
+		return
+	}
+
+	// a job of another project isn't found
+	if job.ProjectID.String != project.ID {
+		_ = response.NotFound(w, r, store.ErrNotFound)
+		return
+	}
+
+	_ = response.Ok(w, r, "job retrieved", job)
+}
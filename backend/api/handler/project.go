@@ -6,16 +6,18 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/google/uuid"
 	"github.com/mujhtech/b0/api/dto"
 	"github.com/mujhtech/b0/api/middleware"
 	"github.com/mujhtech/b0/database/models"
 	"github.com/mujhtech/b0/database/store"
+	b0Errors "github.com/mujhtech/b0/errors"
 	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/openapi"
 	"github.com/mujhtech/b0/internal/pkg/request"
 	"github.com/mujhtech/b0/internal/pkg/response"
 	"github.com/mujhtech/b0/internal/util"
 	"github.com/mujhtech/b0/job"
+	"github.com/mujhtech/b0/job/handlers"
 	"github.com/mujhtech/b0/services"
 	"github.com/rs/zerolog"
 )
@@ -135,7 +137,7 @@ func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
 
 	if dst.Model != "" {
 		var agentModelErr error
-		catalog, agentModelErr := agent.GetModelCatalog(dst.Model)
+		catalog, agentModelErr = h.agent.GetModelCatalog(dst.Model)
 		if agentModelErr != nil {
 			_ = response.BadRequest(w, r, agentModelErr)
 			return
@@ -176,7 +178,7 @@ func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	agentProjectTitleAndSlug, agentToken, err := h.agent.GenerateTitleAndSlug(ctx, dst.Prompt, agent.WithModel(catalog.Model))
+	agentProjectTitleAndSlug, agentToken, err := h.agent.GenerateTitleAndSlug(ctx, dst.Prompt, agent.WithModel(catalog.Model), agent.WithPremium(session.User.CanUsePremiumModels()))
 
 	if err != nil {
 		_ = response.InternalServerError(w, r, err)
@@ -214,21 +216,27 @@ func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = h.store.AIUsageRepo.CreateAIUsage(ctx, &models.AIUsage{
-		ID:          uuid.New().String(),
-		ProjectID:   project.ID,
-		OwnerID:     project.OwnerID,
-		Model:       project.Model.String,
-		UsageType:   "project_creation",
-		InputToken:  agentToken.Input,
-		OutputToken: agentToken.Output,
-		IsPremium:   catalog.IsPremium,
-	}); err != nil {
+	createAIUsageService := services.CreateAIUsageService{
+		AIUsageRepo:        h.store.AIUsageRepo,
+		AIUsagePayloadRepo: h.store.AIUsagePayloadRepo,
+		Usage: &models.AIUsage{
+			ProjectID: project.ID,
+			OwnerID:   project.OwnerID,
+			Model:     project.Model.String,
+			UsageType: "project_creation",
+			IsPremium: catalog.IsPremium,
+		},
+		AgentToken:      agentToken,
+		PromptRetention: h.cfg.Agent.PromptRetention(),
+	}
+
+	if _, err = createAIUsageService.Run(ctx); err != nil {
 		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create AI usage")
 	}
 
-	if err = h.job.Client.Enqueue(job.QueueNameDefault, job.JobNameWorkflowCreate, &job.ClientPayload{
-		Data: []byte(project.ID),
+	if _, err = h.job.Client.Enqueue(ctx, job.QueueNameDefault, job.JobNameWorkflowCreate, &job.ClientPayload{
+		Data:      []byte(project.ID),
+		ProjectID: project.ID,
 	}); err != nil {
 		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to enqueue job")
 	}
@@ -395,26 +403,169 @@ func (h *Handler) ProjectAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var jobName job.JobName
+
 	switch dst.Action {
 	case "deploy":
-
-		if err = h.job.Client.Enqueue(job.QueueNameDefault, job.JobNameProjectDeploy, &job.ClientPayload{
-			Data: []byte(project.ID),
-		}); err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to enqueue job")
-		}
-
+		jobName = job.JobNameProjectDeploy
 	case "export":
+		jobName = job.JobNameProjectExport
+	case "push":
+		jobName = job.JobNameProjectPush
+	case "import":
+
+		h.importOpenAPI(w, r, session.User, project, dst)
+		return
 
-		if err = h.job.Client.Enqueue(job.QueueNameDefault, job.JobNameProjectExport, &job.ClientPayload{
-			Data: []byte(project.ID),
-		}); err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to enqueue job")
-		}
 	default:
 		_ = response.BadRequest(w, r, nil)
 		return
 	}
 
-	_ = response.Ok(w, r, "ok", nil)
+	jobId, err := h.job.Client.Enqueue(ctx, job.QueueNameDefault, jobName, &job.ClientPayload{
+		Data:      []byte(project.ID),
+		ProjectID: project.ID,
+	})
+
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to enqueue job")
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "ok", dto.ProjectActionResponseDto{
+		JobID: jobId,
+	})
+}
+
+// importOpenAPI creates the endpoints of the OpenAPI document, the agent optionally fills in their workflows
+func (h *Handler) importOpenAPI(w http.ResponseWriter, r *http.Request, user *models.User, project *models.Project, dst *dto.ProjectActionRequestDto) {
+	ctx := r.Context()
+
+	doc, err := openapi.Parse([]byte(dst.Spec))
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	importOpenAPIService := services.ImportOpenAPIService{
+		ProjectID:    project.ID,
+		ProjectRepo:  h.store.ProjectRepo,
+		EndpointRepo: h.store.EndpointRepo,
+		User:         user,
+		Document:     doc,
+	}
+
+	endpoints, err := importOpenAPIService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	if dst.GenerateWorkflows {
+		for _, endpoint := range endpoints {
+			payload, err := util.MarshalJSON(handlers.UpdateWorkflowPayload{
+				ProjectId:  project.ID,
+				EndpointId: endpoint.ID,
+				Prompt:     fmt.Sprintf("Implement the %s %s endpoint: %s", endpoint.Method, endpoint.Path, endpoint.Description.String),
+				Modify:     true,
+			})
+
+			if err != nil {
+				_ = response.InternalServerError(w, r, err)
+				return
+			}
+
+			if _, err = h.job.Client.Enqueue(ctx, job.QueueNameDefault, job.JobNameWorkflowUpdate, &job.ClientPayload{
+				Data:      payload,
+				ProjectID: project.ID,
+			}); err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Str("endpoint_id", endpoint.ID).Msg("failed to enqueue job")
+			}
+		}
+	}
+
+	_ = response.Ok(w, r, "endpoints imported successfully", endpoints)
+}
+
+// GetProjectOpenAPI returns the OpenAPI document of the project endpoints
+func (h *Handler) GetProjectOpenAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return
+	}
+
+	projectId, err := getProjectIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	exportOpenAPIService := services.ExportOpenAPIService{
+		ProjectID:    projectId,
+		ProjectRepo:  h.store.ProjectRepo,
+		EndpointRepo: h.store.EndpointRepo,
+		User:         session.User,
+	}
+
+	doc, err := exportOpenAPIService.Run(ctx)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.JSON(w, r, http.StatusOK, doc)
+}
+
+// findProjectFromPath returns the project of the path owned by the user, the response is written when it fails
+func (h *Handler) findProjectFromPath(w http.ResponseWriter, r *http.Request) (*models.Project, bool) {
+	ctx := r.Context()
+
+	session, ok := middleware.GetAuthSession(ctx)
+
+	if !ok {
+		_ = response.Unauthorized(w, r, nil)
+		return nil, false
+	}
+
+	projectId, err := getProjectIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return nil, false
+	}
+
+	findProjectService := services.FindProjectService{
+		ProjectID:   projectId,
+		ProjectRepo: h.store.ProjectRepo,
+		User:        session.User,
+	}
+
+	project, err := findProjectService.Run(ctx)
+
+	if err != nil {
+		projectError(w, r, err)
+		return nil, false
+	}
+
+	return project, true
+}
+
+func projectError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		_ = response.NotFound(w, r, err)
+	case errors.Is(err, b0Errors.ErrNotAuthorized):
+		_ = response.Forbidden(w, r, err)
+	default:
+		_ = response.InternalServerError(w, r, err)
+	}
 }
@@ -9,7 +9,6 @@ import (
 	"github.com/mujhtech/b0/internal/pkg/request"
 	"github.com/mujhtech/b0/internal/pkg/response"
 	"github.com/mujhtech/b0/internal/util"
-	jobHandlers "github.com/mujhtech/b0/job/handlers"
 	"github.com/mujhtech/b0/services"
 )
 
@@ -64,7 +63,13 @@ func (h *Handler) GetScret(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	secrets, err := jobHandlers.GetEnvVars(ctx, h.secretManager, project.ID, endpointId)
+	findEnvVarsService := services.FindEnvVarsService{
+		SecretManager: h.secretManager,
+		ProjectID:     project.ID,
+		EndpointID:    endpointId,
+	}
+
+	secrets, err := findEnvVarsService.Run(ctx)
 
 	if err != nil {
 		_ = response.InternalServerError(w, r, err)
@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/api/dto"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/request"
+	"github.com/mujhtech/b0/internal/pkg/response"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+	"github.com/rs/zerolog"
+)
+
+const (
+	WebhookParamId         = "webhook_id"
+	WebhookDeliveryParamId = "delivery_id"
+)
+
+func getWebhookIdFromPath(r *http.Request) (string, error) {
+	rawRef, err := pathParamOrError(r, WebhookParamId)
+	if err != nil {
+		return "", err
+	}
+
+	return url.PathUnescape(rawRef)
+}
+
+func getWebhookDeliveryIdFromPath(r *http.Request) (string, error) {
+	rawRef, err := pathParamOrError(r, WebhookDeliveryParamId)
+	if err != nil {
+		return "", err
+	}
+
+	return url.PathUnescape(rawRef)
+}
+
+// GetWebhooks lists the webhooks of the project
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project, ok := h.findProjectFromPath(w, r)
+
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.store.WebhookRepo.FindWebhooksByProjectID(ctx, project.ID)
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "webhooks retrieved", webhooks)
+}
+
+// CreateWebhook subscribes a webhook to events of the project, its signing secret is only returned here
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dst := new(dto.CreateWebhookRequestDto)
+
+	if err := request.ReadBody(r, dst); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	if err := validateWebhook(ctx, dst.URL, dst.Events); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	project, ok := h.findProjectFromPath(w, r)
+
+	if !ok {
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	hook := &models.Webhook{
+		ID:          uuid.New().String(),
+		OwnerID:     project.OwnerID,
+		ProjectID:   project.ID,
+		URL:         dst.URL,
+		Secret:      secret,
+		Description: null.NewString(dst.Description, dst.Description != ""),
+		Events:      dst.Events,
+		IsActive:    true,
+	}
+
+	if err := h.store.WebhookRepo.CreateWebhook(ctx, hook); err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Created(w, r, "webhook created", dto.WebhookSecretResponseDto{
+		Webhook: hook,
+		Secret:  secret,
+	})
+}
+
+// UpdateWebhook changes the url, the events or the state of the webhook, a rotated secret is returned once
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dst := new(dto.UpdateWebhookRequestDto)
+
+	if err := request.ReadBody(r, dst); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	hook, ok := h.findProjectWebhook(w, r)
+
+	if !ok {
+		return
+	}
+
+	if dst.URL != "" {
+		hook.URL = dst.URL
+	}
+
+	if dst.Events != nil {
+		hook.Events = dst.Events
+	}
+
+	if err := validateWebhook(ctx, hook.URL, hook.Events); err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	if dst.Description != nil {
+		hook.Description = null.NewString(*dst.Description, *dst.Description != "")
+	}
+
+	if dst.IsActive != nil {
+		hook.IsActive = *dst.IsActive
+	}
+
+	if dst.RotateSecret {
+		secret, err := webhook.NewSecret()
+
+		if err != nil {
+			_ = response.InternalServerError(w, r, err)
+			return
+		}
+
+		hook.Secret = secret
+	}
+
+	if err := h.store.WebhookRepo.UpdateWebhook(ctx, hook); err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	if dst.RotateSecret {
+		_ = response.Ok(w, r, "webhook updated", dto.WebhookSecretResponseDto{
+			Webhook: hook,
+			Secret:  hook.Secret,
+		})
+		return
+	}
+
+	_ = response.Ok(w, r, "webhook updated", hook)
+}
+
+// DeleteWebhook deletes the webhook, its pending deliveries are dropped
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	hook, ok := h.findProjectWebhook(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := h.store.WebhookRepo.DeleteWebhook(ctx, hook.ID); err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "webhook deleted", nil)
+}
+
+// GetWebhookDeliveries lists the latest deliveries of the webhook newest first
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	hook, ok := h.findProjectWebhook(w, r)
+
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.store.WebhookDeliveryRepo.FindWebhookDeliveries(ctx, store.WebhookDeliveryFilter{
+		WebhookID: hook.ID,
+		Limit:     uint64(ParsePerPage(r)),
+	})
+
+	if err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "webhook deliveries retrieved", deliveries)
+}
+
+// RedeliverWebhookDelivery sends the payload of a delivery again as a new delivery
+func (h *Handler) RedeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	deliveryId, err := getWebhookDeliveryIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return
+	}
+
+	hook, ok := h.findProjectWebhook(w, r)
+
+	if !ok {
+		return
+	}
+
+	delivery, err := h.store.WebhookDeliveryRepo.FindWebhookDeliveryByID(ctx, deliveryId)
+
+	if err != nil {
+		projectError(w, r, err)
+		return
+	}
+
+	if delivery.WebhookID != hook.ID {
+		_ = response.NotFound(w, r, store.ErrNotFound)
+		return
+	}
+
+	if !hook.IsActive {
+		_ = response.BadRequest(w, r, errors.New("the webhook is disabled"))
+		return
+	}
+
+	redelivery := &models.WebhookDelivery{
+		ID:           uuid.New().String(),
+		WebhookID:    hook.ID,
+		ProjectID:    delivery.ProjectID,
+		Event:        delivery.Event,
+		Payload:      delivery.Payload,
+		Status:       models.WebhookDeliveryStatusPending,
+		RedeliveryOf: null.StringFrom(delivery.ID),
+	}
+
+	if err := h.store.WebhookDeliveryRepo.CreateWebhookDelivery(ctx, redelivery); err != nil {
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	if _, err := h.job.EnqueueWebhookDelivery(ctx, redelivery.ID); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to enqueue job")
+		_ = response.InternalServerError(w, r, err)
+		return
+	}
+
+	_ = response.Ok(w, r, "webhook delivery redelivered", redelivery)
+}
+
+// findProjectWebhook returns the webhook of the path, a webhook of another project isn't found
+func (h *Handler) findProjectWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	webhookId, err := getWebhookIdFromPath(r)
+
+	if err != nil {
+		_ = response.BadRequest(w, r, err)
+		return nil, false
+	}
+
+	project, ok := h.findProjectFromPath(w, r)
+
+	if !ok {
+		return nil, false
+	}
+
+	hook, err := h.store.WebhookRepo.FindWebhookByID(r.Context(), webhookId)
+
+	if err != nil {
+		projectError(w, r, err)
+		return nil, false
+	}
+
+	if hook.ProjectID != project.ID {
+		_ = response.NotFound(w, r, store.ErrNotFound)
+		return nil, false
+	}
+
+	return hook, true
+}
+
+func validateWebhook(ctx context.Context, rawURL string, events []webhook.EventType) error {
+	if err := webhook.ValidateURL(ctx, rawURL); err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return errors.New("the webhook needs at least one event")
+	}
+
+	for _, event := range events {
+		if !webhook.IsValidEvent(event) {
+			return fmt.Errorf("%w: %s", webhook.ErrInvalidEvent, event)
+		}
+	}
+
+	return nil
+}
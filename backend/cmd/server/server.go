@@ -15,6 +15,7 @@ import (
 	"github.com/mujhtech/b0/database/store"
 	"github.com/mujhtech/b0/http"
 	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/artifact"
 	"github.com/mujhtech/b0/internal/pkg/billing/stripe"
 	"github.com/mujhtech/b0/internal/pkg/container"
 	"github.com/mujhtech/b0/internal/pkg/pubsub"
@@ -119,7 +120,7 @@ func startServer(configFile string, logLevel string) error {
 
 	sse := sse.NewStreamer(pubsub)
 
-	job, err := job.NewJob(cfg, ctx, redis)
+	job, err := job.NewJob(cfg, ctx, redis, store)
 
 	if err != nil {
 		return fmt.Errorf("failed to initialize job: %w", err)
@@ -137,6 +138,12 @@ func startServer(configFile string, logLevel string) error {
 		return fmt.Errorf("failed to create secret manager: %w", err)
 	}
 
+	artifacts, err := artifact.New(cfg)
+
+	if err != nil {
+		return fmt.Errorf("failed to create artifact store: %w", err)
+	}
+
 	app, err := api.New(
 		cfg,
 		ctx,
@@ -148,6 +155,7 @@ func startServer(configFile string, logLevel string) error {
 		container,
 		stripe.New(cfg),
 		secretManager,
+		artifacts,
 	)
 
 	if err != nil {
@@ -165,7 +173,7 @@ func startServer(configFile string, logLevel string) error {
 	g, gCtx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
-		return job.RegisterAndStart(cfg, store, agent, sse, container, secretManager)
+		return job.RegisterAndStart(cfg, store, agent, sse, container, secretManager, artifacts)
 	})
 
 	gHTTP, shutdownHTTP := server.ListenAndServe()
@@ -190,6 +198,7 @@ func startServer(configFile string, logLevel string) error {
 	}
 
 	job.Executor.Stop()
+	job.Scheduler.Stop()
 
 	logger.Info().Msg("waiting for all goroutines to finish")
 	err = g.Wait()
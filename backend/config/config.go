@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/kelseyhightower/envconfig"
 )
@@ -55,7 +57,8 @@ var DefaultConfig = &Config{
 		FromAddress: "b0 <no-reply@b0.dev>",
 	},
 	Job: Job{
-		Concurrency: 10,
+		Concurrency:    10,
+		DeployTestHost: "localhost",
 	},
 	Pubsub: Pubsub{
 		Provider:       PubsubProviderInMemory,
@@ -79,6 +82,25 @@ var DefaultConfig = &Config{
 	SecretManager: SecretManager{
 		Provider: SecretManagerProviderLocal,
 	},
+	Artifact: Artifact{
+		Dir:                   filepath.Join(os.TempDir(), "b0-artifacts"),
+		DownloadExpiryMinutes: 60,
+	},
+	Git: Git{
+		AuthorName:  "b0",
+		AuthorEmail: "noreply@b0.dev",
+	},
+	Webhook: Webhook{
+		TimeoutSeconds:        10,
+		MaxRetries:            8,
+		RetryBaseDelaySeconds: 30,
+		RetryMaxDelayMinutes:  60,
+	},
+	Agent: Agent{
+		RepairAttempts:      2,
+		BuildFixAttempts:    3,
+		PromptRetentionDays: 30,
+	},
 }
 
 func LoadConfig() (*Config, error) {
@@ -111,5 +133,51 @@ func (c *Config) validate() error {
 		return fmt.Errorf("database dsn is empty")
 	}
 
+	if err := c.Agent.CustomProviders.validate(); err != nil {
+		return err
+	}
+
+	switch c.Agent.Replay.Mode {
+	case "":
+	case AgentReplayModeRecord, AgentReplayModeReplay:
+		if c.Agent.Replay.Dir == "" {
+			return fmt.Errorf("agent replay dir cannot be empty")
+		}
+	default:
+		return fmt.Errorf("agent replay mode %s is not supported, use %s or %s", c.Agent.Replay.Mode, AgentReplayModeRecord, AgentReplayModeReplay)
+	}
+
+	return nil
+}
+
+func (c CustomProviders) validate() error {
+	names := map[string]bool{}
+
+	for _, provider := range c {
+		if provider.Name == "" {
+			return fmt.Errorf("custom provider name cannot be empty")
+		}
+
+		if names[provider.Name] {
+			return fmt.Errorf("custom provider %s is declared more than once", provider.Name)
+		}
+
+		names[provider.Name] = true
+
+		if provider.BaseURL == "" {
+			return fmt.Errorf("custom provider %s base url cannot be empty", provider.Name)
+		}
+
+		if len(provider.Models) == 0 {
+			return fmt.Errorf("custom provider %s must declare at least one model", provider.Name)
+		}
+
+		for _, model := range provider.Models {
+			if model.Model == "" {
+				return fmt.Errorf("custom provider %s model cannot be empty", provider.Name)
+			}
+		}
+	}
+
 	return nil
 }
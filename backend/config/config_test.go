@@ -80,6 +80,29 @@ func Test_LoadConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "custom_agent_providers",
+			envVars: map[string]string{
+				"AGENT_CUSTOM_PROVIDERS": `[{"name": "ollama", "base_url": "http://localhost:11434/v1", "models": [{"name": "Llama 3.1", "model": "llama3.1"}]}]`,
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				require.Equal(t, CustomProviders{
+					{
+						Name:    "ollama",
+						BaseURL: "http://localhost:11434/v1",
+						Models:  []CustomProviderModel{{Name: "Llama 3.1", Model: "llama3.1"}},
+					},
+				}, cfg.Agent.CustomProviders)
+			},
+		},
+		{
+			name: "custom_agent_provider_without_base_url",
+			envVars: map[string]string{
+				"AGENT_CUSTOM_PROVIDERS": `[{"name": "vllm", "models": [{"model": "qwen2.5-coder"}]}]`,
+			},
+			wantErr:    true,
+			wantErrMsg: "custom provider vllm base url cannot be empty",
+		},
 		{
 			name: "empty_database_config",
 			envVars: map[string]string{
@@ -117,3 +140,51 @@ func Test_LoadConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_Config_ValidateAgentReplay(t *testing.T) {
+	tests := []struct {
+		name       string
+		replay     AgentReplay
+		wantErrMsg string
+	}{
+		{
+			name: "replay_disabled",
+		},
+		{
+			name:   "record",
+			replay: AgentReplay{Mode: AgentReplayModeRecord, Dir: "testdata/replay"},
+		},
+		{
+			name:   "replay",
+			replay: AgentReplay{Mode: AgentReplayModeReplay, Dir: "testdata/replay"},
+		},
+		{
+			name:       "replay_without_dir",
+			replay:     AgentReplay{Mode: AgentReplayModeReplay},
+			wantErrMsg: "agent replay dir cannot be empty",
+		},
+		{
+			name:       "unsupported_mode",
+			replay:     AgentReplay{Mode: "replya", Dir: "testdata/replay"},
+			wantErrMsg: "agent replay mode replya is not supported, use record or replay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Database: Database{Driver: "postgres", Host: "localhost", Port: 5432},
+				Agent:    Agent{Replay: tt.replay},
+			}
+
+			err := cfg.validate()
+
+			if tt.wantErrMsg != "" {
+				require.EqualError(t, err, tt.wantErrMsg)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"time"
@@ -9,6 +10,7 @@ import (
 type DatabaseDriver string
 type PubsubProvider string
 type CacheProvider string
+type AgentReplayMode string
 type TelemetryProvider string
 type SecretManagerProvider string
 
@@ -28,6 +30,9 @@ const (
 	CacheProviderRedis    CacheProvider = "redis"
 	CacheProviderInMemory CacheProvider = "inmemory"
 
+	AgentReplayModeRecord AgentReplayMode = "record"
+	AgentReplayModeReplay AgentReplayMode = "replay"
+
 	TelemetryProviderSentry TelemetryProvider = "sentry"
 	TelemetryProviderOtel   TelemetryProvider = "otel"
 	TelemetryProviderNone   TelemetryProvider = "none"
@@ -55,6 +60,52 @@ type Config struct {
 	Integrations  Integrations  `json:"integrations"`
 	Stripe        Stripe        `json:"stripe"`
 	SecretManager SecretManager `json:"secret_manager"`
+	Artifact      Artifact      `json:"artifact"`
+	Git           Git           `json:"git"`
+	Webhook       Webhook       `json:"webhook"`
+}
+
+type Artifact struct {
+	// Dir is where the local artifact store keeps the project exports
+	Dir string `json:"dir" envconfig:"ARTIFACT_DIR"`
+	// DownloadExpiryMinutes is how long a download link stays valid, the artifacts are pruned after it
+	DownloadExpiryMinutes int `json:"download_expiry_minutes" envconfig:"ARTIFACT_DOWNLOAD_EXPIRY_MINUTES"`
+}
+
+// DownloadExpiry returns how long a download link stays valid
+func (a Artifact) DownloadExpiry() time.Duration {
+	return time.Duration(a.DownloadExpiryMinutes) * time.Minute
+}
+
+// Git is the author of the commits pushed to the git remotes of the projects
+type Git struct {
+	AuthorName  string `json:"author_name" envconfig:"GIT_AUTHOR_NAME"`
+	AuthorEmail string `json:"author_email" envconfig:"GIT_AUTHOR_EMAIL"`
+}
+
+type Webhook struct {
+	// TimeoutSeconds is how long a delivery waits for the webhook to answer
+	TimeoutSeconds int `json:"timeout_seconds" envconfig:"WEBHOOK_TIMEOUT_SECONDS"`
+	// MaxRetries is how many times a failed delivery is retried, the delay doubles from RetryBaseDelaySeconds
+	// up to RetryMaxDelayMinutes
+	MaxRetries            int `json:"max_retries" envconfig:"WEBHOOK_MAX_RETRIES"`
+	RetryBaseDelaySeconds int `json:"retry_base_delay_seconds" envconfig:"WEBHOOK_RETRY_BASE_DELAY_SECONDS"`
+	RetryMaxDelayMinutes  int `json:"retry_max_delay_minutes" envconfig:"WEBHOOK_RETRY_MAX_DELAY_MINUTES"`
+}
+
+// Timeout returns how long a delivery waits for the webhook to answer
+func (w Webhook) Timeout() time.Duration {
+	return time.Duration(w.TimeoutSeconds) * time.Second
+}
+
+// RetryBaseDelay returns the delay before the first retry of a delivery
+func (w Webhook) RetryBaseDelay() time.Duration {
+	return time.Duration(w.RetryBaseDelaySeconds) * time.Second
+}
+
+// RetryMaxDelay returns the longest delay between two retries of a delivery
+func (w Webhook) RetryMaxDelay() time.Duration {
+	return time.Duration(w.RetryMaxDelayMinutes) * time.Minute
 }
 
 type SecretManager struct {
@@ -95,6 +146,70 @@ type Agent struct {
 	AnthropicKey string `json:"anthropic_key" envconfig:"AGENT_ANTHROPIC_KEY"`
 	GeminiKey    string `json:"gemini_key" envconfig:"AGENT_GEMINI_KEY"`
 	XAIKey       string `json:"xai_key" envconfig:"AGENT_XAI_KEY"`
+	// RepairAttempts is the number of follow-up turns the agent may use to fix an invalid response
+	RepairAttempts int `json:"repair_attempts" envconfig:"AGENT_REPAIR_ATTEMPTS"`
+	// BuildFixAttempts is the number of times the agent may fix generated code that fails to build before the deploy is blocked
+	BuildFixAttempts int `json:"build_fix_attempts" envconfig:"AGENT_BUILD_FIX_ATTEMPTS"`
+	// PromptRetentionDays is how long prompts and responses are kept, 0 disables storing them
+	PromptRetentionDays int         `json:"prompt_retention_days" envconfig:"AGENT_PROMPT_RETENTION_DAYS"`
+	Routes              AgentRoutes `json:"routes"`
+	// CustomProviders are self-hosted OpenAI compatible endpoints (Ollama, vLLM, LM Studio...), a JSON array in the environment
+	CustomProviders CustomProviders `json:"custom_providers" envconfig:"AGENT_CUSTOM_PROVIDERS"`
+	Replay          AgentReplay     `json:"replay"`
+}
+
+// AgentReplay records the completions of the providers to fixture files, or answers from them instead of the providers
+type AgentReplay struct {
+	Mode AgentReplayMode `json:"mode" envconfig:"AGENT_REPLAY_MODE"`
+	Dir  string          `json:"dir" envconfig:"AGENT_REPLAY_DIR"`
+}
+
+// CustomProvider is an OpenAI compatible endpoint serving the listed models
+type CustomProvider struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url"`
+	Key     string `json:"key"`
+	// StructuredOutput is set when the endpoint enforces the json_schema response format
+	StructuredOutput bool                  `json:"structured_output"`
+	Models           []CustomProviderModel `json:"models"`
+}
+
+type CustomProviderModel struct {
+	// Name is the display name, the model id is used when empty
+	Name      string `json:"name"`
+	Model     string `json:"model"`
+	IsPremium bool   `json:"is_premium"`
+}
+
+type CustomProviders []CustomProvider
+
+// Decode implements envconfig.Decoder
+func (c *CustomProviders) Decode(value string) error {
+	providers := CustomProviders{}
+
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &providers); err != nil {
+			return err
+		}
+	}
+
+	*c = providers
+
+	return nil
+}
+
+// AgentRoutes holds the ordered list of models tried for each agent task, comma separated in the environment
+type AgentRoutes struct {
+	TitleAndSlug       []string `json:"title_and_slug" envconfig:"AGENT_ROUTE_TITLE_AND_SLUG"`
+	WorkflowGeneration []string `json:"workflow_generation" envconfig:"AGENT_ROUTE_WORKFLOW_GENERATION"`
+	WorkflowUpdate     []string `json:"workflow_update" envconfig:"AGENT_ROUTE_WORKFLOW_UPDATE"`
+	ChatIntent         []string `json:"chat_intent" envconfig:"AGENT_ROUTE_CHAT_INTENT"`
+	CodeGeneration     []string `json:"code_generation" envconfig:"AGENT_ROUTE_CODE_GENERATION"`
+}
+
+// PromptRetention returns how long prompts and responses are kept
+func (a Agent) PromptRetention() time.Duration {
+	return time.Duration(a.PromptRetentionDays) * 24 * time.Hour
 }
 
 type Sentry struct {
@@ -188,6 +303,9 @@ type Email struct {
 
 type Job struct {
 	Concurrency int `json:"concurrency" envconfig:"JOB_CONCURRENCY"`
+	// DeployTestHost is the host the worker reaches the published ports of the deployed containers on to run their
+	// test suite, localhost by default, the test suites are skipped when it's empty
+	DeployTestHost string `json:"deploy_test_host" envconfig:"JOB_DEPLOY_TEST_HOST"`
 }
 
 type Pubsub struct {
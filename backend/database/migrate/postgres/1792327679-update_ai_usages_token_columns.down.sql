@@ -0,0 +1,11 @@
+ALTER TABLE "ai_usages" ADD COLUMN "input_tokens" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "ai_usages" ADD COLUMN "output_tokens" TEXT NOT NULL DEFAULT '';
+
+UPDATE ai_usages SET input_tokens = p.input, output_tokens = p.output
+FROM ai_usage_payloads p WHERE p.ai_usage_id = ai_usages.id;
+
+ALTER TABLE "ai_usages" DROP COLUMN "prompt_tokens";
+ALTER TABLE "ai_usages" DROP COLUMN "completion_tokens";
+ALTER TABLE "ai_usages" DROP COLUMN "cached_tokens";
+
+DROP TABLE IF EXISTS ai_usage_payloads;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS ai_usage_payloads (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+	ai_usage_id uuid NOT NULL REFERENCES ai_usages (id) ON DELETE CASCADE,
+    owner_id uuid NOT NULL REFERENCES users (id),
+	input TEXT NOT NULL,
+    output TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS ai_usage_payloads_ai_usage_id_idx ON ai_usage_payloads (ai_usage_id);
+CREATE INDEX IF NOT EXISTS ai_usage_payloads_expires_at_idx ON ai_usage_payloads (expires_at);
+
+INSERT INTO ai_usage_payloads (ai_usage_id, owner_id, input, output, expires_at)
+SELECT id, owner_id, input_tokens, output_tokens, CURRENT_TIMESTAMP + INTERVAL '30 days' FROM ai_usages;
+
+ALTER TABLE "ai_usages" DROP COLUMN "input_tokens";
+ALTER TABLE "ai_usages" DROP COLUMN "output_tokens";
+ALTER TABLE "ai_usages" ADD COLUMN "prompt_tokens" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "ai_usages" ADD COLUMN "completion_tokens" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "ai_usages" ADD COLUMN "cached_tokens" BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS chat_messages (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+	owner_id uuid NOT NULL REFERENCES users (id),
+    project_id uuid NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    endpoint_id uuid NULL DEFAULT NULL REFERENCES endpoints (id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    content TEXT NOT NULL,
+
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS chat_messages_project_id_endpoint_id_created_at_idx ON chat_messages (project_id, endpoint_id, created_at);
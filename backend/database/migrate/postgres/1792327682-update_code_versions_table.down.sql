@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS code_versions_endpoint_id_created_at_idx;
+
+DROP INDEX IF EXISTS code_versions_endpoint_id_version_idx;
+
+ALTER TABLE "code_versions" DROP COLUMN "content_hash";
+
+ALTER TABLE "code_versions" DROP COLUMN "commit_id";
@@ -0,0 +1,7 @@
+ALTER TABLE "code_versions" ADD COLUMN "commit_id" TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE "code_versions" ADD COLUMN "content_hash" TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS code_versions_endpoint_id_version_idx ON code_versions (endpoint_id, version) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS code_versions_endpoint_id_created_at_idx ON code_versions (endpoint_id, created_at);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+	owner_id uuid NOT NULL REFERENCES users (id),
+    project_id uuid NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    description TEXT NULL,
+    events jsonb NOT NULL DEFAULT '[]'::jsonb,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_project_id_idx ON webhooks (project_id) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    webhook_id uuid NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    project_id uuid NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    payload jsonb NOT NULL DEFAULT '{}'::jsonb,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER NULL,
+    response_body TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    redelivery_of uuid NULL DEFAULT NULL REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    delivered_at TIMESTAMP NULL,
+
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries (webhook_id, created_at);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS jobs (
+	id uuid PRIMARY KEY,
+
+    project_id uuid NULL DEFAULT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    queue TEXT NOT NULL,
+    state TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    result TEXT NOT NULL DEFAULT '',
+    transitions jsonb NOT NULL DEFAULT '[]'::jsonb,
+    enqueued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS jobs_project_id_created_at_idx ON jobs (project_id, created_at) WHERE project_id IS NOT NULL;
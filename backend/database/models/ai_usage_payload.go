@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// AIUsagePayload holds the prompt and response of an AIUsage until it expires
+type AIUsagePayload struct {
+	ID        string    `json:"id" db:"id"`
+	AIUsageID string    `json:"ai_usage_id" db:"ai_usage_id"`
+	OwnerID   string    `json:"owner_id" db:"owner_id"`
+	Input     string    `json:"input" db:"input"`
+	Output    string    `json:"output" db:"output"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
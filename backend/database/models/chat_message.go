@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/guregu/null"
+)
+
+type ChatMessageRole string
+
+const (
+	ChatMessageRoleUser      ChatMessageRole = "user"
+	ChatMessageRoleAssistant ChatMessageRole = "assistant"
+)
+
+// ChatMessage is a turn of the conversation between the user and b0 about a project endpoint
+type ChatMessage struct {
+	ID         string          `json:"id" db:"id"`
+	OwnerID    string          `json:"owner_id" db:"owner_id"`
+	ProjectID  string          `json:"project_id" db:"project_id"`
+	EndpointID null.String     `json:"endpoint_id" db:"endpoint_id"`
+	Role       ChatMessageRole `json:"role" db:"role"`
+	Content    string          `json:"content" db:"content"`
+	CreatedAt  time.Time       `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt  time.Time       `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
@@ -1,23 +1,94 @@
 package models
 
 import (
+	"encoding/json"
+	"log"
 	"time"
 
 	"github.com/guregu/null"
+	"github.com/mujhtech/b0/internal/pkg/agent"
 )
 
+// CodeVersionBranchDefault is the branch of the versions until the code is pushed elsewhere
+const CodeVersionBranchDefault = "main"
+
+// CodeVersion is a snapshot of the code of an endpoint, saved on every generation, edit and rollback.
+// Version counts the versions of the endpoint from 1 and ContentHash is the hash of the code.
 type CodeVersion struct {
-	ID         string      `json:"id" db:"id"`
-	OwnerID    string      `json:"owner_id" db:"owner_id"`
-	ProjectID  string      `json:"project_id" db:"project_id"`
-	EndpointID null.String `json:"endpoint_id" db:"endpoint_id"`
-	Version    string      `json:"version" db:"version"`
-	CommitID   string      `json:"commit_id" db:"commit_id"`
-	Branch     string      `json:"branch" db:"branch"`
-	CommitMsg  string      `json:"commit_msg" db:"commit_msg"`
-	Content    interface{} `json:"content" db:"content"`
-	Metadata   interface{} `json:"metadata" db:"metadata"`
-	CreatedAt  time.Time   `json:"created_at,omitempty" db:"created_at,omitempty"`
-	UpdatedAt  time.Time   `json:"updated_at,omitempty" db:"updated_at,omitempty"`
-	DeletedAt  null.Time   `json:"deleted_at,omitempty" db:"deleted_at"`
+	ID          string                `json:"id"`
+	OwnerID     string                `json:"owner_id"`
+	ProjectID   string                `json:"project_id"`
+	EndpointID  null.String           `json:"endpoint_id"`
+	Version     string                `json:"version"`
+	CommitID    string                `json:"commit_id"`
+	Branch      string                `json:"branch"`
+	CommitMsg   string                `json:"commit_msg"`
+	ContentHash string                `json:"content_hash"`
+	Content     *agent.CodeGeneration `json:"content,omitempty"`
+	Metadata    interface{}           `json:"metadata"`
+	CreatedAt   time.Time             `json:"created_at,omitempty"`
+	UpdatedAt   time.Time             `json:"updated_at,omitempty"`
+	DeletedAt   null.Time             `json:"deleted_at,omitempty"`
+}
+
+type CodeVersionFromDB struct {
+	ID          string      `db:"id"`
+	OwnerID     string      `db:"owner_id"`
+	ProjectID   string      `db:"project_id"`
+	EndpointID  null.String `db:"endpoint_id"`
+	Version     string      `db:"version"`
+	CommitID    string      `db:"commit_id"`
+	Branch      string      `db:"branch"`
+	CommitMsg   string      `db:"commit_msg"`
+	ContentHash string      `db:"content_hash"`
+	Content     JSONField   `db:"content"`
+	Metadata    JSONField   `db:"metadata"`
+	CreatedAt   time.Time   `db:"created_at,omitempty"`
+	UpdatedAt   time.Time   `db:"updated_at,omitempty"`
+	DeletedAt   null.Time   `db:"deleted_at"`
+}
+
+// ToCodeVersion decodes the version, a version selected without its content has a nil Content
+func ToCodeVersion(c *CodeVersionFromDB) *CodeVersion {
+	var content *agent.CodeGeneration
+	var metadata interface{}
+
+	if len(c.Content) > 0 {
+		if err := json.Unmarshal(c.Content, &content); err != nil {
+			log.Printf("failed to unmarshal code version content: %v", err)
+		}
+	}
+
+	if len(c.Metadata) > 0 {
+		if err := json.Unmarshal(c.Metadata, &metadata); err != nil {
+			log.Printf("failed to unmarshal code version metadata: %v", err)
+		}
+	}
+
+	return &CodeVersion{
+		ID:          c.ID,
+		OwnerID:     c.OwnerID,
+		ProjectID:   c.ProjectID,
+		EndpointID:  c.EndpointID,
+		Version:     c.Version,
+		CommitID:    c.CommitID,
+		Branch:      c.Branch,
+		CommitMsg:   c.CommitMsg,
+		ContentHash: c.ContentHash,
+		Content:     content,
+		Metadata:    metadata,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+		DeletedAt:   c.DeletedAt,
+	}
+}
+
+func ToCodeVersions(versions []*CodeVersionFromDB) []*CodeVersion {
+	result := []*CodeVersion{}
+
+	for _, v := range versions {
+		result = append(result, ToCodeVersion(v))
+	}
+
+	return result
 }
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/guregu/null"
+)
+
+type JobState string
+
+const (
+	JobStateQueued  JobState = "queued"
+	JobStateRunning JobState = "running"
+	// JobStateRetrying is a job whose attempt failed and that waits for its next attempt
+	JobStateRetrying  JobState = "retrying"
+	JobStateSucceeded JobState = "succeeded"
+	JobStateFailed    JobState = "failed"
+)
+
+// JobTransition is a change of the state of a job
+type JobTransition struct {
+	State JobState  `json:"state"`
+	At    time.Time `json:"at"`
+	Error string    `json:"error,omitempty"`
+}
+
+// Job is a background job enqueued for a project, its id is the id of the task in the queue.
+// A job without a project is a job of the platform like a webhook delivery.
+type Job struct {
+	ID          string          `json:"id"`
+	ProjectID   null.String     `json:"project_id"`
+	Name        string          `json:"name"`
+	Queue       string          `json:"queue"`
+	State       JobState        `json:"state"`
+	Attempts    int             `json:"attempts"`
+	Error       string          `json:"error"`
+	Result      string          `json:"result"`
+	Transitions []JobTransition `json:"transitions"`
+	EnqueuedAt  time.Time       `json:"enqueued_at"`
+	StartedAt   null.Time       `json:"started_at"`
+	FinishedAt  null.Time       `json:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at,omitempty"`
+	UpdatedAt   time.Time       `json:"updated_at,omitempty"`
+}
+
+// Transition moves the job to the state and keeps its timings, the error is the error of a failed attempt
+func (j *Job) Transition(state JobState, at time.Time, errMsg string) {
+	j.State = state
+	j.Transitions = append(j.Transitions, JobTransition{State: state, At: at, Error: errMsg})
+
+	switch state {
+	case JobStateQueued:
+		j.EnqueuedAt = at
+	case JobStateRunning:
+		// the job started with its first attempt
+		if !j.StartedAt.Valid {
+			j.StartedAt = null.TimeFrom(at)
+		}
+	case JobStateSucceeded:
+		// the errors of the retried attempts stay in the transitions
+		j.Error = ""
+		j.FinishedAt = null.TimeFrom(at)
+	case JobStateFailed:
+		j.FinishedAt = null.TimeFrom(at)
+	}
+
+	if errMsg != "" {
+		j.Error = errMsg
+	}
+}
+
+type JobFromDB struct {
+	ID          string      `db:"id"`
+	ProjectID   null.String `db:"project_id"`
+	Name        string      `db:"name"`
+	Queue       string      `db:"queue"`
+	State       JobState    `db:"state"`
+	Attempts    int         `db:"attempts"`
+	Error       string      `db:"error"`
+	Result      string      `db:"result"`
+	Transitions JSONField   `db:"transitions"`
+	EnqueuedAt  time.Time   `db:"enqueued_at"`
+	StartedAt   null.Time   `db:"started_at"`
+	FinishedAt  null.Time   `db:"finished_at"`
+	CreatedAt   time.Time   `db:"created_at,omitempty"`
+	UpdatedAt   time.Time   `db:"updated_at,omitempty"`
+}
+
+func ToJob(j *JobFromDB) *Job {
+	transitions := []JobTransition{}
+
+	if len(j.Transitions) > 0 {
+		if err := json.Unmarshal(j.Transitions, &transitions); err != nil {
+			log.Printf("failed to unmarshal job transitions: %v", err)
+		}
+	}
+
+	return &Job{
+		ID:          j.ID,
+		ProjectID:   j.ProjectID,
+		Name:        j.Name,
+		Queue:       j.Queue,
+		State:       j.State,
+		Attempts:    j.Attempts,
+		Error:       j.Error,
+		Result:      j.Result,
+		Transitions: transitions,
+		EnqueuedAt:  j.EnqueuedAt,
+		StartedAt:   j.StartedAt,
+		FinishedAt:  j.FinishedAt,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+	}
+}
+
+func ToJobs(jobs []*JobFromDB) []*Job {
+	result := []*Job{}
+
+	for _, j := range jobs {
+		result = append(result, ToJob(j))
+	}
+
+	return result
+}
@@ -33,3 +33,8 @@ type User struct {
 	UpdatedAt                time.Time   `json:"updated_at,omitempty" db:"updated_at,omitempty"`
 	DeletedAt                null.Time   `json:"deleted_at,omitempty" db:"deleted_at"`
 }
+
+// CanUsePremiumModels reports whether the subscription plan allows premium agent models
+func (u *User) CanUsePremiumModels() bool {
+	return u.SubscriptionPlan != "free" && u.SubscriptionPlan != "starter"
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryStatusPending is a delivery waiting for its first attempt or for a retry
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryStatusFailed is a delivery whose retries ran out
+	WebhookDeliveryStatusFailed WebhookDeliveryStatus = "failed"
+)
+
+// Webhook receives the events of a project it subscribed to, every delivery is signed with its secret
+type Webhook struct {
+	ID          string              `json:"id"`
+	OwnerID     string              `json:"owner_id"`
+	ProjectID   string              `json:"project_id"`
+	URL         string              `json:"url"`
+	Secret      string              `json:"-"`
+	Description null.String         `json:"description"`
+	Events      []webhook.EventType `json:"events"`
+	IsActive    bool                `json:"is_active"`
+	CreatedAt   time.Time           `json:"created_at,omitempty"`
+	UpdatedAt   time.Time           `json:"updated_at,omitempty"`
+	DeletedAt   null.Time           `json:"deleted_at,omitempty"`
+}
+
+// Subscribed reports whether the webhook receives the event
+func (w *Webhook) Subscribed(event webhook.EventType) bool {
+	return w.IsActive && slices.Contains(w.Events, event)
+}
+
+type WebhookFromDB struct {
+	ID          string      `db:"id"`
+	OwnerID     string      `db:"owner_id"`
+	ProjectID   string      `db:"project_id"`
+	URL         string      `db:"url"`
+	Secret      string      `db:"secret"`
+	Description null.String `db:"description"`
+	Events      JSONField   `db:"events"`
+	IsActive    bool        `db:"is_active"`
+	CreatedAt   time.Time   `db:"created_at,omitempty"`
+	UpdatedAt   time.Time   `db:"updated_at,omitempty"`
+	DeletedAt   null.Time   `db:"deleted_at"`
+}
+
+func ToWebhook(w *WebhookFromDB) *Webhook {
+	events := []webhook.EventType{}
+
+	if len(w.Events) > 0 {
+		if err := json.Unmarshal(w.Events, &events); err != nil {
+			log.Printf("failed to unmarshal webhook events: %v", err)
+		}
+	}
+
+	return &Webhook{
+		ID:          w.ID,
+		OwnerID:     w.OwnerID,
+		ProjectID:   w.ProjectID,
+		URL:         w.URL,
+		Secret:      w.Secret,
+		Description: w.Description,
+		Events:      events,
+		IsActive:    w.IsActive,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+		DeletedAt:   w.DeletedAt,
+	}
+}
+
+func ToWebhooks(webhooks []*WebhookFromDB) []*Webhook {
+	result := []*Webhook{}
+
+	for _, w := range webhooks {
+		result = append(result, ToWebhook(w))
+	}
+
+	return result
+}
+
+// WebhookDelivery is an event sent to a webhook and the outcome of its latest attempt.
+// A redelivery is a new delivery of the same payload, RedeliveryOf is the delivery it resends.
+type WebhookDelivery struct {
+	ID           string                `json:"id"`
+	WebhookID    string                `json:"webhook_id"`
+	ProjectID    string                `json:"project_id"`
+	Event        webhook.EventType     `json:"event"`
+	Payload      json.RawMessage       `json:"payload"`
+	Status       WebhookDeliveryStatus `json:"status"`
+	Attempts     int                   `json:"attempts"`
+	ResponseCode null.Int              `json:"response_code"`
+	ResponseBody string                `json:"response_body"`
+	Error        string                `json:"error"`
+	DurationMs   int64                 `json:"duration_ms"`
+	RedeliveryOf null.String           `json:"redelivery_of"`
+	DeliveredAt  null.Time             `json:"delivered_at"`
+	CreatedAt    time.Time             `json:"created_at,omitempty"`
+	UpdatedAt    time.Time             `json:"updated_at,omitempty"`
+}
+
+type WebhookDeliveryFromDB struct {
+	ID           string                `db:"id"`
+	WebhookID    string                `db:"webhook_id"`
+	ProjectID    string                `db:"project_id"`
+	Event        webhook.EventType     `db:"event"`
+	Payload      JSONField             `db:"payload"`
+	Status       WebhookDeliveryStatus `db:"status"`
+	Attempts     int                   `db:"attempts"`
+	ResponseCode null.Int              `db:"response_code"`
+	ResponseBody string                `db:"response_body"`
+	Error        string                `db:"error"`
+	DurationMs   int64                 `db:"duration_ms"`
+	RedeliveryOf null.String           `db:"redelivery_of"`
+	DeliveredAt  null.Time             `db:"delivered_at"`
+	CreatedAt    time.Time             `db:"created_at,omitempty"`
+	UpdatedAt    time.Time             `db:"updated_at,omitempty"`
+}
+
+func ToWebhookDelivery(d *WebhookDeliveryFromDB) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:           d.ID,
+		WebhookID:    d.WebhookID,
+		ProjectID:    d.ProjectID,
+		Event:        d.Event,
+		Payload:      json.RawMessage(d.Payload),
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		ResponseBody: d.ResponseBody,
+		Error:        d.Error,
+		DurationMs:   d.DurationMs,
+		RedeliveryOf: d.RedeliveryOf,
+		DeliveredAt:  d.DeliveredAt,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+}
+
+func ToWebhookDeliveries(deliveries []*WebhookDeliveryFromDB) []*WebhookDelivery {
+	result := []*WebhookDelivery{}
+
+	for _, d := range deliveries {
+		result = append(result, ToWebhookDelivery(d))
+	}
+
+	return result
+}
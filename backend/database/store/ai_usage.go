@@ -13,7 +13,7 @@ type TotalAIUsageFilterRange string
 
 const (
 	aiUsageBaseTable    = "ai_usages"
-	aiUsageSelectColumn = "id, owner_id, project_id, endpoint_id, input_tokens, output_tokens, model, usage_type, is_premium, metadata, created_at, updated_at, deleted_at"
+	aiUsageSelectColumn = "id, owner_id, project_id, endpoint_id, prompt_tokens, completion_tokens, cached_tokens, model, usage_type, is_premium, metadata, created_at, updated_at, deleted_at"
 
 	TotalAIUsageFilterRangeToday  TotalAIUsageFilterRange = "today"
 	TotalAIUsageFilterRangeMonth  TotalAIUsageFilterRange = "month"
@@ -32,9 +32,22 @@ type TotalAIUsageFilter struct {
 }
 
 type TotalAIUsage struct {
-	TotalUsage             int `db:"total_usage" json:"total_usage"`
-	TotalInputTokensMonth  int `db:"total_input_tokens,omitempty" json:"total_input_tokens,omitempty"`
-	TotalOutputTokensMonth int `db:"total_output_tokens,omitempty" json:"total_output_tokens,omitempty"`
+	// TotalUsage counts the requests, the repair turns of a request and the classification of a chat prompt
+	// aren't requests of their own but their tokens are summed up
+	TotalUsage            int             `db:"total_usage" json:"total_usage"`
+	TotalPromptTokens     int64           `db:"total_prompt_tokens" json:"total_prompt_tokens"`
+	TotalCompletionTokens int64           `db:"total_completion_tokens" json:"total_completion_tokens"`
+	TotalCachedTokens     int64           `db:"total_cached_tokens" json:"total_cached_tokens"`
+	Models                []*ModelAIUsage `db:"-" json:"models"`
+}
+
+// ModelAIUsage is the usage aggregated for a single model
+type ModelAIUsage struct {
+	Model                 string `db:"model" json:"model"`
+	TotalUsage            int    `db:"total_usage" json:"total_usage"`
+	TotalPromptTokens     int64  `db:"total_prompt_tokens" json:"total_prompt_tokens"`
+	TotalCompletionTokens int64  `db:"total_completion_tokens" json:"total_completion_tokens"`
+	TotalCachedTokens     int64  `db:"total_cached_tokens" json:"total_cached_tokens"`
 }
 
 type aiUsageRepo struct {
@@ -68,8 +81,9 @@ func (a *aiUsageRepo) CreateAIUsage(ctx context.Context, aiUsage *models.AIUsage
 			"owner_id",
 			"project_id",
 			"endpoint_id",
-			"input_tokens",
-			"output_tokens",
+			"prompt_tokens",
+			"completion_tokens",
+			"cached_tokens",
 			"model",
 			"usage_type",
 			"is_premium",
@@ -80,8 +94,9 @@ func (a *aiUsageRepo) CreateAIUsage(ctx context.Context, aiUsage *models.AIUsage
 			aiUsage.OwnerID,
 			aiUsage.ProjectID,
 			aiUsage.EndpointID,
-			aiUsage.InputToken,
-			aiUsage.OutputToken,
+			aiUsage.PromptTokens,
+			aiUsage.CompletionTokens,
+			aiUsage.CachedTokens,
 			aiUsage.Model,
 			aiUsage.UsageType,
 			aiUsage.IsPremium,
@@ -112,12 +127,16 @@ func (a *aiUsageRepo) UpdateAIUsage(ctx context.Context, aiUsage *models.AIUsage
 		Where(squirrel.Eq{"id": aiUsage.ID}).
 		Where(excludeDeleted)
 
-	if aiUsage.InputToken != "" {
-		stmt = stmt.Set("input_tokens", aiUsage.InputToken)
+	if aiUsage.PromptTokens > 0 {
+		stmt = stmt.Set("prompt_tokens", aiUsage.PromptTokens)
+	}
+
+	if aiUsage.CompletionTokens > 0 {
+		stmt = stmt.Set("completion_tokens", aiUsage.CompletionTokens)
 	}
 
-	if aiUsage.OutputToken != "" {
-		stmt = stmt.Set("output_tokens", aiUsage.OutputToken)
+	if aiUsage.CachedTokens > 0 {
+		stmt = stmt.Set("cached_tokens", aiUsage.CachedTokens)
 	}
 
 	if aiUsage.Model != "" {
@@ -212,11 +231,20 @@ func (a *aiUsageRepo) FindAIUsageByProjectID(ctx context.Context, projectId stri
 }
 
 // GetTotalUsage implements AIUsageRepository.
+// The request count and token sums are aggregated per model and summed up across models.
 func (a *aiUsageRepo) GetTotalUsage(ctx context.Context, opts TotalAIUsageFilter) (*TotalAIUsage, error) {
 	stmt := Builder.
-		Select("COUNT(*) AS total_usage").
+		Select(
+			"COALESCE(model, '') AS model",
+			"COUNT(*) FILTER (WHERE usage_type NOT IN ('repair', 'chat_intent')) AS total_usage",
+			"COALESCE(SUM(prompt_tokens), 0) AS total_prompt_tokens",
+			"COALESCE(SUM(completion_tokens), 0) AS total_completion_tokens",
+			"COALESCE(SUM(cached_tokens), 0) AS total_cached_tokens",
+		).
 		From(aiUsageBaseTable).
-		Where(excludeDeleted)
+		Where(excludeDeleted).
+		GroupBy("model").
+		OrderBy("model")
 
 	if opts.OwnerID != "" {
 		stmt = stmt.Where(squirrel.Eq{"owner_id": opts.OwnerID})
@@ -252,10 +280,21 @@ func (a *aiUsageRepo) GetTotalUsage(ctx context.Context, opts TotalAIUsageFilter
 		return nil, err
 	}
 
-	dst := new(TotalAIUsage)
-	if err := a.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+	perModel := []*ModelAIUsage{}
+	if err := a.db.GetDB().SelectContext(ctx, &perModel, sql, args...); err != nil {
 		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to get total usage in current month by owner id")
 	}
 
+	dst := &TotalAIUsage{
+		Models: perModel,
+	}
+
+	for _, model := range perModel {
+		dst.TotalUsage += model.TotalUsage
+		dst.TotalPromptTokens += model.TotalPromptTokens
+		dst.TotalCompletionTokens += model.TotalCompletionTokens
+		dst.TotalCachedTokens += model.TotalCachedTokens
+	}
+
 	return dst, nil
 }
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mujhtech/b0/database"
+	"github.com/mujhtech/b0/database/models"
+)
+
+const (
+	aiUsagePayloadBaseTable    = "ai_usage_payloads"
+	aiUsagePayloadSelectColumn = "id, ai_usage_id, owner_id, input, output, expires_at, created_at, updated_at"
+)
+
+type aiUsagePayloadRepo struct {
+	db *database.Database
+}
+
+func NewAIUsagePayloadRepository(db *database.Database) AIUsagePayloadRepository {
+	return &aiUsagePayloadRepo{
+		db: db,
+	}
+}
+
+// CreateAIUsagePayload implements AIUsagePayloadRepository.
+func (a *aiUsagePayloadRepo) CreateAIUsagePayload(ctx context.Context, payload *models.AIUsagePayload) error {
+	stmt := Builder.
+		Insert(aiUsagePayloadBaseTable).
+		Columns(
+			"id",
+			"ai_usage_id",
+			"owner_id",
+			"input",
+			"output",
+			"expires_at",
+		).
+		Values(
+			payload.ID,
+			payload.AIUsageID,
+			payload.OwnerID,
+			payload.Input,
+			payload.Output,
+			payload.ExpiresAt,
+		)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to create ai usage payload")
+	}
+
+	return nil
+}
+
+// FindAIUsagePayloadByUsageID implements AIUsagePayloadRepository.
+func (a *aiUsagePayloadRepo) FindAIUsagePayloadByUsageID(ctx context.Context, aiUsageId string) (*models.AIUsagePayload, error) {
+	stmt := Builder.
+		Select(aiUsagePayloadSelectColumn).
+		From(aiUsagePayloadBaseTable).
+		Where(squirrel.Eq{"ai_usage_id": aiUsageId}).
+		Where("expires_at > NOW()")
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := new(models.AIUsagePayload)
+	if err := a.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find ai usage payload by usage id")
+	}
+
+	return dst, nil
+}
+
+// DeleteExpiredAIUsagePayloads implements AIUsagePayloadRepository.
+func (a *aiUsagePayloadRepo) DeleteExpiredAIUsagePayloads(ctx context.Context) (int64, error) {
+	stmt := Builder.
+		Delete(aiUsagePayloadBaseTable).
+		Where("expires_at <= NOW()")
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := a.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return 0, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to delete expired ai usage payloads")
+	}
+
+	return res.RowsAffected()
+}
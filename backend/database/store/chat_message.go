@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mujhtech/b0/database"
+	"github.com/mujhtech/b0/database/models"
+)
+
+const (
+	chatMessageBaseTable    = "chat_messages"
+	chatMessageSelectColumn = "id, owner_id, project_id, endpoint_id, role, content, created_at, updated_at"
+)
+
+// ChatMessageFilter selects the latest Limit messages of a project conversation created before Before.
+// An empty EndpointID selects the messages that are not about an endpoint.
+type ChatMessageFilter struct {
+	ProjectID  string    `json:"project_id"`
+	EndpointID string    `json:"endpoint_id"`
+	Before     time.Time `json:"before"`
+	Limit      uint64    `json:"limit"`
+}
+
+type chatMessageRepo struct {
+	db *database.Database
+}
+
+func NewChatMessageRepository(db *database.Database) ChatMessageRepository {
+	return &chatMessageRepo{
+		db: db,
+	}
+}
+
+// CreateChatMessage implements ChatMessageRepository.
+func (c *chatMessageRepo) CreateChatMessage(ctx context.Context, message *models.ChatMessage) error {
+	stmt := Builder.
+		Insert(chatMessageBaseTable).
+		Columns(
+			"id",
+			"owner_id",
+			"project_id",
+			"endpoint_id",
+			"role",
+			"content",
+		).
+		Values(
+			message.ID,
+			message.OwnerID,
+			message.ProjectID,
+			message.EndpointID,
+			message.Role,
+			message.Content,
+		)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to create chat message")
+	}
+
+	return nil
+}
+
+// FindChatMessageByID implements ChatMessageRepository.
+func (c *chatMessageRepo) FindChatMessageByID(ctx context.Context, id string) (*models.ChatMessage, error) {
+	stmt := Builder.
+		Select(chatMessageSelectColumn).
+		From(chatMessageBaseTable).
+		Where(squirrel.Eq{"id": id})
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := new(models.ChatMessage)
+	if err := c.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find chat message by id")
+	}
+
+	return dst, nil
+}
+
+// FindChatMessages implements ChatMessageRepository.
+// The messages are returned oldest first, the way they're shown and sent to the agent.
+func (c *chatMessageRepo) FindChatMessages(ctx context.Context, filter ChatMessageFilter) ([]*models.ChatMessage, error) {
+	stmt := Builder.
+		Select(chatMessageSelectColumn).
+		From(chatMessageBaseTable).
+		Where(squirrel.Eq{"project_id": filter.ProjectID}).
+		OrderBy(orderByCreatedAtDesc, "id DESC")
+
+	if filter.EndpointID != "" {
+		stmt = stmt.Where(squirrel.Eq{"endpoint_id": filter.EndpointID})
+	} else {
+		stmt = stmt.Where("endpoint_id IS NULL")
+	}
+
+	if !filter.Before.IsZero() {
+		stmt = stmt.Where(squirrel.Lt{"created_at": filter.Before})
+	}
+
+	if filter.Limit > 0 {
+		stmt = stmt.Limit(filter.Limit)
+	}
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := []*models.ChatMessage{}
+	if err := c.db.GetDB().SelectContext(ctx, &dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find chat messages")
+	}
+
+	slices.Reverse(dst)
+
+	return dst, nil
+}
+
+// DeleteChatMessage implements ChatMessageRepository.
+func (c *chatMessageRepo) DeleteChatMessage(ctx context.Context, id string) error {
+	stmt := Builder.
+		Delete(chatMessageBaseTable).
+		Where(squirrel.Eq{"id": id})
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to delete chat message")
+	}
+
+	return nil
+}
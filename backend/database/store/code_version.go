@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mujhtech/b0/database"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/util"
+)
+
+const (
+	codeVersionBaseTable = "code_versions"
+	// the versions are listed without their content, it holds every file of the code
+	codeVersionListColumn   = "id, owner_id, project_id, endpoint_id, version, commit_id, branch, commit_msg, content_hash, metadata, created_at, updated_at, deleted_at"
+	codeVersionSelectColumn = codeVersionListColumn + ", content"
+)
+
+// CodeVersionFilter selects the latest Limit versions of the code of an endpoint
+type CodeVersionFilter struct {
+	EndpointID string `json:"endpoint_id"`
+	Limit      uint64 `json:"limit"`
+}
+
+type codeVersionRepo struct {
+	db *database.Database
+}
+
+func NewCodeVersionRepository(db *database.Database) CodeVersionRepository {
+	return &codeVersionRepo{
+		db: db,
+	}
+}
+
+// CreateCodeVersion implements CodeVersionRepository.
+func (c *codeVersionRepo) CreateCodeVersion(ctx context.Context, version *models.CodeVersion) error {
+	content := "{}"
+	metadata := "{}"
+
+	if version.Content != nil {
+		contentOutput, err := util.MarshalJSONToString(version.Content)
+		if err != nil {
+			return err
+		}
+		content = contentOutput
+	}
+
+	if version.Metadata != nil {
+		metadataOutput, err := util.MarshalJSONToString(version.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = metadataOutput
+	}
+
+	stmt := Builder.
+		Insert(codeVersionBaseTable).
+		Columns(
+			"id",
+			"owner_id",
+			"project_id",
+			"endpoint_id",
+			"version",
+			"commit_id",
+			"branch",
+			"commit_msg",
+			"content_hash",
+			"content",
+			"metadata",
+		).
+		Values(
+			version.ID,
+			version.OwnerID,
+			version.ProjectID,
+			version.EndpointID,
+			version.Version,
+			version.CommitID,
+			version.Branch,
+			version.CommitMsg,
+			version.ContentHash,
+			content,
+			metadata,
+		)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to create code version")
+	}
+
+	return nil
+}
+
+// FindCodeVersionByID implements CodeVersionRepository.
+func (c *codeVersionRepo) FindCodeVersionByID(ctx context.Context, id string) (*models.CodeVersion, error) {
+	stmt := Builder.
+		Select(codeVersionSelectColumn).
+		From(codeVersionBaseTable).
+		Where(squirrel.Eq{"id": id}).
+		Where(excludeDeleted)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := new(models.CodeVersionFromDB)
+	if err := c.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find code version by id")
+	}
+
+	return models.ToCodeVersion(dst), nil
+}
+
+// FindLatestCodeVersion implements CodeVersionRepository.
+func (c *codeVersionRepo) FindLatestCodeVersion(ctx context.Context, endpointID string) (*models.CodeVersion, error) {
+	stmt := Builder.
+		Select(codeVersionSelectColumn).
+		From(codeVersionBaseTable).
+		Where(squirrel.Eq{"endpoint_id": endpointID}).
+		Where(excludeDeleted).
+		OrderBy(orderByCreatedAtDesc, "id DESC").
+		Limit(1)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := new(models.CodeVersionFromDB)
+	if err := c.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find latest code version")
+	}
+
+	return models.ToCodeVersion(dst), nil
+}
+
+// FindCodeVersions implements CodeVersionRepository.
+// The versions are returned newest first and without their content.
+func (c *codeVersionRepo) FindCodeVersions(ctx context.Context, filter CodeVersionFilter) ([]*models.CodeVersion, error) {
+	stmt := Builder.
+		Select(codeVersionListColumn).
+		From(codeVersionBaseTable).
+		Where(squirrel.Eq{"endpoint_id": filter.EndpointID}).
+		Where(excludeDeleted).
+		OrderBy(orderByCreatedAtDesc, "id DESC")
+
+	if filter.Limit > 0 {
+		stmt = stmt.Limit(filter.Limit)
+	}
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := []*models.CodeVersionFromDB{}
+	if err := c.db.GetDB().SelectContext(ctx, &dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find code versions")
+	}
+
+	return models.ToCodeVersions(dst), nil
+}
+
+// FindProjectCodeVersions implements CodeVersionRepository.
+// The versions of every endpoint of the project are returned oldest first with their content.
+func (c *codeVersionRepo) FindProjectCodeVersions(ctx context.Context, projectID string) ([]*models.CodeVersion, error) {
+	stmt := Builder.
+		Select(codeVersionSelectColumn).
+		From(codeVersionBaseTable).
+		Where(squirrel.Eq{"project_id": projectID}).
+		Where(excludeDeleted).
+		OrderBy("created_at ASC", "id ASC")
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := []*models.CodeVersionFromDB{}
+	if err := c.db.GetDB().SelectContext(ctx, &dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find project code versions")
+	}
+
+	return models.ToCodeVersions(dst), nil
+}
+
+// UpdateCodeVersionCommit implements CodeVersionRepository.
+func (c *codeVersionRepo) UpdateCodeVersionCommit(ctx context.Context, id, commitID, branch string) error {
+	stmt := Builder.
+		Update(codeVersionBaseTable).
+		Set("commit_id", commitID).
+		Set("branch", branch).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": id}).
+		Where(excludeDeleted)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = c.db.GetDB().ExecContext(ctx, sql, args...); err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to update code version commit")
+	}
+
+	return nil
+}
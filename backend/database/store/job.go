@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mujhtech/b0/database"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/util"
+)
+
+const (
+	jobBaseTable    = "jobs"
+	jobSelectColumn = "id, project_id, name, queue, state, attempts, error, result, transitions, enqueued_at, started_at, finished_at, created_at, updated_at"
+)
+
+// JobFilter selects the latest Limit jobs of a project
+type JobFilter struct {
+	ProjectID string `json:"project_id"`
+	Limit     uint64 `json:"limit"`
+}
+
+type jobRepo struct {
+	db *database.Database
+}
+
+func NewJobRepository(db *database.Database) JobRepository {
+	return &jobRepo{
+		db: db,
+	}
+}
+
+// CreateJob implements JobRepository.
+func (j *jobRepo) CreateJob(ctx context.Context, job *models.Job) error {
+	transitions, err := util.MarshalJSONToString(job.Transitions)
+
+	if err != nil {
+		return err
+	}
+
+	stmt := Builder.
+		Insert(jobBaseTable).
+		Columns(
+			"id",
+			"project_id",
+			"name",
+			"queue",
+			"state",
+			"transitions",
+			"enqueued_at",
+		).
+		Values(
+			job.ID,
+			job.ProjectID,
+			job.Name,
+			job.Queue,
+			job.State,
+			transitions,
+			job.EnqueuedAt,
+		)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = j.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to create job")
+	}
+
+	return nil
+}
+
+// FindJobByID implements JobRepository.
+func (j *jobRepo) FindJobByID(ctx context.Context, id string) (*models.Job, error) {
+	stmt := Builder.
+		Select(jobSelectColumn).
+		From(jobBaseTable).
+		Where(squirrel.Eq{"id": id})
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := new(models.JobFromDB)
+	if err := j.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find job by id")
+	}
+
+	return models.ToJob(dst), nil
+}
+
+// FindJobs implements JobRepository.
+// The jobs are returned newest first.
+func (j *jobRepo) FindJobs(ctx context.Context, filter JobFilter) ([]*models.Job, error) {
+	stmt := Builder.
+		Select(jobSelectColumn).
+		From(jobBaseTable).
+		Where(squirrel.Eq{"project_id": filter.ProjectID}).
+		OrderBy(orderByCreatedAtDesc, "id DESC")
+
+	if filter.Limit > 0 {
+		stmt = stmt.Limit(filter.Limit)
+	}
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := []*models.JobFromDB{}
+	if err := j.db.GetDB().SelectContext(ctx, &dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find jobs")
+	}
+
+	return models.ToJobs(dst), nil
+}
+
+// UpdateJob implements JobRepository.
+// It records the state of the job and its timings.
+func (j *jobRepo) UpdateJob(ctx context.Context, job *models.Job) error {
+	transitions, err := util.MarshalJSONToString(job.Transitions)
+
+	if err != nil {
+		return err
+	}
+
+	stmt := Builder.
+		Update(jobBaseTable).
+		Set("state", job.State).
+		Set("attempts", job.Attempts).
+		Set("error", job.Error).
+		Set("result", job.Result).
+		Set("transitions", transitions).
+		Set("started_at", job.StartedAt).
+		Set("finished_at", job.FinishedAt).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": job.ID})
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = j.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to update job")
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
 )
 
 type UserRepository interface {
@@ -47,6 +48,51 @@ type AIUsageRepository interface {
 	GetTotalUsage(ctx context.Context, opts TotalAIUsageFilter) (*TotalAIUsage, error)
 }
 
+type AIUsagePayloadRepository interface {
+	CreateAIUsagePayload(ctx context.Context, payload *models.AIUsagePayload) error
+	FindAIUsagePayloadByUsageID(ctx context.Context, aiUsageID string) (*models.AIUsagePayload, error)
+	DeleteExpiredAIUsagePayloads(ctx context.Context) (int64, error)
+}
+
+type ChatMessageRepository interface {
+	CreateChatMessage(ctx context.Context, message *models.ChatMessage) error
+	FindChatMessageByID(ctx context.Context, id string) (*models.ChatMessage, error)
+	FindChatMessages(ctx context.Context, filter ChatMessageFilter) ([]*models.ChatMessage, error)
+	DeleteChatMessage(ctx context.Context, id string) error
+}
+
+type CodeVersionRepository interface {
+	CreateCodeVersion(ctx context.Context, version *models.CodeVersion) error
+	FindCodeVersionByID(ctx context.Context, id string) (*models.CodeVersion, error)
+	FindLatestCodeVersion(ctx context.Context, endpointID string) (*models.CodeVersion, error)
+	FindCodeVersions(ctx context.Context, filter CodeVersionFilter) ([]*models.CodeVersion, error)
+	FindProjectCodeVersions(ctx context.Context, projectID string) ([]*models.CodeVersion, error)
+	UpdateCodeVersionCommit(ctx context.Context, id, commitID, branch string) error
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	FindWebhookByID(ctx context.Context, id string) (*models.Webhook, error)
+	FindWebhooksByProjectID(ctx context.Context, projectID string) ([]*models.Webhook, error)
+	FindWebhooksByEvent(ctx context.Context, projectID string, event webhook.EventType) ([]*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	FindWebhookDeliveryByID(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	FindWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+type JobRepository interface {
+	CreateJob(ctx context.Context, job *models.Job) error
+	FindJobByID(ctx context.Context, id string) (*models.Job, error)
+	FindJobs(ctx context.Context, filter JobFilter) ([]*models.Job, error)
+	UpdateJob(ctx context.Context, job *models.Job) error
+}
+
 type ProjectLogRepository interface{}
 
 type AITokenCreditRepository interface{}
@@ -24,24 +24,36 @@ const (
 )
 
 type Store struct {
-	UserRepo          UserRepository
-	TokenRepo         TokenRepository
-	ProjectRepo       ProjectRepository
-	EndpointRepo      EndpointRepository
-	AIUsageRepo       AIUsageRepository
-	ProjectLogRepo    ProjectLogRepository
-	AITokenCreditRepo AITokenCreditRepository
+	UserRepo            UserRepository
+	TokenRepo           TokenRepository
+	ProjectRepo         ProjectRepository
+	EndpointRepo        EndpointRepository
+	AIUsageRepo         AIUsageRepository
+	AIUsagePayloadRepo  AIUsagePayloadRepository
+	ChatMessageRepo     ChatMessageRepository
+	CodeVersionRepo     CodeVersionRepository
+	WebhookRepo         WebhookRepository
+	WebhookDeliveryRepo WebhookDeliveryRepository
+	JobRepo             JobRepository
+	ProjectLogRepo      ProjectLogRepository
+	AITokenCreditRepo   AITokenCreditRepository
 }
 
 func NewStore(db *database.Database) *Store {
 	return &Store{
-		UserRepo:          NewUserRepository(db),
-		TokenRepo:         NewTokenRepository(db),
-		ProjectRepo:       NewProjectRepository(db),
-		EndpointRepo:      NewEndpointRepository(db),
-		AIUsageRepo:       NewAIUsageRepository(db),
-		ProjectLogRepo:    NewProjectLogRepository(db),
-		AITokenCreditRepo: NewAITokenCreditRepository(db),
+		UserRepo:            NewUserRepository(db),
+		TokenRepo:           NewTokenRepository(db),
+		ProjectRepo:         NewProjectRepository(db),
+		EndpointRepo:        NewEndpointRepository(db),
+		AIUsageRepo:         NewAIUsageRepository(db),
+		AIUsagePayloadRepo:  NewAIUsagePayloadRepository(db),
+		ChatMessageRepo:     NewChatMessageRepository(db),
+		CodeVersionRepo:     NewCodeVersionRepository(db),
+		WebhookRepo:         NewWebhookRepository(db),
+		WebhookDeliveryRepo: NewWebhookDeliveryRepository(db),
+		JobRepo:             NewJobRepository(db),
+		ProjectLogRepo:      NewProjectLogRepository(db),
+		AITokenCreditRepo:   NewAITokenCreditRepository(db),
 	}
 }
 
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mujhtech/b0/database"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+	"github.com/mujhtech/b0/internal/util"
+)
+
+const (
+	webhookBaseTable    = "webhooks"
+	webhookSelectColumn = "id, owner_id, project_id, url, secret, description, events, is_active, created_at, updated_at, deleted_at"
+)
+
+type webhookRepo struct {
+	db *database.Database
+}
+
+func NewWebhookRepository(db *database.Database) WebhookRepository {
+	return &webhookRepo{
+		db: db,
+	}
+}
+
+// CreateWebhook implements WebhookRepository.
+func (w *webhookRepo) CreateWebhook(ctx context.Context, hook *models.Webhook) error {
+	events, err := util.MarshalJSONToString(hook.Events)
+
+	if err != nil {
+		return err
+	}
+
+	stmt := Builder.
+		Insert(webhookBaseTable).
+		Columns(
+			"id",
+			"owner_id",
+			"project_id",
+			"url",
+			"secret",
+			"description",
+			"events",
+			"is_active",
+		).
+		Values(
+			hook.ID,
+			hook.OwnerID,
+			hook.ProjectID,
+			hook.URL,
+			hook.Secret,
+			hook.Description,
+			events,
+			hook.IsActive,
+		)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to create webhook")
+	}
+
+	return nil
+}
+
+// FindWebhookByID implements WebhookRepository.
+func (w *webhookRepo) FindWebhookByID(ctx context.Context, id string) (*models.Webhook, error) {
+	stmt := Builder.
+		Select(webhookSelectColumn).
+		From(webhookBaseTable).
+		Where(squirrel.Eq{"id": id}).
+		Where(excludeDeleted)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := new(models.WebhookFromDB)
+	if err := w.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find webhook by id")
+	}
+
+	return models.ToWebhook(dst), nil
+}
+
+// FindWebhooksByProjectID implements WebhookRepository.
+func (w *webhookRepo) FindWebhooksByProjectID(ctx context.Context, projectID string) ([]*models.Webhook, error) {
+	stmt := Builder.
+		Select(webhookSelectColumn).
+		From(webhookBaseTable).
+		Where(squirrel.Eq{"project_id": projectID}).
+		Where(excludeDeleted).
+		OrderBy(orderByCreatedAtDesc)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := []*models.WebhookFromDB{}
+	if err := w.db.GetDB().SelectContext(ctx, &dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find webhooks by project id")
+	}
+
+	return models.ToWebhooks(dst), nil
+}
+
+// FindWebhooksByEvent implements WebhookRepository.
+// Only the active webhooks of the project subscribed to the event are returned.
+func (w *webhookRepo) FindWebhooksByEvent(ctx context.Context, projectID string, event webhook.EventType) ([]*models.Webhook, error) {
+	events, err := util.MarshalJSONToString([]webhook.EventType{event})
+
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := Builder.
+		Select(webhookSelectColumn).
+		From(webhookBaseTable).
+		Where(squirrel.Eq{"project_id": projectID, "is_active": true}).
+		Where(squirrel.Expr("events @> ?::jsonb", events)).
+		Where(excludeDeleted)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := []*models.WebhookFromDB{}
+	if err := w.db.GetDB().SelectContext(ctx, &dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find webhooks by event")
+	}
+
+	return models.ToWebhooks(dst), nil
+}
+
+// UpdateWebhook implements WebhookRepository.
+func (w *webhookRepo) UpdateWebhook(ctx context.Context, hook *models.Webhook) error {
+	events, err := util.MarshalJSONToString(hook.Events)
+
+	if err != nil {
+		return err
+	}
+
+	stmt := Builder.
+		Update(webhookBaseTable).
+		Set("url", hook.URL).
+		Set("secret", hook.Secret).
+		Set("description", hook.Description).
+		Set("events", events).
+		Set("is_active", hook.IsActive).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": hook.ID}).
+		Where(excludeDeleted)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to update webhook")
+	}
+
+	return nil
+}
+
+// DeleteWebhook implements WebhookRepository.
+func (w *webhookRepo) DeleteWebhook(ctx context.Context, id string) error {
+	stmt := Builder.
+		Update(webhookBaseTable).
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": id}).
+		Where(excludeDeleted)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to delete webhook")
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mujhtech/b0/database"
+	"github.com/mujhtech/b0/database/models"
+)
+
+const (
+	webhookDeliveryBaseTable    = "webhook_deliveries"
+	webhookDeliverySelectColumn = "id, webhook_id, project_id, event, payload, status, attempts, response_code, response_body, error, duration_ms, redelivery_of, delivered_at, created_at, updated_at"
+)
+
+// WebhookDeliveryFilter selects the latest Limit deliveries of a webhook
+type WebhookDeliveryFilter struct {
+	WebhookID string `json:"webhook_id"`
+	Limit     uint64 `json:"limit"`
+}
+
+type webhookDeliveryRepo struct {
+	db *database.Database
+}
+
+func NewWebhookDeliveryRepository(db *database.Database) WebhookDeliveryRepository {
+	return &webhookDeliveryRepo{
+		db: db,
+	}
+}
+
+// CreateWebhookDelivery implements WebhookDeliveryRepository.
+func (w *webhookDeliveryRepo) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	payload := "{}"
+
+	if len(delivery.Payload) > 0 {
+		payload = string(delivery.Payload)
+	}
+
+	stmt := Builder.
+		Insert(webhookDeliveryBaseTable).
+		Columns(
+			"id",
+			"webhook_id",
+			"project_id",
+			"event",
+			"payload",
+			"status",
+			"redelivery_of",
+		).
+		Values(
+			delivery.ID,
+			delivery.WebhookID,
+			delivery.ProjectID,
+			delivery.Event,
+			payload,
+			delivery.Status,
+			delivery.RedeliveryOf,
+		)
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to create webhook delivery")
+	}
+
+	return nil
+}
+
+// FindWebhookDeliveryByID implements WebhookDeliveryRepository.
+func (w *webhookDeliveryRepo) FindWebhookDeliveryByID(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	stmt := Builder.
+		Select(webhookDeliverySelectColumn).
+		From(webhookDeliveryBaseTable).
+		Where(squirrel.Eq{"id": id})
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := new(models.WebhookDeliveryFromDB)
+	if err := w.db.GetDB().GetContext(ctx, dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find webhook delivery by id")
+	}
+
+	return models.ToWebhookDelivery(dst), nil
+}
+
+// FindWebhookDeliveries implements WebhookDeliveryRepository.
+// The deliveries are returned newest first.
+func (w *webhookDeliveryRepo) FindWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	stmt := Builder.
+		Select(webhookDeliverySelectColumn).
+		From(webhookDeliveryBaseTable).
+		Where(squirrel.Eq{"webhook_id": filter.WebhookID}).
+		OrderBy(orderByCreatedAtDesc, "id DESC")
+
+	if filter.Limit > 0 {
+		stmt = stmt.Limit(filter.Limit)
+	}
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dst := []*models.WebhookDeliveryFromDB{}
+	if err := w.db.GetDB().SelectContext(ctx, &dst, sql, args...); err != nil {
+		return nil, ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to find webhook deliveries")
+	}
+
+	return models.ToWebhookDeliveries(dst), nil
+}
+
+// UpdateWebhookDelivery implements WebhookDeliveryRepository.
+// It records the outcome of the latest attempt of the delivery.
+func (w *webhookDeliveryRepo) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	stmt := Builder.
+		Update(webhookDeliveryBaseTable).
+		Set("status", delivery.Status).
+		Set("attempts", delivery.Attempts).
+		Set("response_code", delivery.ResponseCode).
+		Set("response_body", delivery.ResponseBody).
+		Set("error", delivery.Error).
+		Set("duration_ms", delivery.DurationMs).
+		Set("delivered_at", delivery.DeliveredAt).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": delivery.ID})
+
+	sql, args, err := stmt.ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.db.GetDB().ExecContext(ctx, sql, args...)
+
+	if err != nil {
+		return ProcessSQLErrorfWithCtx(ctx, sql, err, "failed to update webhook delivery")
+	}
+
+	return nil
+}
@@ -2,118 +2,76 @@ package agent
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/mujhtech/b0/config"
 	"github.com/mujhtech/b0/internal/util"
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
 	"github.com/rs/zerolog"
 )
 
-const (
-	deepSeekBaseUrl = "https://api.deepseek.com/v1"
-	openaiBaseUrl   = "https://api.openai.com/v1"
-	geminiBaseUrl   = "https://generativelanguage.googleapis.com/v1beta/openai/"
-	xAIbaseUrl      = "https://api.x.ai/v1"
-)
-
 type Agent struct {
-	cfg *Config
+	cfg       *Config
+	providers map[ProviderName]Provider
+	// catalogs are the built-in models and those of the custom providers
+	catalogs []ModeCatalog
 }
 
 func New(cfg *config.Config) *Agent {
 
-	return &Agent{
-		cfg: &Config{
-			Model:        AgentModelGeminiFlash1Dot5,
-			OpenAIKey:    cfg.Agent.OpenAIKey,
-			DeepSeekKey:  cfg.Agent.DeepSeekKey,
-			AnthropicKey: cfg.Agent.AnthropicKey,
-			GeminiKey:    cfg.Agent.GeminiKey,
-			XAIKey:       cfg.Agent.XAIKey,
+	agentCfg := &Config{
+		Model:          AgentModelGeminiFlash1Dot5,
+		MaxTokens:      defaultMaxTokens,
+		RepairAttempts: cfg.Agent.RepairAttempts,
+		Routes: map[AgentTask][]AgentModel{
+			AgentTaskTitleAndSlug:       toModels(cfg.Agent.Routes.TitleAndSlug),
+			AgentTaskWorkflowGeneration: toModels(cfg.Agent.Routes.WorkflowGeneration),
+			AgentTaskWorkflowUpdate:     toModels(cfg.Agent.Routes.WorkflowUpdate),
+			AgentTaskCodeGeneration:     toModels(cfg.Agent.Routes.CodeGeneration),
+			AgentTaskChatIntent:         toModels(cfg.Agent.Routes.ChatIntent),
 		},
+		OpenAIKey:       cfg.Agent.OpenAIKey,
+		DeepSeekKey:     cfg.Agent.DeepSeekKey,
+		AnthropicKey:    cfg.Agent.AnthropicKey,
+		GeminiKey:       cfg.Agent.GeminiKey,
+		XAIKey:          cfg.Agent.XAIKey,
+		CustomProviders: cfg.Agent.CustomProviders,
+		Replay:          cfg.Agent.Replay,
 	}
-}
 
-func (a *Agent) client(opts ...option.RequestOption) openai.Client {
-
-	baseUrl := ""
-	apiKey := ""
-	switch a.cfg.Model {
-	case AgentModelGPT4, AgentModelGPT3Dot5:
-		baseUrl = openaiBaseUrl
-		apiKey = a.cfg.OpenAIKey
-	case AgentModelDeepSeekR1:
-		baseUrl = deepSeekBaseUrl
-		apiKey = a.cfg.DeepSeekKey
-	case AgentModelClaudeSonnet3Dot5:
-		baseUrl = openaiBaseUrl
-		apiKey = a.cfg.AnthropicKey
-	case AgentModelGeminiFlash1Dot5, AgentModelGeminiFlash2Dot0:
-		baseUrl = geminiBaseUrl
-		apiKey = a.cfg.GeminiKey
-	case AgentModelGrok2Dot0:
-		baseUrl = xAIbaseUrl
-		apiKey = a.cfg.XAIKey
+	catalogs := mergeCatalogs(AvailableCatalogs, cfg.Agent.CustomProviders)
+
+	return &Agent{
+		cfg:       agentCfg,
+		providers: newProviders(agentCfg, catalogs),
+		catalogs:  catalogs,
 	}
+}
 
-	opts = append(opts, option.WithBaseURL(baseUrl), option.WithAPIKey(apiKey))
+// Catalogs returns the models the agent can answer with, the built-in ones when the agent has no custom provider
+func (a *Agent) Catalogs() []ModeCatalog {
+	if a.catalogs == nil {
+		return AvailableCatalogs
+	}
 
-	client := openai.NewClient(opts...)
+	return a.catalogs
+}
 
-	return client
+// GetModelCatalog returns the catalog entry of the model, the models of the custom providers included
+func (a *Agent) GetModelCatalog(model string) (ModeCatalog, error) {
+	return findCatalog(a.Catalogs(), model)
 }
 
-// func (a *Agent) GenerateTitleAndSlugWithSchema(ctx context.Context, prompt string, opts ...OptionFunc) (*ProjectTitleAndSlug, *AgentToken, error) {
-// 	opCfg := *a.cfg
-// 	for _, opt := range opts {
-// 		opt(&opCfg)
-// 	}
-
-// 	agentToken := &AgentToken{
-// 		Input: fmt.Sprintf(`
-// 		%s
-
-// 		%s
-// 		`, fmt.Sprintf(b0ProjectTitleAndSlugSystemMessage, a.cfg.Model), prompt),
-// 	}
-
-// 	client := a.client()
-
-// 	schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
-// 		Name:        "project",
-// 		Description: openai.F("Project title, description and slug"),
-// 		Schema:      ProjectTitleAndSlugResponseSchema,
-// 		Strict:      openai.Bool(true),
-// 	}
-
-// 	chat, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-// 		Messages: []openai.ChatCompletionMessageParamUnion{
-// 			openai.SystemMessage(fmt.Sprintf(b0ProjectTitleAndSlugSystemMessage, a.cfg.Model)),
-// 			openai.UserMessage(prompt),
-// 		},
-// 		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
-// 			openai.ResponseFormatJSONSchemaParam{
-// 				Type:       openai.F(openai.ResponseFormatJSONSchemaTypeJSONSchema),
-// 				JSONSchema: openai.F(schemaParam),
-// 			},
-// 		},
-// 		Model: openai.F(openai.ChatModelGPT4o2024_08_06),
-// 	})
-
-// 	if err != nil {
-// 		return nil, agentToken, err
-// 	}
-
-// 	// extract into a well-typed struct
-// 	dst := ProjectTitleAndSlug{}
-// 	_ = json.Unmarshal([]byte(chat.Choices[0].Message.Content), &dst)
-
-// 	return &dst, agentToken, nil
-// }
+// agentToken returns the token recording the completions of the model, billed at the tier of its catalog
+func (a *Agent) agentToken(model AgentModel) *AgentToken {
+	agentToken := &AgentToken{Model: string(model)}
+
+	if catalog, err := a.GetModelCatalog(string(model)); err == nil {
+		agentToken.IsPremium = catalog.IsPremium
+	}
+
+	return agentToken
+}
 
 // GenerateTitleAndSlug generates a title and slug for a new project based on the given prompt.
 func (a *Agent) GenerateTitleAndSlug(ctx context.Context, prompt string, opts ...OptionFunc) (*ProjectTitleAndSlug, *AgentToken, error) {
@@ -122,177 +80,187 @@ func (a *Agent) GenerateTitleAndSlug(ctx context.Context, prompt string, opts ..
 		opt(&opCfg)
 	}
 
-	agentToken := &AgentToken{
-		Input: fmt.Sprintf(`
-		%s
-		
-		%s
-		`, fmt.Sprintf(b0ProjectTitleAndSlugSystemMessage, a.cfg.Model), prompt),
-	}
-
-	client := a.client()
-
-	chat, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(fmt.Sprintf(b0ProjectTitleAndSlugSystemMessage, a.cfg.Model)),
-			openai.UserMessage(prompt),
-		},
-		Model: openai.ChatModel(a.cfg.Model),
+	agentProjectTitleAndSlug, agentToken, err := runWithFallback(ctx, a, opCfg, AgentTaskTitleAndSlug, func(cfg Config) (*ProjectTitleAndSlug, *AgentToken, error) {
+		return completeStructured(ctx, a, cfg, ProjectTitleAndSlugResponseSchema, fmt.Sprintf(b0ProjectTitleAndSlugSystemMessage, cfg.Model), prompt, checkProjectTitleAndSlug)
 	})
 
-	if err != nil {
-		return nil, agentToken, err
-	}
-
-	projectTitleAndSlug := chat.Choices[0].Message.Content
+	zerolog.Ctx(ctx).Info().Msgf("Generated title and slug: %s", agentToken.Output)
 
-	agentToken.Output = chat.Choices[0].Message.Content
-
-	zerolog.Ctx(ctx).Info().Msgf("Generated title and slug: %s", projectTitleAndSlug)
-
-	if strings.HasPrefix(projectTitleAndSlug, "I'm b0, an AI assistant") {
-		return nil, agentToken, fmt.Errorf("invalid prompt")
-	}
-
-	// cleanup the message
-	projectTitleAndSlug = removeJSONMarkdown(projectTitleAndSlug)
-
-	zerolog.Ctx(ctx).Info().Msgf("cleanup response: %s", strings.TrimSpace(projectTitleAndSlug))
-
-	// unmarshal the projectTitleAndSlug
-	var agentProjectTitleAndSlug *ProjectTitleAndSlug
-
-	if err := json.Unmarshal([]byte(projectTitleAndSlug), &agentProjectTitleAndSlug); err != nil {
+	if err != nil {
 		return nil, agentToken, err
 	}
 
-	zerolog.Ctx(ctx).Info().Msgf("%s", agentProjectTitleAndSlug)
-
 	return agentProjectTitleAndSlug, agentToken, nil
 }
 
-// GenerateWorkflow generates a workflow diagram based on the given prompt.
-func (a *Agent) GenerateWorkflow(ctx context.Context, options WorkflowGenerationOption, opts ...OptionFunc) ([]*Workflow, *AgentToken, error) {
+// GenerateWorkflow generates a workflow diagram based on the given prompt and the earlier conversation.
+func (a *Agent) GenerateWorkflow(ctx context.Context, options WorkflowGenerationOption, opts ...OptionFunc) (*WorkflowGenerationResponse, *AgentToken, error) {
 	opCfg := *a.cfg
 	for _, opt := range opts {
 		opt(&opCfg)
 	}
 
-	client := a.client()
-
-	otherInstructions := ""
+	// the workflows to update come first, the update system message lists them under its workflow diagram
+	otherInstructions := []string{}
 
 	if len(options.Workflows) > 0 {
 
 		workflowToString, _ := util.MarshalJSONToString(options.Workflows)
 
-		otherInstructions = workflowToString
+		otherInstructions = append(otherInstructions, workflowToString)
 	}
 
-	systemPrompt := fmt.Sprintf(b0ProjectWorkflowSystemMessage, a.cfg.Model, otherInstructions)
+	if len(options.Endpoints) > 0 {
+		endpointsToString, _ := util.MarshalJSONToString(options.Endpoints)
 
-	if len(options.Workflows) > 0 {
-		systemPrompt = fmt.Sprintf(b0UpdateProjectWorkflowSystemMessage, a.cfg.Model, otherInstructions)
+		otherInstructions = append(otherInstructions, fmt.Sprintf(b0NewEndpointsInstruction, endpointsToString))
 	}
 
-	agentToken := &AgentToken{
-		Input: fmt.Sprintf(`
-		%s
-		
-		%s
-		`, systemPrompt, options.Prompt),
+	task, systemMessage := AgentTaskWorkflowGeneration, b0ProjectWorkflowSystemMessage
+
+	if len(options.Workflows) > 0 {
+		task, systemMessage = AgentTaskWorkflowUpdate, b0UpdateProjectWorkflowSystemMessage
 	}
 
-	chat, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(options.Prompt),
-		},
-		Model: openai.ChatModel(a.cfg.Model),
+	response, agentToken, err := runWithFallback(ctx, a, opCfg, task, func(cfg Config) (*WorkflowGenerationResponse, *AgentToken, error) {
+		return completeConversation(ctx, a, cfg, WorkflowResponseSchema, fmt.Sprintf(systemMessage, cfg.Model, strings.Join(otherInstructions, "\n")), options.Prompt, options.History, checkWorkflows)
 	})
 
+	zerolog.Ctx(ctx).Info().Msgf("Generated workflows: %s", agentToken.Output)
+
 	if err != nil {
 		return nil, agentToken, err
 	}
 
-	workflowString := chat.Choices[0].Message.Content
+	return response, agentToken, nil
+}
+
+// CodeGeneration generates code from a workflow diagram.
+// When option.Previous is set only the files affected by the workflow changes since then are patched or replaced,
+// the other files are kept byte for byte.
+func (a *Agent) CodeGeneration(ctx context.Context, prompt string, option CodeGenerationOption, opts ...OptionFunc) (*CodeGeneration, *AgentToken, error) {
+	opCfg := *a.cfg
+	for _, opt := range opts {
+		opt(&opCfg)
+	}
 
-	zerolog.Ctx(ctx).Info().Msgf("Generated workflows: %s", workflowString)
+	workflowToString, err := util.MarshalJSONToString(option.Workflows)
 
-	agentToken.Output = workflowString
+	if err != nil {
+		return nil, a.agentToken(opCfg.Model), err
+	}
 
-	workflowString = removeJSONMarkdown(workflowString)
+	if option.Previous != nil && len(option.Previous.FileContents) > 0 {
+		return a.updateCode(ctx, prompt, option, opCfg, workflowToString)
+	}
 
-	var rawDogData map[string]interface{}
+	codeGeneration, agentToken, err := runWithFallback(ctx, a, opCfg, AgentTaskCodeGeneration, func(cfg Config) (*CodeGeneration, *AgentToken, error) {
+		systemPrompt := fmt.Sprintf(b0WorkflowToCodeGenerationSystemMessage, cfg.Model, option.Language, option.FrameworkInsructions, workflowToString)
+		return completeStructured(ctx, a, cfg, CodeGenerationResponseSchema, systemPrompt, prompt, checkCodeGeneration)
+	})
 
-	err = json.Unmarshal([]byte(workflowString), &rawDogData)
+	zerolog.Ctx(ctx).Info().Msgf("Generated code: %s", agentToken.Output)
 
 	if err != nil {
 		return nil, agentToken, err
 	}
 
-	var workflows []*Workflow
-	workflowsRaw, ok := rawDogData["workflows"].([]interface{})
-	if !ok {
-		return nil, agentToken, fmt.Errorf("invalid workflows format")
+	codeGeneration.Workflows = generatedFrom(option.Workflows)
+
+	return codeGeneration, agentToken, nil
+}
+
+// updateCode patches the previous code with the changes of the workflows it was generated from
+func (a *Agent) updateCode(ctx context.Context, prompt string, option CodeGenerationOption, opCfg Config, workflowToString string) (*CodeGeneration, *AgentToken, error) {
+	changes, err := DiffWorkflows(option.Previous.Workflows, option.Workflows)
+
+	if err != nil {
+		return nil, a.agentToken(opCfg.Model), err
+	}
+
+	if len(changes) == 0 {
+		code := *option.Previous
+		code.FileContents = append([]FileContent{}, option.Previous.FileContents...)
+
+		return &code, a.agentToken(opCfg.Model), nil
 	}
 
-	workflowsJson, err := json.Marshal(workflowsRaw)
+	changesToString := make([]string, 0, len(changes))
+
+	for _, change := range changes {
+		changesToString = append(changesToString, "- "+change.String())
+	}
+
+	filesToString, err := util.MarshalJSONToString(option.Previous.FileContents)
+
 	if err != nil {
-		return nil, agentToken, fmt.Errorf("failed to marshal workflows: %w", err)
+		return nil, a.agentToken(opCfg.Model), err
 	}
 
-	if err := json.Unmarshal(workflowsJson, &workflows); err != nil {
-		return nil, agentToken, fmt.Errorf("failed to unmarshal workflows: %w", err)
+	var code *CodeGeneration
+
+	// the patch is applied in the check so a hunk that doesn't match is repaired like any invalid response
+	_, agentToken, err := runWithFallback(ctx, a, opCfg, AgentTaskCodeGeneration, func(cfg Config) (*CodePatch, *AgentToken, error) {
+		systemPrompt := fmt.Sprintf(b0CodeUpdateSystemMessage, cfg.Model, option.Language, option.FrameworkInsructions, workflowToString, strings.Join(changesToString, "\n"), filesToString)
+		return completeStructured(ctx, a, cfg, CodePatchResponseSchema, systemPrompt, prompt, func(patch *CodePatch) (err error) {
+			code, err = option.Previous.ApplyPatch(patch)
+			return err
+		})
+	})
+
+	zerolog.Ctx(ctx).Info().Msgf("Updated code: %s", agentToken.Output)
+
+	if err != nil {
+		return nil, agentToken, err
 	}
 
-	return workflows, agentToken, nil
+	code.Workflows = generatedFrom(option.Workflows)
+
+	return code, agentToken, nil
 }
 
-// CodeGeneration generates code from a workflow diagram.
-func (a *Agent) CodeGeneration(ctx context.Context, prompt string, option CodeGenerationOption, opts ...OptionFunc) (*CodeGeneration, *AgentToken, error) {
+// generatedFrom returns the workflows recorded on the generated code, other workflow types are left unrecorded
+func generatedFrom(workflows interface{}) []*Workflow {
+	generated, _ := workflows.([]*Workflow)
+	return generated
+}
+
+// FixCode asks the model to fix code that failed to build, the files listed in the build output are the ones to fix.
+// The fixed files are applied to a copy of code.
+func (a *Agent) FixCode(ctx context.Context, option CodeGenerationOption, code *CodeGeneration, buildOutput string, opts ...OptionFunc) (*CodeGeneration, *AgentToken, error) {
 	opCfg := *a.cfg
 	for _, opt := range opts {
 		opt(&opCfg)
 	}
 
-	workflowToString, err := util.MarshalJSONToString(option.Workflows)
+	filesToString, err := util.MarshalJSONToString(code.FileContents)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, a.agentToken(opCfg.Model), err
 	}
 
-	agentToken := &AgentToken{
-		Input: fmt.Sprintf(`
-		%s
-		
-		%s
-		`, fmt.Sprintf(b0WorkflowToCodeGenerationSystemMessage, a.cfg.Model, option.Language, option.FrameworkInsructions, workflowToString), prompt),
-	}
+	prompt := fmt.Sprintf("Fix the build errors of the following files: %s", strings.Join(code.FilesIn(buildOutput), ", "))
 
-	client := a.client()
-
-	chat, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(fmt.Sprintf(b0WorkflowToCodeGenerationSystemMessage, a.cfg.Model, option.Language, option.FrameworkInsructions, workflowToString)),
-			openai.UserMessage(prompt),
-		},
-		Model: openai.ChatModel(a.cfg.Model),
+	fix, agentToken, err := runWithFallback(ctx, a, opCfg, AgentTaskCodeGeneration, func(cfg Config) (*CodeGeneration, *AgentToken, error) {
+		systemPrompt := fmt.Sprintf(b0CodeFixSystemMessage, cfg.Model, option.Language, option.FrameworkInsructions, filesToString, buildOutput)
+		return completeStructured(ctx, a, cfg, CodeGenerationResponseSchema, systemPrompt, prompt, checkCodeGeneration)
 	})
 
+	zerolog.Ctx(ctx).Info().Msgf("Fixed code: %s", agentToken.Output)
+
 	if err != nil {
 		return nil, agentToken, err
 	}
 
-	agentToken.Output = chat.Choices[0].Message.Content
-
-	zerolog.Ctx(ctx).Info().Msgf("Generated code: %s", chat.Choices[0].Message.Content)
+	return code.Apply(fix), agentToken, nil
+}
 
-	var codeGeneration *CodeGeneration
+func toModels(models []string) []AgentModel {
+	agentModels := []AgentModel{}
 
-	if err := json.Unmarshal([]byte(removeJSONMarkdown(chat.Choices[0].Message.Content)), &codeGeneration); err != nil {
-		return nil, agentToken, err
+	for _, model := range models {
+		agentModels = append(agentModels, AgentModel(model))
 	}
 
-	return codeGeneration, agentToken, nil
+	return agentModels
 }
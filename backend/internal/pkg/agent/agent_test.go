@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Agent_GenerateWorkflow_Instructions(t *testing.T) {
+	validResponse := `{"workflows": [
+		{"action_id": "1", "type": "request", "instruction": "List todos", "method": "GET", "url": "/todos"},
+		{"action_id": "2", "type": "response", "instruction": "Return the todos", "status": "200", "body": {"todos": []}}
+	]}`
+
+	workflows := []*Workflow{{ActionID: "1", Type: WorkflowTypeRequest, Instruction: "List todos", Method: "GET", Url: "/todos"}}
+	endpoints := []ChatEndpoint{{ID: "endpoint-id", Name: "Create todo", Method: "POST", Path: "/todos"}}
+
+	tests := []struct {
+		name         string
+		option       WorkflowGenerationOption
+		wantInSystem []string
+	}{
+		{
+			name:         "new_endpoints",
+			option:       WorkflowGenerationOption{Prompt: "list my todos", Endpoints: endpoints},
+			wantInSystem: []string{"## Existing Endpoints:", `"path":"/todos"`},
+		},
+		{
+			name:         "update_with_endpoints",
+			option:       WorkflowGenerationOption{Prompt: "list my todos", Workflows: workflows, Endpoints: endpoints},
+			wantInSystem: []string{"## Workflow Diagram:", `"url":"/todos"`, "## Existing Endpoints:", `"id":"endpoint-id"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{responses: []string{validResponse}}
+
+			a := &Agent{
+				cfg:       &Config{Model: AgentModelGPT4},
+				providers: map[ProviderName]Provider{ProviderOpenAI: provider},
+			}
+
+			_, _, err := a.GenerateWorkflow(context.Background(), tt.option)
+
+			require.NoError(t, err)
+			require.Len(t, provider.requests, 1)
+
+			for _, want := range tt.wantInSystem {
+				require.Contains(t, provider.requests[0].System, want)
+			}
+		})
+	}
+}
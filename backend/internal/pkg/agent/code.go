@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type FileDiffStatus string
+
+const (
+	FileDiffStatusAdded    FileDiffStatus = "added"
+	FileDiffStatusRemoved  FileDiffStatus = "removed"
+	FileDiffStatusModified FileDiffStatus = "modified"
+)
+
+// FileDiff is a file that changed between two versions of the code, Diff is its unified diff
+type FileDiff struct {
+	Filename string         `json:"filename"`
+	Status   FileDiffStatus `json:"status"`
+	Diff     string         `json:"diff"`
+}
+
+// FilesIn returns the files of the code named in the build output, every file when none is
+func (c *CodeGeneration) FilesIn(output string) []string {
+	files := []string{}
+
+	for _, file := range c.FileContents {
+		if strings.Contains(output, file.Filename) || strings.Contains(output, path.Base(file.Filename)) {
+			files = append(files, file.Filename)
+		}
+	}
+
+	if len(files) > 0 {
+		return files
+	}
+
+	for _, file := range c.FileContents {
+		files = append(files, file.Filename)
+	}
+
+	return files
+}
+
+// Apply returns a copy of the code with the files of the fix, the commands and env vars of the fix replace the ones it sets
+func (c *CodeGeneration) Apply(fix *CodeGeneration) *CodeGeneration {
+	code := *c
+	code.FileContents = make([]FileContent, 0, len(c.FileContents)+len(fix.FileContents))
+
+	fixed := map[string]FileContent{}
+
+	for _, file := range fix.FileContents {
+		fixed[file.Filename] = file
+	}
+
+	for _, file := range c.FileContents {
+		if fixedFile, ok := fixed[file.Filename]; ok {
+			file = fixedFile
+			delete(fixed, file.Filename)
+		}
+
+		code.FileContents = append(code.FileContents, file)
+	}
+
+	// files the fix adds keep the order of the fix
+	for _, file := range fix.FileContents {
+		if _, ok := fixed[file.Filename]; ok {
+			code.FileContents = append(code.FileContents, file)
+		}
+	}
+
+	if len(fix.InstallCommands) > 0 {
+		code.InstallCommands = fix.InstallCommands
+	}
+
+	if strings.TrimSpace(fix.BuildCommands) != "" {
+		code.BuildCommands = fix.BuildCommands
+	}
+
+	if strings.TrimSpace(fix.RunCommands) != "" {
+		code.RunCommands = fix.RunCommands
+	}
+
+	if len(fix.EnvVars) > 0 {
+		code.EnvVars = fix.EnvVars
+	}
+
+	return &code
+}
+
+// Hash returns the sha256 of the files, commands, env vars and tests of the code, the order of the files doesn't matter.
+// The workflows the code was generated from aren't hashed, the same code generated from other workflows has the same hash.
+func (c *CodeGeneration) Hash() string {
+	files := append([]FileContent{}, c.FileContents...)
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Filename < files[j].Filename
+	})
+
+	content, _ := json.Marshal(CodeGeneration{
+		FileContents:    files,
+		InstallCommands: c.InstallCommands,
+		BuildCommands:   c.BuildCommands,
+		RunCommands:     c.RunCommands,
+		EnvVars:         c.EnvVars,
+		Tests:           c.Tests,
+	})
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// DiffFiles returns the files that changed from the code to the other code sorted by filename, a nil code has no files
+func (c *CodeGeneration) DiffFiles(other *CodeGeneration) []FileDiff {
+	before := fileContents(c)
+	after := fileContents(other)
+
+	filenames := []string{}
+
+	for filename := range before {
+		filenames = append(filenames, filename)
+	}
+
+	for filename := range after {
+		if _, ok := before[filename]; !ok {
+			filenames = append(filenames, filename)
+		}
+	}
+
+	sort.Strings(filenames)
+
+	diffs := []FileDiff{}
+
+	for _, filename := range filenames {
+		a, inBefore := before[filename]
+		b, inAfter := after[filename]
+
+		if a == b && inBefore == inAfter {
+			continue
+		}
+
+		status := FileDiffStatusModified
+
+		fromFile, toFile := "a/"+filename, "b/"+filename
+
+		switch {
+		case !inBefore:
+			status = FileDiffStatusAdded
+			fromFile = "/dev/null"
+		case !inAfter:
+			status = FileDiffStatusRemoved
+			toFile = "/dev/null"
+		}
+
+		diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        diffLines(a),
+			B:        diffLines(b),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+
+		diffs = append(diffs, FileDiff{
+			Filename: filename,
+			Status:   status,
+			Diff:     diff,
+		})
+	}
+
+	return diffs
+}
+
+func fileContents(c *CodeGeneration) map[string]string {
+	files := map[string]string{}
+
+	if c == nil {
+		return files
+	}
+
+	for _, file := range c.FileContents {
+		files[file.Filename] = file.Content
+	}
+
+	return files
+}
+
+// diffLines splits the content in lines ending with a newline, the last line gets one when the file has none
+func diffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+
+	return lines
+}
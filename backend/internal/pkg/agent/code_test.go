@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CodeGeneration_FilesIn(t *testing.T) {
+	code := &CodeGeneration{FileContents: []FileContent{
+		{Filename: "main.go"},
+		{Filename: "internal/todo/handler.go"},
+		{Filename: "go.mod"},
+	}}
+
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{name: "relative_path", output: "./main.go:4:2: undefined: fmt", want: []string{"main.go"}},
+		{name: "base_name", output: "handler.go:12:5: missing return", want: []string{"internal/todo/handler.go"}},
+		{name: "no_file", output: "npm ERR! code ENOENT", want: []string{"main.go", "internal/todo/handler.go", "go.mod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, code.FilesIn(tt.output))
+		})
+	}
+}
+
+func Test_CodeGeneration_Apply(t *testing.T) {
+	code := &CodeGeneration{
+		FileContents:    []FileContent{{Filename: "main.go", Content: "broken"}, {Filename: "go.mod", Content: "module b0/todo"}},
+		InstallCommands: []string{"go mod tidy"},
+		BuildCommands:   "go build -o app .",
+		RunCommands:     "./app",
+		EnvVars:         []CodeGenEnvVar{{Key: "B0_PORT", Value: "8080"}},
+	}
+
+	fixed := code.Apply(&CodeGeneration{
+		FileContents:  []FileContent{{Filename: "todo.go", Content: "package main"}, {Filename: "main.go", Content: "fixed"}},
+		BuildCommands: "go build -o bin/app .",
+	})
+
+	require.Equal(t, &CodeGeneration{
+		FileContents: []FileContent{
+			{Filename: "main.go", Content: "fixed"},
+			{Filename: "go.mod", Content: "module b0/todo"},
+			{Filename: "todo.go", Content: "package main"},
+		},
+		InstallCommands: []string{"go mod tidy"},
+		BuildCommands:   "go build -o bin/app .",
+		RunCommands:     "./app",
+		EnvVars:         []CodeGenEnvVar{{Key: "B0_PORT", Value: "8080"}},
+	}, fixed)
+
+	require.Equal(t, "broken", code.FileContents[0].Content)
+}
+
+func Test_CodeGeneration_Hash(t *testing.T) {
+	code := &CodeGeneration{
+		FileContents: []FileContent{{Filename: "main.go", Content: "package main"}, {Filename: "go.mod", Content: "module todo"}},
+		RunCommands:  "./app",
+	}
+
+	reordered := &CodeGeneration{
+		FileContents: []FileContent{{Filename: "go.mod", Content: "module todo"}, {Filename: "main.go", Content: "package main"}},
+		RunCommands:  "./app",
+		Workflows:    []*Workflow{{Type: "request"}},
+	}
+
+	edited := &CodeGeneration{
+		FileContents: []FileContent{{Filename: "main.go", Content: "package main\n"}, {Filename: "go.mod", Content: "module todo"}},
+		RunCommands:  "./app",
+	}
+
+	require.Len(t, code.Hash(), 64)
+	require.Equal(t, code.Hash(), reordered.Hash())
+	require.NotEqual(t, code.Hash(), edited.Hash())
+}
+
+func Test_CodeGeneration_DiffFiles(t *testing.T) {
+	before := &CodeGeneration{FileContents: []FileContent{
+		{Filename: "main.go", Content: "package main\n\nfunc main() {\n}\n"},
+		{Filename: "go.sum", Content: "sum\n"},
+		{Filename: "go.mod", Content: "module todo\n"},
+	}}
+
+	after := &CodeGeneration{FileContents: []FileContent{
+		{Filename: "main.go", Content: "package main\n\nfunc main() {\n\tserve()\n}\n"},
+		{Filename: "go.mod", Content: "module todo\n"},
+		{Filename: "server.go", Content: "package main\n"},
+	}}
+
+	diffs := before.DiffFiles(after)
+
+	require.Equal(t, []FileDiff{
+		{
+			Filename: "go.sum",
+			Status:   FileDiffStatusRemoved,
+			Diff:     "--- a/go.sum\n+++ /dev/null\n@@ -1 +0,0 @@\n-sum\n",
+		},
+		{
+			Filename: "main.go",
+			Status:   FileDiffStatusModified,
+			Diff:     "--- a/main.go\n+++ b/main.go\n@@ -1,4 +1,5 @@\n package main\n \n func main() {\n+\tserve()\n }\n",
+		},
+		{
+			Filename: "server.go",
+			Status:   FileDiffStatusAdded,
+			Diff:     "--- /dev/null\n+++ b/server.go\n@@ -0,0 +1 @@\n+package main\n",
+		},
+	}, diffs)
+
+	require.Empty(t, after.DiffFiles(after))
+	require.Len(t, (*CodeGeneration)(nil).DiffFiles(after), 3)
+}
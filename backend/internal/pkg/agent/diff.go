@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// WorkflowChange is a value of the workflows that changed, a nil Before is an addition and a nil After a removal
+type WorkflowChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+func (c WorkflowChange) String() string {
+	before, _ := json.Marshal(c.Before)
+	after, _ := json.Marshal(c.After)
+
+	switch {
+	case c.Before == nil:
+		return fmt.Sprintf("added %s: %s", c.Path, after)
+	case c.After == nil:
+		return fmt.Sprintf("removed %s: %s", c.Path, before)
+	default:
+		return fmt.Sprintf("changed %s: %s -> %s", c.Path, before, after)
+	}
+}
+
+// DiffWorkflows returns the changes between two workflow documents, compared as JSON with arrays compared by index
+func DiffWorkflows(before, after interface{}) ([]WorkflowChange, error) {
+	a, err := toJSONValue(before)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := toJSONValue(after)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []WorkflowChange{}
+	diffValue("workflows", a, b, &changes)
+
+	return changes, nil
+}
+
+func toJSONValue(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func diffValue(path string, before, after interface{}, changes *[]WorkflowChange) {
+	switch a := before.(type) {
+	case map[string]interface{}:
+		if b, ok := after.(map[string]interface{}); ok {
+			keys := map[string]bool{}
+
+			for key := range a {
+				keys[key] = true
+			}
+
+			for key := range b {
+				keys[key] = true
+			}
+
+			sorted := make([]string, 0, len(keys))
+
+			for key := range keys {
+				sorted = append(sorted, key)
+			}
+
+			sort.Strings(sorted)
+
+			for _, key := range sorted {
+				diffValue(path+"."+key, a[key], b[key], changes)
+			}
+
+			return
+		}
+	case []interface{}:
+		if b, ok := after.([]interface{}); ok {
+			for i := 0; i < max(len(a), len(b)); i++ {
+				var x, y interface{}
+
+				if i < len(a) {
+					x = a[i]
+				}
+
+				if i < len(b) {
+					y = b[i]
+				}
+
+				diffValue(fmt.Sprintf("%s[%d]", path, i), x, y, changes)
+			}
+
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, WorkflowChange{Path: path, Before: before, After: after})
+	}
+}
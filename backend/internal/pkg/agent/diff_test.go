@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DiffWorkflows(t *testing.T) {
+	workflows := []*Workflow{
+		{Type: WorkflowTypeRequest, ActionID: "request", Method: "GET", Url: "/todos"},
+		{Type: WorkflowTypeResponse, ActionID: "response", Status: "200", Body: map[string]interface{}{"todos": "{{context.todos}}"}},
+	}
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   []string
+	}{
+		{
+			name:   "unchanged",
+			before: workflows,
+			after: []*Workflow{
+				{Type: WorkflowTypeRequest, ActionID: "request", Method: "GET", Url: "/todos"},
+				{Type: WorkflowTypeResponse, ActionID: "response", Status: "200", Body: map[string]interface{}{"todos": "{{context.todos}}"}},
+			},
+			want: []string{},
+		},
+		{
+			name:   "changed_value",
+			before: workflows,
+			after: []*Workflow{
+				{Type: WorkflowTypeRequest, ActionID: "request", Method: "GET", Url: "/todos"},
+				{Type: WorkflowTypeResponse, ActionID: "response", Status: "201", Body: map[string]interface{}{"todos": "{{context.todos}}"}},
+			},
+			want: []string{`changed workflows[1].status: "200" -> "201"`},
+		},
+		{
+			name:   "added_and_removed_values",
+			before: workflows,
+			after: []*Workflow{
+				{Type: WorkflowTypeRequest, ActionID: "request", Method: "GET", Url: "/todos", Name: "List todos"},
+				{Type: WorkflowTypeResponse, ActionID: "response", Status: "200", Body: map[string]interface{}{"items": "{{context.todos}}"}},
+			},
+			want: []string{
+				`added workflows[0].name: "List todos"`,
+				`added workflows[1].body.items: "{{context.todos}}"`,
+				`removed workflows[1].body.todos: "{{context.todos}}"`,
+			},
+		},
+		{
+			name:   "added_workflow",
+			before: workflows[:1],
+			after:  workflows,
+			want:   []string{`added workflows[1]: {"action_id":"response","body":{"todos":"{{context.todos}}"},"instruction":"","status":"200","type":"response"}`},
+		},
+		{
+			name:   "no_previous_workflows",
+			before: nil,
+			after:  workflows[:1],
+			want:   []string{`added workflows: [{"action_id":"request","instruction":"","method":"GET","type":"request","url":"/todos"}]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := DiffWorkflows(tt.before, tt.after)
+			require.NoError(t, err)
+
+			got := make([]string, 0, len(changes))
+
+			for _, change := range changes {
+				got = append(got, change.String())
+			}
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,743 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExpressionErrorCode classifies the errors of the expression language
+type ExpressionErrorCode string
+
+const (
+	ExpressionErrorSyntax    ExpressionErrorCode = "syntax"
+	ExpressionErrorUndefined ExpressionErrorCode = "undefined_variable"
+	ExpressionErrorType      ExpressionErrorCode = "type"
+	ExpressionErrorRuntime   ExpressionErrorCode = "runtime"
+)
+
+// expressionRoot is the only identifier an expression can read values from
+const expressionRoot = "context"
+
+// ExpressionError is returned when an expression can't be parsed, checked or evaluated.
+// Pos is the byte offset of the offending token in the source, Name is set for undefined variables.
+type ExpressionError struct {
+	Code    ExpressionErrorCode
+	Pos     int
+	Name    string
+	Message string
+}
+
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos+1)
+}
+
+func expressionErrorf(code ExpressionErrorCode, pos int, format string, args ...interface{}) *ExpressionError {
+	return &ExpressionError{
+		Code:    code,
+		Pos:     pos,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Expression is a parsed node condition or {{...}} interpolation, see b0ExpressionGrammar for the syntax
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// ParseExpression parses a condition e.g {{context.title}} == "" && len(context.request.body.items) > 0
+func ParseExpression(source string) (*Expression, error) {
+	p := &expressionParser{}
+
+	if err := p.init(source, 0); err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind == tokenEOF {
+		return nil, expressionErrorf(ExpressionErrorSyntax, 0, "empty expression")
+	}
+
+	root, err := p.parseExpression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if token := p.peek(); token.kind != tokenEOF {
+		return nil, expressionErrorf(ExpressionErrorSyntax, token.pos, "unexpected %s", token)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// References returns the context variables read by the expression e.g todos for context.todos[0].title
+func (e *Expression) References() []string {
+	var names []string
+
+	walkExpression(e.root, func(node exprNode) {
+		if name, ok := contextVariable(node); ok {
+			names = append(names, name)
+		}
+	})
+
+	return names
+}
+
+// Paths returns the context paths read by the expression e.g [request body title] for context.request.body.title.
+// A path stops at the first index that isn't a literal.
+func (e *Expression) Paths() [][]string {
+	var paths [][]string
+
+	var visit func(node exprNode)
+
+	visit = func(node exprNode) {
+		if path, ok := staticPath(node); ok {
+			if len(path) > 0 {
+				paths = append(paths, path)
+			}
+
+			return
+		}
+
+		switch node := node.(type) {
+		case *memberNode:
+			visit(node.target)
+		case *indexNode:
+			visit(node.target)
+			visit(node.index)
+		case *callNode:
+			for _, arg := range node.args {
+				visit(arg)
+			}
+		case *unaryNode:
+			visit(node.operand)
+		case *binaryNode:
+			visit(node.left)
+			visit(node.right)
+		}
+	}
+
+	visit(e.root)
+
+	return paths
+}
+
+// staticPath returns the path of a context read made of field names and literal indexes only
+func staticPath(node exprNode) ([]string, bool) {
+	switch node := node.(type) {
+	case *contextNode:
+		return []string{}, true
+	case *memberNode:
+		path, ok := staticPath(node.target)
+
+		if !ok {
+			return nil, false
+		}
+
+		return append(path, node.name), true
+	case *indexNode:
+		literal, isLiteral := node.index.(*literalNode)
+
+		if !isLiteral {
+			return nil, false
+		}
+
+		path, ok := staticPath(node.target)
+
+		if !ok {
+			return nil, false
+		}
+
+		return append(path, ExpressionString(literal.value)), true
+	}
+
+	return nil, false
+}
+
+// Template is a string with {{...}} interpolations e.g "Hello {{upper(context.user.name)}}"
+type Template struct {
+	source string
+	parts  []templatePart
+}
+
+type templatePart struct {
+	text       string
+	expression *Expression
+}
+
+// ParseTemplate parses every {{...}} found in the source, text outside of them is kept as is
+func ParseTemplate(source string) (*Template, error) {
+	t := &Template{source: source}
+	offset := 0
+
+	for {
+		start := strings.Index(source[offset:], "{{")
+
+		if start < 0 {
+			if offset < len(source) {
+				t.parts = append(t.parts, templatePart{text: source[offset:]})
+			}
+
+			return t, nil
+		}
+
+		start += offset
+
+		if start > offset {
+			t.parts = append(t.parts, templatePart{text: source[offset:start]})
+		}
+
+		p := &expressionParser{}
+
+		if err := p.init(source, start+2); err != nil {
+			return nil, err
+		}
+
+		if p.peek().kind == tokenTemplateClose {
+			return nil, expressionErrorf(ExpressionErrorSyntax, start, "empty interpolation")
+		}
+
+		root, err := p.parseExpression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		end := p.peek()
+
+		if end.kind != tokenTemplateClose {
+			return nil, expressionErrorf(ExpressionErrorSyntax, end.pos, "expected }} to close the interpolation, got %s", end)
+		}
+
+		t.parts = append(t.parts, templatePart{expression: &Expression{
+			source: strings.TrimSpace(source[start+2 : end.pos]),
+			root:   root,
+		}})
+
+		offset = end.pos + 2
+	}
+}
+
+func (t *Template) String() string {
+	return t.source
+}
+
+// Expressions returns the expressions interpolated in the template
+func (t *Template) Expressions() []*Expression {
+	var expressions []*Expression
+
+	for _, part := range t.parts {
+		if part.expression != nil {
+			expressions = append(expressions, part.expression)
+		}
+	}
+
+	return expressions
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenTemplateOpen
+	tokenTemplateClose
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int
+	text  string
+	value interface{}
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+var expressionOperators = []string{"{{", "}}", "==", "!=", ">=", "<=", "&&", "||", ">", "<", "!", "+", "-", "*", "/", "(", ")", "[", "]", ".", ","}
+
+// lexExpression splits the source into tokens from offset, it stops after a }} closing an interpolation
+func lexExpression(source string, offset int) ([]token, error) {
+	var tokens []token
+	depth := 0
+
+	for i := offset; ; {
+		for i < len(source) && strings.ContainsRune(" \t\r\n", rune(source[i])) {
+			i++
+		}
+
+		if i >= len(source) {
+			return append(tokens, token{kind: tokenEOF, pos: i}), nil
+		}
+
+		c := source[i]
+
+		switch {
+		case c == '_' || isLetter(c):
+			start := i
+
+			for i < len(source) && (source[i] == '_' || isLetter(source[i]) || isDigit(source[i])) {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, pos: start, text: source[start:i]})
+		case isDigit(c):
+			start := i
+
+			for i < len(source) && isDigit(source[i]) {
+				i++
+			}
+
+			if i+1 < len(source) && source[i] == '.' && isDigit(source[i+1]) {
+				i++
+
+				for i < len(source) && isDigit(source[i]) {
+					i++
+				}
+			}
+
+			number, err := strconv.ParseFloat(source[start:i], 64)
+
+			if err != nil {
+				return nil, expressionErrorf(ExpressionErrorSyntax, start, "invalid number %q", source[start:i])
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, pos: start, text: source[start:i], value: number})
+		case c == '"' || c == '\'':
+			start := i
+			value, end, err := lexString(source, i)
+
+			if err != nil {
+				return nil, err
+			}
+
+			i = end
+			tokens = append(tokens, token{kind: tokenString, pos: start, text: value, value: value})
+		default:
+			operator := ""
+
+			for _, candidate := range expressionOperators {
+				if strings.HasPrefix(source[i:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+
+			switch operator {
+			case "":
+				return nil, expressionErrorf(ExpressionErrorSyntax, i, "unexpected character %q", c)
+			case "{{":
+				depth++
+				tokens = append(tokens, token{kind: tokenTemplateOpen, pos: i, text: operator})
+			case "}}":
+				tokens = append(tokens, token{kind: tokenTemplateClose, pos: i, text: operator})
+
+				if depth == 0 {
+					return append(tokens, token{kind: tokenEOF, pos: i + len(operator)}), nil
+				}
+
+				depth--
+			default:
+				tokens = append(tokens, token{kind: tokenOperator, pos: i, text: operator})
+			}
+
+			i += len(operator)
+		}
+	}
+}
+
+// lexString reads the quoted string starting at start and returns its value and the offset after the closing quote
+func lexString(source string, start int) (string, int, error) {
+	quote := source[start]
+
+	var value strings.Builder
+
+	for i := start + 1; i < len(source); i++ {
+		c := source[i]
+
+		switch {
+		case c == quote:
+			return value.String(), i + 1, nil
+		case c == '\\' && i+1 < len(source):
+			i++
+
+			switch source[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				value.WriteByte(source[i])
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	return "", 0, expressionErrorf(ExpressionErrorSyntax, start, "unterminated string")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type exprNode interface {
+	position() int
+}
+
+type literalNode struct {
+	pos   int
+	value interface{}
+}
+
+// contextNode is the context identifier every path starts from
+type contextNode struct {
+	pos int
+}
+
+type memberNode struct {
+	pos    int
+	target exprNode
+	name   string
+}
+
+type indexNode struct {
+	pos    int
+	target exprNode
+	index  exprNode
+}
+
+type callNode struct {
+	pos  int
+	name string
+	args []exprNode
+}
+
+type unaryNode struct {
+	pos      int
+	operator string
+	operand  exprNode
+}
+
+type binaryNode struct {
+	pos      int
+	operator string
+	left     exprNode
+	right    exprNode
+}
+
+func (n *literalNode) position() int { return n.pos }
+func (n *contextNode) position() int { return n.pos }
+func (n *memberNode) position() int  { return n.pos }
+func (n *indexNode) position() int   { return n.pos }
+func (n *callNode) position() int    { return n.pos }
+func (n *unaryNode) position() int   { return n.pos }
+func (n *binaryNode) position() int  { return n.pos }
+
+// walkExpression calls fn for the node and all its descendants
+func walkExpression(node exprNode, fn func(exprNode)) {
+	fn(node)
+
+	switch node := node.(type) {
+	case *memberNode:
+		walkExpression(node.target, fn)
+	case *indexNode:
+		walkExpression(node.target, fn)
+		walkExpression(node.index, fn)
+	case *callNode:
+		for _, arg := range node.args {
+			walkExpression(arg, fn)
+		}
+	case *unaryNode:
+		walkExpression(node.operand, fn)
+	case *binaryNode:
+		walkExpression(node.left, fn)
+		walkExpression(node.right, fn)
+	}
+}
+
+// contextVariable returns the variable name when the node reads a top level context value e.g context.todos
+func contextVariable(node exprNode) (string, bool) {
+	switch node := node.(type) {
+	case *memberNode:
+		if _, ok := node.target.(*contextNode); ok {
+			return node.name, true
+		}
+	case *indexNode:
+		if _, ok := node.target.(*contextNode); ok {
+			if literal, ok := node.index.(*literalNode); ok {
+				if name, ok := literal.value.(string); ok {
+					return name, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+type expressionParser struct {
+	tokens  []token
+	current int
+}
+
+func (p *expressionParser) init(source string, offset int) error {
+	tokens, err := lexExpression(source, offset)
+
+	if err != nil {
+		return err
+	}
+
+	p.tokens = tokens
+
+	return nil
+}
+
+func (p *expressionParser) peek() token {
+	return p.tokens[p.current]
+}
+
+func (p *expressionParser) next() token {
+	token := p.tokens[p.current]
+
+	if token.kind != tokenEOF {
+		p.current++
+	}
+
+	return token
+}
+
+// match consumes the next token when it is one of the operators
+func (p *expressionParser) match(operators ...string) (token, bool) {
+	token := p.peek()
+
+	if token.kind != tokenOperator {
+		return token, false
+	}
+
+	for _, operator := range operators {
+		if token.text == operator {
+			return p.next(), true
+		}
+	}
+
+	return token, false
+}
+
+func (p *expressionParser) expect(operator string) error {
+	if token, ok := p.match(operator); !ok {
+		return expressionErrorf(ExpressionErrorSyntax, token.pos, "expected %q, got %s", operator, token)
+	}
+
+	return nil
+}
+
+func (p *expressionParser) parseExpression() (exprNode, error) {
+	return p.parseBinary(0)
+}
+
+// binaryPrecedence lists the binary operators from the lowest to the highest precedence
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", ">", ">=", "<", "<="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *expressionParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryPrecedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.match(binaryPrecedence[level]...)
+
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseBinary(level + 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryNode{pos: operator.pos, operator: operator.text, left: left, right: right}
+
+		// comparisons don't chain, a == b == c is a syntax error
+		if level == 2 {
+			if token, ok := p.match(binaryPrecedence[level]...); ok {
+				return nil, expressionErrorf(ExpressionErrorSyntax, token.pos, "comparisons can't be chained, use && instead")
+			}
+
+			return left, nil
+		}
+	}
+}
+
+func (p *expressionParser) parseUnary() (exprNode, error) {
+	if operator, ok := p.match("!", "-"); ok {
+		operand, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &unaryNode{pos: operator.pos, operator: operator.text, operand: operand}, nil
+	}
+
+	return p.parsePostfix()
+}
+
+func (p *expressionParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.match(".", "[")
+
+		if !ok {
+			return node, nil
+		}
+
+		if operator.text == "[" {
+			index, err := p.parseExpression()
+
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+
+			node = &indexNode{pos: operator.pos, target: node, index: index}
+			continue
+		}
+
+		segment := p.next()
+
+		switch segment.kind {
+		case tokenIdent:
+			node = &memberNode{pos: segment.pos, target: node, name: segment.text}
+		case tokenNumber:
+			// items.0.1 is lexed as items . 0.1, every part is an index
+			for _, part := range strings.Split(segment.text, ".") {
+				index, _ := strconv.ParseFloat(part, 64)
+				node = &indexNode{pos: segment.pos, target: node, index: &literalNode{pos: segment.pos, value: index}}
+			}
+		default:
+			return nil, expressionErrorf(ExpressionErrorSyntax, segment.pos, "expected a field name after \".\", got %s", segment)
+		}
+	}
+}
+
+func (p *expressionParser) parsePrimary() (exprNode, error) {
+	token := p.next()
+
+	switch token.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{pos: token.pos, value: token.value}, nil
+	case tokenTemplateOpen:
+		node, err := p.parseExpression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenTemplateClose {
+			return nil, expressionErrorf(ExpressionErrorSyntax, closing.pos, "expected }}, got %s", closing)
+		}
+
+		return node, nil
+	case tokenOperator:
+		if token.text != "(" {
+			break
+		}
+
+		node, err := p.parseExpression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	case tokenIdent:
+		switch token.text {
+		case "true":
+			return &literalNode{pos: token.pos, value: true}, nil
+		case "false":
+			return &literalNode{pos: token.pos, value: false}, nil
+		case "null":
+			return &literalNode{pos: token.pos, value: nil}, nil
+		case expressionRoot:
+			return &contextNode{pos: token.pos}, nil
+		}
+
+		if _, ok := p.match("("); !ok {
+			return nil, expressionErrorf(ExpressionErrorSyntax, token.pos, "unknown identifier %q, values are read from context e.g context.%s", token.text, token.text)
+		}
+
+		call := &callNode{pos: token.pos, name: token.text}
+
+		if _, ok := p.match(")"); ok {
+			return call, nil
+		}
+
+		for {
+			arg, err := p.parseExpression()
+
+			if err != nil {
+				return nil, err
+			}
+
+			call.args = append(call.args, arg)
+
+			if _, ok := p.match(","); ok {
+				continue
+			}
+
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+
+			return call, nil
+		}
+	}
+
+	return nil, expressionErrorf(ExpressionErrorSyntax, token.pos, "unexpected %s", token)
+}
@@ -0,0 +1,193 @@
+package agent
+
+import "strings"
+
+// ExpressionType is the static type the checker infers for an expression, values read from the context are any
+type ExpressionType string
+
+const (
+	ExpressionTypeAny    ExpressionType = "any"
+	ExpressionTypeNull   ExpressionType = "null"
+	ExpressionTypeBool   ExpressionType = "boolean"
+	ExpressionTypeNumber ExpressionType = "number"
+	ExpressionTypeString ExpressionType = "string"
+	ExpressionTypeArray  ExpressionType = "array"
+	ExpressionTypeObject ExpressionType = "object"
+)
+
+// Check type checks the expression, every context variable it reads must be in variables.
+// It returns all the errors found, nil when the expression is valid.
+func (e *Expression) Check(variables map[string]bool) []*ExpressionError {
+	c := &expressionChecker{variables: variables}
+	c.check(e.root)
+
+	return c.errors
+}
+
+// Type returns the static type of the expression, values read from the context are any
+func (e *Expression) Type() ExpressionType {
+	c := &expressionChecker{}
+
+	return c.check(e.root)
+}
+
+type expressionChecker struct {
+	variables map[string]bool
+	errors    []*ExpressionError
+}
+
+func (c *expressionChecker) fail(code ExpressionErrorCode, pos int, format string, args ...interface{}) {
+	c.errors = append(c.errors, expressionErrorf(code, pos, format, args...))
+}
+
+func (c *expressionChecker) check(node exprNode) ExpressionType {
+	if name, ok := contextVariable(node); ok && !c.variables[name] {
+		err := expressionErrorf(ExpressionErrorUndefined, node.position(), "context.%s is never defined", name)
+		err.Name = name
+		c.errors = append(c.errors, err)
+	}
+
+	switch node := node.(type) {
+	case *literalNode:
+		return literalType(node.value)
+	case *contextNode:
+		return ExpressionTypeObject
+	case *memberNode:
+		c.checkReadable(node.pos, c.check(node.target))
+		return ExpressionTypeAny
+	case *indexNode:
+		c.checkReadable(node.pos, c.check(node.target))
+
+		if index := c.check(node.index); !accepts([]ExpressionType{ExpressionTypeString, ExpressionTypeNumber}, index) {
+			c.fail(ExpressionErrorType, node.index.position(), "index must be a string or a number, got %s", index)
+		}
+
+		return ExpressionTypeAny
+	case *callNode:
+		return c.checkCall(node)
+	case *unaryNode:
+		operand := c.check(node.operand)
+
+		if node.operator == "!" {
+			return ExpressionTypeBool
+		}
+
+		c.expect(node.pos, "-", []ExpressionType{ExpressionTypeNumber, ExpressionTypeString}, operand)
+
+		return ExpressionTypeNumber
+	case *binaryNode:
+		return c.checkBinary(node)
+	}
+
+	return ExpressionTypeAny
+}
+
+// checkReadable reports fields read from a value that can't have any e.g "todo".title
+func (c *expressionChecker) checkReadable(pos int, target ExpressionType) {
+	if !accepts([]ExpressionType{ExpressionTypeObject, ExpressionTypeArray, ExpressionTypeNull}, target) {
+		c.fail(ExpressionErrorType, pos, "can't read a field of %s", target)
+	}
+}
+
+func (c *expressionChecker) checkCall(node *callNode) ExpressionType {
+	argTypes := make([]ExpressionType, 0, len(node.args))
+
+	for _, arg := range node.args {
+		argTypes = append(argTypes, c.check(arg))
+	}
+
+	fn, ok := expressionFunctions[node.name]
+
+	if !ok {
+		c.fail(ExpressionErrorType, node.pos, "unknown function %q", node.name)
+		return ExpressionTypeAny
+	}
+
+	if len(node.args) != len(fn.params) {
+		c.fail(ExpressionErrorType, node.pos, "%s expects %d arguments, got %d", node.name, len(fn.params), len(node.args))
+		return fn.result
+	}
+
+	for i, argType := range argTypes {
+		if !accepts(fn.params[i], argType) {
+			c.fail(ExpressionErrorType, node.args[i].position(), "argument %d of %s must be %s, got %s", i+1, node.name, typeList(fn.params[i]), argType)
+		}
+	}
+
+	return fn.result
+}
+
+func (c *expressionChecker) checkBinary(node *binaryNode) ExpressionType {
+	left, right := c.check(node.left), c.check(node.right)
+	ordered := []ExpressionType{ExpressionTypeNumber, ExpressionTypeString}
+
+	switch node.operator {
+	case "&&", "||", "==", "!=":
+		return ExpressionTypeBool
+	case ">", ">=", "<", "<=":
+		c.expect(node.pos, node.operator, ordered, left, right)
+		return ExpressionTypeBool
+	case "+":
+		c.expect(node.pos, node.operator, ordered, left, right)
+
+		switch {
+		case left == ExpressionTypeString || right == ExpressionTypeString:
+			return ExpressionTypeString
+		case left == ExpressionTypeNumber && right == ExpressionTypeNumber:
+			return ExpressionTypeNumber
+		default:
+			return ExpressionTypeAny
+		}
+	default:
+		c.expect(node.pos, node.operator, ordered, left, right)
+		return ExpressionTypeNumber
+	}
+}
+
+func (c *expressionChecker) expect(pos int, operator string, allowed []ExpressionType, operands ...ExpressionType) {
+	for _, operand := range operands {
+		if !accepts(allowed, operand) {
+			c.fail(ExpressionErrorType, pos, "%q expects %s, got %s", operator, typeList(allowed), operand)
+			return
+		}
+	}
+}
+
+func accepts(allowed []ExpressionType, actual ExpressionType) bool {
+	if actual == ExpressionTypeAny {
+		return true
+	}
+
+	for _, expressionType := range allowed {
+		if expressionType == ExpressionTypeAny || expressionType == actual {
+			return true
+		}
+	}
+
+	return false
+}
+
+func typeList(types []ExpressionType) string {
+	names := make([]string, 0, len(types))
+
+	for _, expressionType := range types {
+		names = append(names, string(expressionType))
+	}
+
+	return strings.Join(names, " or ")
+}
+
+func literalType(value interface{}) ExpressionType {
+	switch value.(type) {
+	case nil:
+		return ExpressionTypeNull
+	case bool:
+		return ExpressionTypeBool
+	case float64:
+		return ExpressionTypeNumber
+	case string:
+		return ExpressionTypeString
+	default:
+		return ExpressionTypeAny
+	}
+}
@@ -0,0 +1,566 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// expressionFunction is a built-in function, params lists the types accepted by every parameter
+type expressionFunction struct {
+	params [][]ExpressionType
+	result ExpressionType
+	call   func(args []interface{}) (interface{}, error)
+}
+
+var (
+	stringParam = []ExpressionType{ExpressionTypeString}
+	anyParam    = []ExpressionType{ExpressionTypeAny}
+
+	expressionFunctions = map[string]expressionFunction{
+		"len": {
+			params: [][]ExpressionType{{ExpressionTypeString, ExpressionTypeArray, ExpressionTypeObject, ExpressionTypeNull}},
+			result: ExpressionTypeNumber,
+			call: func(args []interface{}) (interface{}, error) {
+				switch value := args[0].(type) {
+				case nil:
+					return float64(0), nil
+				case string:
+					return float64(len([]rune(value))), nil
+				case []interface{}:
+					return float64(len(value)), nil
+				case map[string]interface{}:
+					return float64(len(value)), nil
+				}
+
+				return nil, fmt.Errorf("len expects a string, an array or an object, got %s", jsonTypeOf(args[0]))
+			},
+		},
+		"lower": stringFunction(strings.ToLower),
+		"upper": stringFunction(strings.ToUpper),
+		"trim":  stringFunction(strings.TrimSpace),
+		"contains": {
+			params: [][]ExpressionType{{ExpressionTypeString, ExpressionTypeArray}, anyParam},
+			result: ExpressionTypeBool,
+			call: func(args []interface{}) (interface{}, error) {
+				if items, ok := args[0].([]interface{}); ok {
+					for _, item := range items {
+						if expressionEqual(item, args[1]) {
+							return true, nil
+						}
+					}
+
+					return false, nil
+				}
+
+				return strings.Contains(ExpressionString(args[0]), ExpressionString(args[1])), nil
+			},
+		},
+		"starts_with": stringPredicate(strings.HasPrefix),
+		"ends_with":   stringPredicate(strings.HasSuffix),
+		"split": {
+			params: [][]ExpressionType{stringParam, stringParam},
+			result: ExpressionTypeArray,
+			call: func(args []interface{}) (interface{}, error) {
+				parts := strings.Split(ExpressionString(args[0]), ExpressionString(args[1]))
+				items := make([]interface{}, 0, len(parts))
+
+				for _, part := range parts {
+					items = append(items, part)
+				}
+
+				return items, nil
+			},
+		},
+		"join": {
+			params: [][]ExpressionType{{ExpressionTypeArray}, stringParam},
+			result: ExpressionTypeString,
+			call: func(args []interface{}) (interface{}, error) {
+				items, ok := args[0].([]interface{})
+
+				if !ok {
+					return nil, fmt.Errorf("join expects an array, got %s", jsonTypeOf(args[0]))
+				}
+
+				parts := make([]string, 0, len(items))
+
+				for _, item := range items {
+					parts = append(parts, ExpressionString(item))
+				}
+
+				return strings.Join(parts, ExpressionString(args[1])), nil
+			},
+		},
+		"default": {
+			params: [][]ExpressionType{anyParam, anyParam},
+			result: ExpressionTypeAny,
+			call: func(args []interface{}) (interface{}, error) {
+				if args[0] == nil || args[0] == "" {
+					return args[1], nil
+				}
+
+				return args[0], nil
+			},
+		},
+		"empty": {
+			params: [][]ExpressionType{anyParam},
+			result: ExpressionTypeBool,
+			call: func(args []interface{}) (interface{}, error) {
+				switch value := args[0].(type) {
+				case nil:
+					return true, nil
+				case string:
+					return value == "", nil
+				case []interface{}:
+					return len(value) == 0, nil
+				case map[string]interface{}:
+					return len(value) == 0, nil
+				}
+
+				return false, nil
+			},
+		},
+		"string": {
+			params: [][]ExpressionType{anyParam},
+			result: ExpressionTypeString,
+			call: func(args []interface{}) (interface{}, error) {
+				return ExpressionString(args[0]), nil
+			},
+		},
+		"number": {
+			params: [][]ExpressionType{{ExpressionTypeNumber, ExpressionTypeString}},
+			result: ExpressionTypeNumber,
+			call: func(args []interface{}) (interface{}, error) {
+				number, ok := expressionNumber(args[0])
+
+				if !ok {
+					return nil, fmt.Errorf("%s can't be converted to a number", ExpressionString(args[0]))
+				}
+
+				return number, nil
+			},
+		},
+	}
+)
+
+func stringFunction(fn func(string) string) expressionFunction {
+	return expressionFunction{
+		params: [][]ExpressionType{stringParam},
+		result: ExpressionTypeString,
+		call: func(args []interface{}) (interface{}, error) {
+			return fn(ExpressionString(args[0])), nil
+		},
+	}
+}
+
+func stringPredicate(fn func(string, string) bool) expressionFunction {
+	return expressionFunction{
+		params: [][]ExpressionType{stringParam, stringParam},
+		result: ExpressionTypeBool,
+		call: func(args []interface{}) (interface{}, error) {
+			return fn(ExpressionString(args[0]), ExpressionString(args[1])), nil
+		},
+	}
+}
+
+// Evaluate evaluates the expression against the context values, a missing value evaluates to null
+func (e *Expression) Evaluate(values map[string]interface{}) (interface{}, error) {
+	return evaluateNode(e.root, values)
+}
+
+// EvaluateBool evaluates the expression and checks the result for truthiness
+func (e *Expression) EvaluateBool(values map[string]interface{}) (bool, error) {
+	value, err := e.Evaluate(values)
+
+	if err != nil {
+		return false, err
+	}
+
+	return ExpressionTruthy(value), nil
+}
+
+// Evaluate interpolates the template. A template made of a single {{...}}, surrounding spaces aside, keeps the
+// type of the value, otherwise the values are formatted into the string.
+func (t *Template) Evaluate(values map[string]interface{}) (interface{}, error) {
+	if expressions := t.Expressions(); len(expressions) == 1 && strings.TrimSpace(t.text()) == "" {
+		return expressions[0].Evaluate(values)
+	}
+
+	var result strings.Builder
+
+	for _, part := range t.parts {
+		if part.expression == nil {
+			result.WriteString(part.text)
+			continue
+		}
+
+		value, err := part.expression.Evaluate(values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result.WriteString(ExpressionString(value))
+	}
+
+	return result.String(), nil
+}
+
+// text returns the template without its interpolations
+func (t *Template) text() string {
+	var text strings.Builder
+
+	for _, part := range t.parts {
+		text.WriteString(part.text)
+	}
+
+	return text.String()
+}
+
+func evaluateNode(node exprNode, values map[string]interface{}) (interface{}, error) {
+	switch node := node.(type) {
+	case *literalNode:
+		return node.value, nil
+	case *contextNode:
+		return values, nil
+	case *memberNode:
+		target, err := evaluateNode(node.target, values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return member(node.pos, target, node.name)
+	case *indexNode:
+		target, err := evaluateNode(node.target, values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		index, err := evaluateNode(node.index, values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if number, ok := normalizeValue(index).(float64); ok {
+			if items, ok := target.([]interface{}); ok {
+				if number < 0 || number >= float64(len(items)) || number != math.Trunc(number) {
+					return nil, nil
+				}
+
+				return normalizeValue(items[int(number)]), nil
+			}
+		}
+
+		return member(node.pos, target, ExpressionString(index))
+	case *callNode:
+		return evaluateCall(node, values)
+	case *unaryNode:
+		operand, err := evaluateNode(node.operand, values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if node.operator == "!" {
+			return !ExpressionTruthy(operand), nil
+		}
+
+		number, ok := expressionNumber(operand)
+
+		if !ok {
+			return nil, expressionErrorf(ExpressionErrorRuntime, node.pos, "can't negate %s", jsonTypeOf(operand))
+		}
+
+		return -number, nil
+	case *binaryNode:
+		return evaluateBinary(node, values)
+	}
+
+	return nil, fmt.Errorf("unknown expression node %T", node)
+}
+
+// member reads a field of an object or an element of an array, reading from null gives null
+func member(pos int, target interface{}, name string) (interface{}, error) {
+	switch target := target.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return normalizeValue(target[name]), nil
+	case []interface{}:
+		index, err := strconv.Atoi(name)
+
+		if err != nil || index < 0 || index >= len(target) {
+			return nil, nil
+		}
+
+		return normalizeValue(target[index]), nil
+	}
+
+	return nil, expressionErrorf(ExpressionErrorRuntime, pos, "can't read %q of %s", name, jsonTypeOf(target))
+}
+
+func evaluateCall(node *callNode, values map[string]interface{}) (interface{}, error) {
+	fn, ok := expressionFunctions[node.name]
+
+	if !ok {
+		return nil, expressionErrorf(ExpressionErrorRuntime, node.pos, "unknown function %q", node.name)
+	}
+
+	if len(node.args) != len(fn.params) {
+		return nil, expressionErrorf(ExpressionErrorRuntime, node.pos, "%s expects %d arguments, got %d", node.name, len(fn.params), len(node.args))
+	}
+
+	args := make([]interface{}, 0, len(node.args))
+
+	for _, arg := range node.args {
+		value, err := evaluateNode(arg, values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value)
+	}
+
+	result, err := fn.call(args)
+
+	if err != nil {
+		return nil, expressionErrorf(ExpressionErrorRuntime, node.pos, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
+func evaluateBinary(node *binaryNode, values map[string]interface{}) (interface{}, error) {
+	left, err := evaluateNode(node.left, values)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || short circuit
+	switch node.operator {
+	case "&&":
+		if !ExpressionTruthy(left) {
+			return false, nil
+		}
+	case "||":
+		if ExpressionTruthy(left) {
+			return true, nil
+		}
+	}
+
+	right, err := evaluateNode(node.right, values)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.operator {
+	case "&&", "||":
+		return ExpressionTruthy(right), nil
+	case "==":
+		return expressionEqual(left, right), nil
+	case "!=":
+		return !expressionEqual(left, right), nil
+	case ">", ">=", "<", "<=":
+		order, ok := expressionCompare(left, right)
+
+		if !ok {
+			return nil, expressionErrorf(ExpressionErrorRuntime, node.pos, "can't compare %s with %s", jsonTypeOf(left), jsonTypeOf(right))
+		}
+
+		switch node.operator {
+		case ">":
+			return order > 0, nil
+		case ">=":
+			return order >= 0, nil
+		case "<":
+			return order < 0, nil
+		default:
+			return order <= 0, nil
+		}
+	case "+":
+		_, leftIsString := left.(string)
+		_, rightIsString := right.(string)
+
+		if leftIsString || rightIsString {
+			return ExpressionString(left) + ExpressionString(right), nil
+		}
+	}
+
+	leftNumber, leftOk := expressionNumber(left)
+	rightNumber, rightOk := expressionNumber(right)
+
+	if !leftOk || !rightOk {
+		return nil, expressionErrorf(ExpressionErrorRuntime, node.pos, "%q expects numbers, got %s and %s", node.operator, jsonTypeOf(left), jsonTypeOf(right))
+	}
+
+	switch node.operator {
+	case "+":
+		return leftNumber + rightNumber, nil
+	case "-":
+		return leftNumber - rightNumber, nil
+	case "*":
+		return leftNumber * rightNumber, nil
+	default:
+		if rightNumber == 0 {
+			return nil, expressionErrorf(ExpressionErrorRuntime, node.pos, "division by zero")
+		}
+
+		return leftNumber / rightNumber, nil
+	}
+}
+
+// expressionEqual compares loosely: a number equals its string form and null equals the empty string
+func expressionEqual(left, right interface{}) bool {
+	left, right = normalizeValue(left), normalizeValue(right)
+
+	_, leftIsNumber := left.(float64)
+	_, rightIsNumber := right.(float64)
+
+	if leftIsNumber || rightIsNumber {
+		leftNumber, leftOk := expressionNumber(left)
+		rightNumber, rightOk := expressionNumber(right)
+
+		if leftOk && rightOk {
+			return leftNumber == rightNumber
+		}
+	}
+
+	if isScalar(left) && isScalar(right) {
+		return ExpressionString(left) == ExpressionString(right)
+	}
+
+	return reflect.DeepEqual(left, right)
+}
+
+// expressionCompare orders numbers, or numeric strings compared with a number, numerically and strings alphabetically
+func expressionCompare(left, right interface{}) (int, bool) {
+	left, right = normalizeValue(left), normalizeValue(right)
+
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+
+	if leftIsString && rightIsString {
+		return strings.Compare(leftString, rightString), true
+	}
+
+	leftNumber, leftOk := expressionNumber(left)
+	rightNumber, rightOk := expressionNumber(right)
+
+	if !leftOk || !rightOk {
+		return 0, false
+	}
+
+	switch {
+	case leftNumber < rightNumber:
+		return -1, true
+	case leftNumber > rightNumber:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// ExpressionTruthy reports whether a value passes a condition. null, false, 0, "", "false", "0" and empty
+// arrays or objects are falsy.
+func ExpressionTruthy(value interface{}) bool {
+	switch value := normalizeValue(value).(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != "" && value != "false" && value != "0"
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	default:
+		return true
+	}
+}
+
+// ExpressionString formats a value the way it is interpolated in a string, arrays and objects as JSON
+func ExpressionString(value interface{}) string {
+	switch value := normalizeValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		raw, err := json.Marshal(value)
+
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+
+		return string(raw)
+	}
+}
+
+func expressionNumber(value interface{}) (float64, bool) {
+	switch value := normalizeValue(value).(type) {
+	case float64:
+		return value, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// normalizeValue converts Go numbers and maps to the JSON types the language works with
+func normalizeValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case int:
+		return float64(value)
+	case int32:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case float32:
+		return float64(value)
+	case map[string]string:
+		values := make(map[string]interface{}, len(value))
+
+		for key, item := range value {
+			values[key] = item
+		}
+
+		return values
+	case []string:
+		items := make([]interface{}, 0, len(value))
+
+		for _, item := range value {
+			items = append(items, item)
+		}
+
+		return items
+	default:
+		return value
+	}
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
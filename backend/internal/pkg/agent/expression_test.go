@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Expression_Evaluate(t *testing.T) {
+	values := map[string]interface{}{
+		"request": map[string]interface{}{
+			"method":  "POST",
+			"params":  map[string]interface{}{"id": "42"},
+			"headers": map[string]interface{}{"content-type": "application/json"},
+			"body": map[string]interface{}{
+				"title": "  Buy Milk ",
+				"tags":  []interface{}{"home", "shopping"},
+				"items": []interface{}{
+					map[string]interface{}{"name": "milk", "quantity": float64(2)},
+				},
+			},
+		},
+		"count": 3,
+		"empty": "",
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+		wantErr    string
+	}{
+		{name: "path", expression: "context.request.body.items[0].name", want: "milk"},
+		{name: "numeric_segment", expression: "context.request.body.items.0.quantity", want: float64(2)},
+		{name: "bracket_string", expression: `context.request.headers["content-type"]`, want: "application/json"},
+		{name: "missing_path_is_null", expression: "context.request.body.user.name", want: nil},
+		{name: "template", expression: "{{context.request.method}} == 'POST'", want: true},
+		{name: "loose_number_equality", expression: "context.request.params.id == 42", want: true},
+		{name: "null_equals_empty_string", expression: "context.missing == ''", want: true},
+		{name: "go_int_value", expression: "context.count >= 3", want: true},
+		{name: "precedence", expression: "1 + 2 * 3 == 7 && !(2 > 3)", want: true},
+		{name: "or_short_circuit", expression: "true || context.request.method > 1", want: true},
+		{name: "string_concat", expression: "'#' + context.request.params.id", want: "#42"},
+		{name: "string_helpers", expression: "lower(trim(context.request.body.title))", want: "buy milk"},
+		{name: "contains_array", expression: "contains(context.request.body.tags, 'home')", want: true},
+		{name: "starts_with", expression: "starts_with(context.request.method, 'PO')", want: true},
+		{name: "len", expression: "len(context.request.body.items) + len(context.missing)", want: float64(1)},
+		{name: "join_split", expression: "join(split('a,b', ','), '-')", want: "a-b"},
+		{name: "default", expression: "default(context.empty, 'untitled')", want: "untitled"},
+		{name: "number", expression: "number(context.request.params.id) / 2", want: float64(21)},
+		{name: "negate", expression: "-context.count", want: float64(-3)},
+		{name: "division_by_zero", expression: "context.count / 0", wantErr: "division by zero at position 15"},
+		{name: "compare_types", expression: "context.request.body.tags > 1", wantErr: "can't compare array with number at position 27"},
+		{name: "read_field_of_string", expression: "context.request.method.name", wantErr: `can't read "name" of string at position 24`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := ParseExpression(tt.expression)
+			require.NoError(t, err)
+
+			got, err := expression.Evaluate(values)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ParseExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{name: "valid", expression: "{{context.title}} == '' || len(context.todos) > 0"},
+		{name: "empty", expression: "  ", wantErr: "empty expression at position 1"},
+		{name: "bare_identifier", expression: "title == ''", wantErr: `unknown identifier "title", values are read from context e.g context.title at position 1`},
+		{name: "chained_comparison", expression: "1 < 2 < 3", wantErr: "comparisons can't be chained, use && instead at position 7"},
+		{name: "unterminated_string", expression: "context.title == 'todo", wantErr: "unterminated string at position 18"},
+		{name: "unclosed_call", expression: "len(context.todos", wantErr: `expected ")", got end of expression at position 18`},
+		{name: "unclosed_template", expression: "{{context.title == ''", wantErr: "expected }}, got end of expression at position 22"},
+		{name: "trailing_token", expression: "context.title context.body", wantErr: `unexpected "context" at position 15`},
+		{name: "unknown_character", expression: "context.title = 'a'", wantErr: `unexpected character '=' at position 15`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseExpression(tt.expression)
+
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_Expression_Check(t *testing.T) {
+	variables := map[string]bool{"request": true, "todos": true}
+
+	tests := []struct {
+		name       string
+		expression string
+		wantErrs   []string
+	}{
+		{name: "valid", expression: "len(context.todos) > 0 && lower(context.request.method) == 'get'"},
+		{name: "undefined_variable", expression: "context.user.name == ''", wantErrs: []string{"context.user is never defined at position 9"}},
+		{name: "unknown_function", expression: "size(context.todos)", wantErrs: []string{`unknown function "size" at position 1`}},
+		{name: "arity", expression: "contains(context.todos)", wantErrs: []string{"contains expects 2 arguments, got 1 at position 1"}},
+		{name: "argument_type", expression: "upper(1)", wantErrs: []string{"argument 1 of upper must be string, got number at position 7"}},
+		{name: "comparison_type", expression: "context.todos > true", wantErrs: []string{`">" expects number or string, got boolean at position 15`}},
+		{name: "field_of_literal", expression: "'todo'.title", wantErrs: []string{"can't read a field of string at position 8"}},
+		{name: "nested_errors", expression: "len(context.user) > upper(null)", wantErrs: []string{
+			"context.user is never defined at position 13",
+			"argument 1 of upper must be string, got null at position 27",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := ParseExpression(tt.expression)
+			require.NoError(t, err)
+
+			var gotErrs []string
+
+			for _, err := range expression.Check(variables) {
+				gotErrs = append(gotErrs, err.Error())
+			}
+
+			require.Equal(t, tt.wantErrs, gotErrs)
+		})
+	}
+}
+
+func Test_Template(t *testing.T) {
+	values := map[string]interface{}{
+		"todos": []interface{}{"milk"},
+		"user":  map[string]interface{}{"name": "ada"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     interface{}
+		wantErr  string
+	}{
+		{name: "text", template: "hello", want: "hello"},
+		{name: "surrounding_spaces_keep_type", template: " {{ context.todos }} ", want: []interface{}{"milk"}},
+		{name: "text_formats_value", template: "todos: {{context.todos}}", want: `todos: ["milk"]`},
+		{name: "whole_keeps_type", template: "{{context.todos}}", want: []interface{}{"milk"}},
+		{name: "interpolation", template: "Hello {{upper(context.user.name)}}, you have {{len(context.todos)}} todo", want: "Hello ADA, you have 1 todo"},
+		{name: "closing_braces_in_string", template: "{{'}}' + context.user.name}}", want: "}}ada"},
+		{name: "empty_interpolation", template: "Hello {{ }}", wantErr: "empty interpolation at position 7"},
+		{name: "unclosed", template: "Hello {{context.user", wantErr: "expected }} to close the interpolation, got end of expression at position 21"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := ParseTemplate(tt.template)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			got, err := template.Evaluate(values)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Expression_Paths(t *testing.T) {
+	expression, err := ParseExpression(`len(context.request.body.items) > 0 && context.todos[context.index].title != context.request.headers["x-id"]`)
+	require.NoError(t, err)
+
+	require.Equal(t, [][]string{
+		{"request", "body", "items"},
+		{"todos"},
+		{"index"},
+		{"request", "headers", "x-id"},
+	}, expression.Paths())
+}
@@ -0,0 +1,52 @@
+package agent
+
+const (
+	// maxHistoryMessages and maxHistoryChars bound the earlier turns of a conversation sent with a prompt
+	maxHistoryMessages = 20
+	maxHistoryChars    = 12000
+)
+
+// conversation returns the messages of the prompt preceded by the most recent turns of the history that fit
+// the history budget. Consecutive turns of the same role are merged and the conversation always starts with
+// a user turn, the way providers expect the messages to alternate.
+func conversation(history []Message, prompt string) []Message {
+	merged := []Message{}
+
+	turns := append(append([]Message{}, history...), Message{Role: MessageRoleUser, Content: prompt})
+
+	for _, message := range turns {
+		if message.Content == "" {
+			continue
+		}
+
+		if last := len(merged) - 1; last >= 0 && merged[last].Role == message.Role {
+			merged[last].Content += "\n\n" + message.Content
+			continue
+		}
+
+		merged = append(merged, message)
+	}
+
+	if len(merged) == 0 {
+		return []Message{{Role: MessageRoleUser, Content: prompt}}
+	}
+
+	// the last turn holds the prompt and is always sent
+	start, chars := len(merged)-1, 0
+
+	for start > 0 && len(merged)-start <= maxHistoryMessages {
+		chars += len(merged[start-1].Content)
+
+		if chars > maxHistoryChars {
+			break
+		}
+
+		start--
+	}
+
+	for start < len(merged)-1 && merged[start].Role != MessageRoleUser {
+		start++
+	}
+
+	return merged[start:]
+}
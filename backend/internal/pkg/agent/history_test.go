@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_conversation(t *testing.T) {
+	long := strings.Repeat("a", maxHistoryChars)
+
+	tests := []struct {
+		name    string
+		history []Message
+		prompt  string
+		want    []Message
+	}{
+		{
+			name:   "no_history",
+			prompt: "Add a todo",
+			want:   []Message{{Role: MessageRoleUser, Content: "Add a todo"}},
+		},
+		{
+			name: "history_before_the_prompt",
+			history: []Message{
+				{Role: MessageRoleUser, Content: "Add a todo"},
+				{Role: MessageRoleAssistant, Content: "Added a todo"},
+			},
+			prompt: "Undo that last change",
+			want: []Message{
+				{Role: MessageRoleUser, Content: "Add a todo"},
+				{Role: MessageRoleAssistant, Content: "Added a todo"},
+				{Role: MessageRoleUser, Content: "Undo that last change"},
+			},
+		},
+		{
+			name: "unanswered_prompt_is_merged",
+			history: []Message{
+				{Role: MessageRoleUser, Content: "Add a todo"},
+				{Role: MessageRoleAssistant, Content: "Added a todo"},
+				{Role: MessageRoleUser, Content: "Remove it"},
+			},
+			prompt: "Remove the todo",
+			want: []Message{
+				{Role: MessageRoleUser, Content: "Add a todo"},
+				{Role: MessageRoleAssistant, Content: "Added a todo"},
+				{Role: MessageRoleUser, Content: "Remove it\n\nRemove the todo"},
+			},
+		},
+		{
+			name: "old_turns_over_the_budget_are_dropped",
+			history: []Message{
+				{Role: MessageRoleUser, Content: long},
+				{Role: MessageRoleAssistant, Content: "Done"},
+				{Role: MessageRoleUser, Content: "Add a todo"},
+				{Role: MessageRoleAssistant, Content: "Added a todo"},
+			},
+			prompt: "Undo that last change",
+			want: []Message{
+				{Role: MessageRoleUser, Content: "Add a todo"},
+				{Role: MessageRoleAssistant, Content: "Added a todo"},
+				{Role: MessageRoleUser, Content: "Undo that last change"},
+			},
+		},
+		{
+			name: "window_starts_with_a_user_turn",
+			history: []Message{
+				{Role: MessageRoleUser, Content: long},
+				{Role: MessageRoleAssistant, Content: "Added a todo"},
+			},
+			prompt: "Undo that last change",
+			want: []Message{
+				{Role: MessageRoleUser, Content: "Undo that last change"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, conversation(tt.history, tt.prompt))
+		})
+	}
+}
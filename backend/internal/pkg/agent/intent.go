@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mujhtech/b0/internal/util"
+	"github.com/rs/zerolog"
+)
+
+type ChatIntentType string
+
+const (
+	ChatIntentModifyEndpoint  ChatIntentType = "modify_endpoint"
+	ChatIntentCreateEndpoints ChatIntentType = "create_endpoints"
+	ChatIntentDeleteEndpoint  ChatIntentType = "delete_endpoint"
+)
+
+// ChatEndpoint is the summary of an endpoint of the project the chat prompt may refer to
+type ChatEndpoint struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+type ChatIntentOption struct {
+	Prompt string `json:"prompt"`
+	// EndpointID is the endpoint the user is looking at, empty when the chat is about the whole project
+	EndpointID string         `json:"endpoint_id"`
+	Endpoints  []ChatEndpoint `json:"endpoints"`
+	// History is the earlier conversation, oldest first, only its most recent turns are sent
+	History []Message `json:"-"`
+}
+
+type ChatIntent struct {
+	Intent      ChatIntentType `json:"intent" jsonschema:"enum=modify_endpoint,enum=create_endpoints,enum=delete_endpoint" jsonschema_description:"What the user wants to do with the endpoints"`
+	EndpointID  string         `json:"endpoint_id" jsonschema_description:"The id of the endpoint to modify or delete, empty when endpoints are created"`
+	Explanation string         `json:"explanation" jsonschema_description:"A short sentence for the user describing what was understood"`
+}
+
+// ClassifyChatIntent decides whether the chat prompt modifies an endpoint, creates new endpoints or deletes one.
+func (a *Agent) ClassifyChatIntent(ctx context.Context, option ChatIntentOption, opts ...OptionFunc) (*ChatIntent, *AgentToken, error) {
+	opCfg := *a.cfg
+	for _, opt := range opts {
+		opt(&opCfg)
+	}
+
+	currentEndpoint := "None, the user is chatting about the whole project."
+
+	if option.EndpointID != "" {
+		currentEndpoint = option.EndpointID
+	}
+
+	endpointsToString, _ := util.MarshalJSONToString(option.Endpoints)
+
+	intent, agentToken, err := runWithFallback(ctx, a, opCfg, AgentTaskChatIntent, func(cfg Config) (*ChatIntent, *AgentToken, error) {
+		return completeConversation(ctx, a, cfg, ChatIntentResponseSchema, fmt.Sprintf(b0ChatIntentSystemMessage, cfg.Model, currentEndpoint, endpointsToString), option.Prompt, option.History, func(intent *ChatIntent) error {
+			return checkChatIntent(intent, option.Endpoints)
+		})
+	})
+
+	zerolog.Ctx(ctx).Info().Msgf("Classified chat intent: %s", agentToken.Output)
+
+	if err != nil {
+		return nil, agentToken, err
+	}
+
+	return intent, agentToken, nil
+}
+
+func checkChatIntent(intent *ChatIntent, endpoints []ChatEndpoint) error {
+	switch intent.Intent {
+	case ChatIntentCreateEndpoints:
+		return nil
+	case ChatIntentModifyEndpoint, ChatIntentDeleteEndpoint:
+		if !slices.ContainsFunc(endpoints, func(endpoint ChatEndpoint) bool { return endpoint.ID == intent.EndpointID }) {
+			return fmt.Errorf("endpoint_id %q is not one of the endpoints of the project", intent.EndpointID)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown intent %q, expected %s, %s or %s", intent.Intent, ChatIntentModifyEndpoint, ChatIntentCreateEndpoints, ChatIntentDeleteEndpoint)
+	}
+}
+
+// SplitEndpointWorkflows splits the workflows at every top level request node, each part is the workflow of an endpoint.
+// Nodes before the first request node don't belong to any endpoint and are dropped.
+func SplitEndpointWorkflows(workflows []*Workflow) [][]*Workflow {
+	endpoints := [][]*Workflow{}
+
+	for _, workflow := range workflows {
+		if workflow == nil {
+			continue
+		}
+
+		if workflow.Type == WorkflowTypeRequest {
+			endpoints = append(endpoints, []*Workflow{workflow})
+			continue
+		}
+
+		if last := len(endpoints) - 1; last >= 0 {
+			endpoints[last] = append(endpoints[last], workflow)
+		}
+	}
+
+	return endpoints
+}
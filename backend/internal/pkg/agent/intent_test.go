@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkChatIntent(t *testing.T) {
+	endpoints := []ChatEndpoint{{ID: "endpoint-id", Name: "List todos", Method: "GET", Path: "/todos"}}
+
+	tests := []struct {
+		name    string
+		intent  ChatIntent
+		wantErr bool
+	}{
+		{
+			name:   "modify_known_endpoint",
+			intent: ChatIntent{Intent: ChatIntentModifyEndpoint, EndpointID: "endpoint-id"},
+		},
+		{
+			name:   "create_without_endpoint",
+			intent: ChatIntent{Intent: ChatIntentCreateEndpoints},
+		},
+		{
+			name:    "delete_unknown_endpoint",
+			intent:  ChatIntent{Intent: ChatIntentDeleteEndpoint, EndpointID: "other-endpoint-id"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown_intent",
+			intent:  ChatIntent{Intent: "rename_project"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkChatIntent(&tt.intent, endpoints)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_SplitEndpointWorkflows(t *testing.T) {
+	create := &Workflow{Type: WorkflowTypeRequest, Method: "POST", Url: "/todos"}
+	created := &Workflow{Type: WorkflowTypeResponse, Status: "201"}
+	remove := &Workflow{Type: WorkflowTypeRequest, Method: "DELETE", Url: "/todos/{id}"}
+	removed := &Workflow{Type: WorkflowTypeResponse, Status: "204"}
+
+	got := SplitEndpointWorkflows([]*Workflow{
+		{Type: WorkflowTypeVariable, Name: "orphan"},
+		create,
+		created,
+		remove,
+		removed,
+	})
+
+	require.Equal(t, [][]*Workflow{{create, created}, {remove, removed}}, got)
+}
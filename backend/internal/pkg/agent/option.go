@@ -1,6 +1,10 @@
 package agent
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/mujhtech/b0/config"
+)
 
 type AgentModel string
 type WorkflowType string
@@ -35,6 +39,12 @@ const (
 	WorkflowTypeSupabase WorkflowType = "supabase"
 	WorkflowTypeStripe   WorkflowType = "stripe"
 
+	LanguageGo     = "Go"
+	LanguageNodeJS = "Node.js (TypeScript)"
+	LanguagePython = "Python"
+	LanguageRust   = "Rust"
+	LanguageJava   = "Java"
+
 	nodeJSInstructions = `
 	## Integration Instructions
 	- Github
@@ -406,15 +416,241 @@ const (
 	Make sure to include go mod command in installCommands to initialize the go module and install all dependencies e.g go mod init b0/{project-name} follow by go mod tidy
 	Make sure to enclose all structs in the code with json tags e.g json:"name"
 	`
+
+	pythonInstructions = `
+	## Integration Instructions
+	- Resend
+	Use the resend package to interact with the Resend API:
+	requirements:
+	resend==2.6.0
+
+	e.g To send an email:
+	import os
+	import resend
+
+	resend.api_key = os.environ["B0_RESEND_KEY"]
+
+	resend.Emails.send({
+		"from": os.environ["B0_RESEND_FROM"],
+		"to": [os.environ["B0_RESEND_TO"]],
+		"subject": "Hello World",
+		"html": "<strong>It works!</strong>",
+	})
+
+	- Slack
+	Use the slack_sdk package to interact with the Slack API:
+	requirements:
+	slack_sdk==3.34.0
+
+	e.g To send a message to a channel:
+	import os
+	from slack_sdk import WebClient
+
+	client = WebClient(token=os.environ["B0_SLACK_KEY"])
+	client.chat_postMessage(channel=os.environ["B0_SLACK_CHANNEL_ID"], text="Hello, world!")
+
+	- Stripe
+	Use the stripe package to interact with the Stripe API:
+	requirements:
+	stripe==11.5.0
+
+	e.g To create a new customer:
+	import os
+	import stripe
+
+	stripe.api_key = os.environ["B0_STRIPE_KEY"]
+	customer = stripe.Customer.create(email="jenny.rosen@example.com")
+
+	- Supabase
+	Use the supabase package to interact with the Supabase API:
+	requirements:
+	supabase==2.13.0
+
+	e.g To select rows of a table:
+	import os
+	from supabase import create_client
+
+	supabase = create_client(os.environ["B0_SUPABASE_URL"], os.environ["B0_SUPABASE_KEY"])
+	response = supabase.table("users").select("*").execute()
+
+	- Github, Discord, Telegram
+	Use httpx to call their REST API e.g to send a Telegram message:
+	requirements:
+	httpx==0.28.1
+
+	import os
+	import httpx
+
+	async with httpx.AsyncClient() as client:
+		await client.post(
+			"https://api.telegram.org/bot" + os.environ["B0_TELEGRAM_BOT_TOKEN"] + "/sendMessage",
+			json={"chat_id": "<chat_id>", "text": "Hello, world!"},
+		)
+
+	- OpenAI, Gemini, Anthropic, DeepSeek
+	Use the openai package for OpenAI, Gemini and DeepSeek, they share the OpenAI API with a different base url:
+	requirements:
+	openai==1.63.2
+
+	import os
+	from openai import AsyncOpenAI
+
+	base_urls = {
+		"openai": "https://api.openai.com/v1",
+		"deepseek": "https://api.deepseek.com/v1",
+		"gemini": "https://generativelanguage.googleapis.com/v1beta/openai/",
+	}
+
+	client = AsyncOpenAI(api_key=os.environ["B0_OPENAI_KEY"], base_url=base_urls["openai"])
+
+	chat = await client.chat.completions.create(
+		model="gpt-4o",
+		messages=[
+			{"role": "system", "content": "You are a friendly assistant!"},
+			{"role": "user", "content": "Why is the sky blue?"},
+		],
+	)
+	print(chat.choices[0].message.content)
+
+	Use the anthropic package for Anthropic:
+	requirements:
+	anthropic==0.46.0
+
+	from anthropic import AsyncAnthropic
+
+	client = AsyncAnthropic(api_key=os.environ["B0_ANTHROPIC_KEY"])
+
+	message = await client.messages.create(
+		model="claude-3-haiku-20240307",
+		max_tokens=1024,
+		messages=[{"role": "user", "content": "Write a vegetarian lasagna recipe for 4 people."}],
+	)
+
+	Make sure to add requirements.txt with every package used pinned to a version
+	Make sure to use pip install -r requirements.txt for installCommands
+	Read environment variables with os.environ or os.getenv, never hardcode an api key
+	`
+
+	rustInstructions = `
+	## Integration Instructions
+	Use reqwest with the json and rustls-tls features and default-features = false to call the REST API of every integration, the container has no openssl.
+	Use serde and serde_json to build and read the json bodies.
+
+	dependencies:
+	reqwest = { version = "0.12", default-features = false, features = ["json", "rustls-tls"] }
+	serde = { version = "1", features = ["derive"] }
+	serde_json = "1"
+
+	e.g To send an email with Resend:
+	use serde_json::json;
+
+	let client = reqwest::Client::new();
+	let api_key = std::env::var("B0_RESEND_KEY").expect("B0_RESEND_KEY is required");
+
+	let res = client
+		.post("https://api.resend.com/emails")
+		.bearer_auth(api_key)
+		.json(&json!({
+			"from": std::env::var("B0_RESEND_FROM").unwrap_or_default(),
+			"to": [std::env::var("B0_RESEND_TO").unwrap_or_default()],
+			"subject": "Hello World",
+			"html": "<strong>It works!</strong>"
+		}))
+		.send()
+		.await?;
+
+	- Slack: POST https://slack.com/api/chat.postMessage with bearer B0_SLACK_KEY
+	- Stripe: POST https://api.stripe.com/v1/customers as a form with bearer B0_STRIPE_KEY
+	- Supabase: GET B0_SUPABASE_URL/rest/v1/{table} with the apikey header and bearer B0_SUPABASE_KEY
+	- Telegram: POST https://api.telegram.org/bot{B0_TELEGRAM_BOT_TOKEN}/sendMessage
+	- Discord: POST https://discord.com/api/v10/channels/{B0_DISCORD_CHANNEL_ID}/messages with the header Authorization: Bot B0_DISCORD_KEY
+	- Github: use the https://api.github.com REST API with bearer B0_GITHUB_TOKEN and a User-Agent header
+
+	- OpenAI, Gemini, DeepSeek
+	POST {base_url}/chat/completions with bearer B0_OPENAI_KEY, the base url is https://api.openai.com/v1, https://api.deepseek.com/v1 or https://generativelanguage.googleapis.com/v1beta/openai
+
+	- Anthropic
+	POST https://api.anthropic.com/v1/messages with the x-api-key header set to B0_ANTHROPIC_KEY and the anthropic-version header set to 2023-06-01
+
+	Make sure to add Cargo.toml with the package name b0-app and every dependency used
+	Make sure to use cargo fetch for installCommands and cargo build --release for buildCommands
+	Make sure to use ./target/release/b0-app for runCommands
+	Derive Serialize and Deserialize on every struct of a json body
+	`
+
+	javaInstructions = `
+	## Integration Instructions
+	Use the RestClient of Spring Framework 6 to call the REST API of every integration, read api keys with System.getenv.
+
+	e.g To send an email with Resend:
+	import org.springframework.http.MediaType;
+	import org.springframework.web.client.RestClient;
+	import java.util.List;
+	import java.util.Map;
+
+	RestClient client = RestClient.create();
+
+	Map<String, Object> body = Map.of(
+		"from", System.getenv("B0_RESEND_FROM"),
+		"to", List.of(System.getenv("B0_RESEND_TO")),
+		"subject", "Hello World",
+		"html", "<strong>It works!</strong>"
+	);
+
+	String response = client.post()
+		.uri("https://api.resend.com/emails")
+		.header("Authorization", "Bearer " + System.getenv("B0_RESEND_KEY"))
+		.contentType(MediaType.APPLICATION_JSON)
+		.body(body)
+		.retrieve()
+		.body(String.class);
+
+	- Slack: POST https://slack.com/api/chat.postMessage with bearer B0_SLACK_KEY
+	- Stripe
+	Use the stripe-java package:
+	dependency:
+	com.stripe:stripe-java:28.3.0
+
+	Stripe.apiKey = System.getenv("B0_STRIPE_KEY");
+	Customer customer = Customer.create(CustomerCreateParams.builder().setEmail("jenny.rosen@example.com").build());
+
+	- Supabase: GET B0_SUPABASE_URL/rest/v1/{table} with the apikey header and bearer B0_SUPABASE_KEY
+	- Telegram: POST https://api.telegram.org/bot{B0_TELEGRAM_BOT_TOKEN}/sendMessage
+	- Discord: POST https://discord.com/api/v10/channels/{B0_DISCORD_CHANNEL_ID}/messages with the header Authorization: Bot B0_DISCORD_KEY
+	- Github: use the https://api.github.com REST API with bearer B0_GITHUB_TOKEN
+
+	- OpenAI, Gemini, DeepSeek
+	POST {base_url}/chat/completions with bearer B0_OPENAI_KEY, the base url is https://api.openai.com/v1, https://api.deepseek.com/v1 or https://generativelanguage.googleapis.com/v1beta/openai
+
+	- Anthropic
+	POST https://api.anthropic.com/v1/messages with the x-api-key header set to B0_ANTHROPIC_KEY and the anthropic-version header set to 2023-06-01
+
+	Make sure to add pom.xml with the spring-boot-starter-parent, spring-boot-starter-web and every dependency used
+	Make sure the finalName of the build is app so the jar is target/app.jar
+	Make sure to use mvn -q -B dependency:go-offline for installCommands and mvn -q -B package -DskipTests for buildCommands
+	Make sure to use java -jar target/app.jar for runCommands
+	Use records for the request and response bodies
+	`
 )
 
 type ModeCatalog struct {
-	Name           string     `json:"name"`
-	Model          AgentModel `json:"model"`
-	IsEnabled      bool       `json:"is_enabled"`
-	IsExperimental bool       `json:"is_experimental"`
-	IsDefault      bool       `json:"is_default"`
-	IsPremium      bool       `json:"is_premium"`
+	Name           string       `json:"name"`
+	Model          AgentModel   `json:"model"`
+	Provider       ProviderName `json:"provider"`
+	ProviderModel  string       `json:"-"`
+	IsEnabled      bool         `json:"is_enabled"`
+	IsExperimental bool         `json:"is_experimental"`
+	IsDefault      bool         `json:"is_default"`
+	IsPremium      bool         `json:"is_premium"`
+}
+
+// ProviderModelID returns the model identifier expected by the provider api
+func (m ModeCatalog) ProviderModelID() string {
+	if m.ProviderModel != "" {
+		return m.ProviderModel
+	}
+
+	return string(m.Model)
 }
 
 type WorkflowCase struct {
@@ -446,6 +682,10 @@ type Workflow struct {
 type WorkflowGenerationOption struct {
 	Workflows []*Workflow `json:"workflows"`
 	Prompt    string      `json:"prompt"`
+	// History is the earlier conversation about the workflows, oldest first, only its most recent turns are sent
+	History []Message `json:"-"`
+	// Endpoints are the existing endpoints of the project, the workflows of new endpoints are generated next to them
+	Endpoints []ChatEndpoint `json:"-"`
 }
 
 type CodeGenerationOption struct {
@@ -455,6 +695,8 @@ type CodeGenerationOption struct {
 	FrameworkInsructions string      `json:"-"`
 	Workflows            interface{} `json:"-"`
 	Image                string      `json:"-"`
+	// Previous is the code generated from earlier workflows, only the files affected by the change are regenerated
+	Previous *CodeGeneration `json:"-"`
 }
 
 type CodeGenEnvVar struct {
@@ -468,6 +710,10 @@ type CodeGeneration struct {
 	BuildCommands   string          `json:"buildCommands"`
 	RunCommands     string          `json:"runCommands"`
 	EnvVars         []CodeGenEnvVar `json:"envVars"`
+	// Tests is the HTTP test suite run against the deployed code
+	Tests []TestCase `json:"tests,omitempty" jsonschema_description:"The HTTP test cases of the endpoints, one per response node and per branch of the if and switch nodes"`
+	// Workflows are the workflows the code was generated from, a change only regenerates the files it affects
+	Workflows []*Workflow `json:"workflows,omitempty" jsonschema:"-"`
 }
 
 type FileContent struct {
@@ -475,10 +721,26 @@ type FileContent struct {
 	Content  string `json:"content"`
 }
 
+// TestCase is a request to the deployed code and the response it expects, it's language neutral
+type TestCase struct {
+	Name           string       `json:"name" jsonschema_description:"What the case checks e.g returns 404 when the todo does not exist"`
+	Method         string       `json:"method"`
+	Path           string       `json:"path" jsonschema_description:"The request path with the path parameters and the query string filled in e.g /todos/1?done=true"`
+	Headers        []TestHeader `json:"headers"`
+	Body           string       `json:"body" jsonschema_description:"The JSON request body, empty when the request has no body"`
+	ExpectedStatus int          `json:"expectedStatus"`
+	ExpectedBody   string       `json:"expectedBody" jsonschema_description:"A JSON example of the response body, its shape is checked: the keys and the types of their values. Empty to skip the check"`
+}
+
+type TestHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 var AvailableCodeGenerationOptions = []CodeGenerationOption{
 	{
 		ID:        "1",
-		Language:  "Go",
+		Language:  LanguageGo,
 		Framework: "Chi",
 		Image:     "golang:1.23-alpine3.20",
 		FrameworkInsructions: `
@@ -491,7 +753,7 @@ var AvailableCodeGenerationOptions = []CodeGenerationOption{
 	},
 	{
 		ID:        "2",
-		Language:  "Go",
+		Language:  LanguageGo,
 		Framework: "Echo",
 		Image:     "golang:1.23-alpine3.20",
 		FrameworkInsructions: `
@@ -503,7 +765,7 @@ var AvailableCodeGenerationOptions = []CodeGenerationOption{
 	},
 	{
 		ID:        "3",
-		Language:  "Go",
+		Language:  LanguageGo,
 		Framework: "Gin",
 		Image:     "golang:1.23-alpine3.20",
 		FrameworkInsructions: `
@@ -515,7 +777,7 @@ var AvailableCodeGenerationOptions = []CodeGenerationOption{
 	},
 	{
 		ID:        "4",
-		Language:  "Node.js (TypeScript)",
+		Language:  LanguageNodeJS,
 		Framework: "Express",
 		Image:     "node:20-alpine3.20",
 		FrameworkInsructions: `
@@ -533,7 +795,7 @@ var AvailableCodeGenerationOptions = []CodeGenerationOption{
 	},
 	{
 		ID:        "5",
-		Language:  "Node.js (TypeScript)",
+		Language:  LanguageNodeJS,
 		Framework: "Fastify",
 		Image:     "node:20-alpine3.20",
 		FrameworkInsructions: `
@@ -548,7 +810,7 @@ var AvailableCodeGenerationOptions = []CodeGenerationOption{
 	},
 	{
 		ID:        "6",
-		Language:  "Node.js (TypeScript)",
+		Language:  LanguageNodeJS,
 		Framework: "Hono",
 		Image:     "node:20-alpine3.20",
 		FrameworkInsructions: `
@@ -701,6 +963,100 @@ var AvailableCodeGenerationOptions = []CodeGenerationOption{
 		})
 		` + nodeJSInstructions,
 	},
+	{
+		ID:        "7",
+		Language:  LanguagePython,
+		Framework: "FastAPI",
+		Image:     "python:3.12-slim",
+		FrameworkInsructions: `
+		## Framework instructions
+		- Use FastAPI framework with pydantic models for the request and response bodies
+		- Put the FastAPI app in main.py as app e.g app = FastAPI()
+		- Make sure to add requirements.txt and don't forget to include all neccessary dependencies
+		- Below are the basic dependencies you need to add to your requirements.txt file
+		1. fastapi==0.115.8
+		2. uvicorn[standard]==0.34.0
+		- Don't add a buildCommands, python isn't compiled
+		- Use uvicorn main:app --host 0.0.0.0 --port $B0_PORT for runCommands, the server port is the B0_PORT environment variable
+		- Use async route handlers
+		- For all api key, use this format: os.environ["B0_API_KEY"] e.g os.environ["B0_OPENAI_KEY"], os.environ["B0_SLACK_KEY"], etc
+
+		Example of simple FastAPI web api:
+
+		from fastapi import FastAPI, HTTPException
+		from pydantic import BaseModel
+
+		app = FastAPI()
+
+		class Todo(BaseModel):
+			title: str
+			done: bool = False
+
+		@app.post("/todos", status_code=201)
+		async def create_todo(todo: Todo):
+			if not todo.title:
+				raise HTTPException(status_code=400, detail="title is required")
+			return todo
+
+
+		` + pythonInstructions,
+	},
+	{
+		ID:        "8",
+		Language:  LanguageRust,
+		Framework: "Axum",
+		Image:     "rust:1.85-slim",
+		FrameworkInsructions: `
+		## Framework instructions
+		- Use Axum framework with tokio
+		- Below are the basic dependencies you need to add to your Cargo.toml file
+		1. axum = "0.8"
+		2. tokio = { version = "1", features = ["full"] }
+		- Read the server port from the B0_PORT environment variable and bind 0.0.0.0 e.g
+
+		let port = std::env::var("B0_PORT").unwrap_or_else(|_| "8080".to_string());
+		let listener = tokio::net::TcpListener::bind(format!("0.0.0.0:{}", port)).await.unwrap();
+		axum::serve(listener, app).await.unwrap();
+
+		- Use the axum Json extractor to bind request bodies and return (StatusCode, Json(value)) for responses
+		- Axum 0.8 path parameters use braces e.g .route("/todos/{id}", get(get_todo))
+		- For all api key, use this format: std::env::var("B0_API_KEY") e.g std::env::var("B0_OPENAI_KEY"), std::env::var("B0_SLACK_KEY"), etc
+
+
+		` + rustInstructions,
+	},
+	{
+		ID:        "9",
+		Language:  LanguageJava,
+		Framework: "Spring Boot",
+		Image:     "maven:3.9-eclipse-temurin-21",
+		FrameworkInsructions: `
+		## Framework instructions
+		- Use Spring Boot 3 with Java 21 and Maven
+		- Put the sources in src/main/java/dev/b0/app and the application class in Application.java
+		- Set server.port=${B0_PORT:8080} in src/main/resources/application.properties, the server port is the B0_PORT environment variable
+		- Use @RestController classes and ResponseEntity to set the response status
+		- For all api key, use this format: System.getenv("B0_API_KEY") e.g System.getenv("B0_OPENAI_KEY"), System.getenv("B0_SLACK_KEY"), etc
+
+		Example of simple Spring Boot web api:
+
+		@RestController
+		@RequestMapping("/todos")
+		public class TodoController {
+			record Todo(String title, boolean done) {}
+
+			@PostMapping
+			public ResponseEntity<?> create(@RequestBody Todo todo) {
+				if (todo.title() == null || todo.title().isBlank()) {
+					return ResponseEntity.badRequest().body(Map.of("error", "title is required"));
+				}
+				return ResponseEntity.status(HttpStatus.CREATED).body(todo);
+			}
+		}
+
+
+		` + javaInstructions,
+	},
 }
 
 func GetLanguageCodeGenerationByID(id string) (CodeGenerationOption, error) {
@@ -724,12 +1080,19 @@ func GetLanguageCodeGeneration(language string, framework string) (CodeGeneratio
 type AgentToken struct {
 	Input  string `json:"input"`
 	Output string `json:"output"`
+	Model  string `json:"model"`
+	// IsPremium is the tier of Model, the model that answered, which differs from the requested one after a fallback
+	IsPremium bool          `json:"is_premium"`
+	Usage     Usage         `json:"usage"`
+	Repairs   []*AgentToken `json:"repairs,omitempty"`
 }
 
 var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "GPT 3.5",
 		Model:          AgentModelGPT3Dot5,
+		Provider:       ProviderOpenAI,
+		ProviderModel:  "gpt-3.5-turbo",
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -738,6 +1101,7 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "GPT 4",
 		Model:          AgentModelGPT4,
+		Provider:       ProviderOpenAI,
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -746,6 +1110,8 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "Claude Sonnet 3.5",
 		Model:          AgentModelClaudeSonnet3Dot5,
+		Provider:       ProviderAnthropic,
+		ProviderModel:  "claude-3-5-sonnet-latest",
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -754,6 +1120,8 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "Claude Sonnet 3.7",
 		Model:          AgentModelClaudeSonnet3Dot7,
+		Provider:       ProviderAnthropic,
+		ProviderModel:  "claude-3-7-sonnet-latest",
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -762,6 +1130,7 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "DeepSeek R1",
 		Model:          AgentModelDeepSeekR1,
+		Provider:       ProviderDeepSeek,
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -770,6 +1139,7 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "Gemini 1.5 Flash",
 		Model:          AgentModelGeminiFlash1Dot5,
+		Provider:       ProviderGemini,
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -778,6 +1148,7 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "Gemini 2.0 Flash",
 		Model:          AgentModelGeminiFlash2Dot0,
+		Provider:       ProviderGemini,
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -786,6 +1157,7 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "Grok 2.0",
 		Model:          AgentModelGrok2Dot0,
+		Provider:       ProviderXAI,
 		IsEnabled:      false,
 		IsExperimental: false,
 		IsDefault:      false,
@@ -794,6 +1166,7 @@ var AvailableCatalogs = []ModeCatalog{
 	{
 		Name:           "Gemini 2.5 Pro Preview",
 		Model:          AgentModelGemini2Dot5ProPreview,
+		Provider:       ProviderGemini,
 		IsEnabled:      true,
 		IsExperimental: true,
 		IsDefault:      false,
@@ -801,6 +1174,53 @@ var AvailableCatalogs = []ModeCatalog{
 	},
 }
 
+// mergeCatalogs returns a copy of the catalog with the models of the custom providers,
+// a custom model replaces the entry of the same model if any
+func mergeCatalogs(catalogs []ModeCatalog, providers config.CustomProviders) []ModeCatalog {
+	merged := append([]ModeCatalog{}, catalogs...)
+
+	for _, provider := range providers {
+	next:
+		for _, catalog := range customCatalogs(provider) {
+			for i := range merged {
+				if merged[i].Model == catalog.Model {
+					merged[i] = catalog
+					continue next
+				}
+			}
+
+			merged = append(merged, catalog)
+		}
+	}
+
+	return merged
+}
+
+// customCatalogs returns the catalog entries of the models served by a custom provider.
+// Models are namespaced with the provider name, e.g. ollama/llama3.1
+func customCatalogs(provider config.CustomProvider) []ModeCatalog {
+	catalogs := []ModeCatalog{}
+
+	for _, model := range provider.Models {
+		name := model.Name
+
+		if name == "" {
+			name = model.Model
+		}
+
+		catalogs = append(catalogs, ModeCatalog{
+			Name:          name,
+			Model:         AgentModel(fmt.Sprintf("%s/%s", provider.Name, model.Model)),
+			Provider:      ProviderName(provider.Name),
+			ProviderModel: model.Model,
+			IsEnabled:     true,
+			IsPremium:     model.IsPremium,
+		})
+	}
+
+	return catalogs
+}
+
 func GetModel(model string) (AgentModel, error) {
 	for _, catalog := range AvailableCatalogs {
 		if catalog.Model == AgentModel(model) {
@@ -810,8 +1230,14 @@ func GetModel(model string) (AgentModel, error) {
 	return AgentModelNone, fmt.Errorf("model not found")
 }
 
+// GetModelCatalog returns the built-in catalog entry of the model, the models of the custom providers are only
+// known to the agent, see Agent.GetModelCatalog
 func GetModelCatalog(model string) (ModeCatalog, error) {
-	for _, catalog := range AvailableCatalogs {
+	return findCatalog(AvailableCatalogs, model)
+}
+
+func findCatalog(catalogs []ModeCatalog, model string) (ModeCatalog, error) {
+	for _, catalog := range catalogs {
 		if catalog.Model == AgentModel(model) {
 			return catalog, nil
 		}
@@ -820,12 +1246,21 @@ func GetModelCatalog(model string) (ModeCatalog, error) {
 }
 
 type Config struct {
-	Model        AgentModel
-	OpenAIKey    string
-	DeepSeekKey  string
-	AnthropicKey string
-	GeminiKey    string
-	XAIKey       string
+	Model          AgentModel
+	MaxTokens      int64
+	RepairAttempts int
+	OnDelta        DeltaFunc
+	AllowPremium   bool
+	OpenAIKey      string
+	DeepSeekKey    string
+	AnthropicKey   string
+	GeminiKey      string
+	XAIKey         string
+	// Routes holds the ordered models tried for each task after the selected model
+	Routes map[AgentTask][]AgentModel
+	// CustomProviders are OpenAI compatible endpoints registered next to the built-in providers
+	CustomProviders []config.CustomProvider
+	Replay          config.AgentReplay
 }
 
 type OptionFunc func(*Config)
@@ -833,12 +1268,44 @@ type OptionFunc func(*Config)
 // WithModel sets the model to be used by the agent
 func WithModel(model AgentModel) OptionFunc {
 	return func(cfg *Config) {
-		if model != AgentModelNone {
+		if model != AgentModelNone && model != "" {
 			cfg.Model = model
 		}
 	}
 }
 
+// WithMaxTokens sets the maximum number of tokens the model can generate
+func WithMaxTokens(maxTokens int64) OptionFunc {
+	return func(cfg *Config) {
+		if maxTokens > 0 {
+			cfg.MaxTokens = maxTokens
+		}
+	}
+}
+
+// WithDeltaHandler streams the completion and calls onDelta with every content delta
+func WithDeltaHandler(onDelta DeltaFunc) OptionFunc {
+	return func(cfg *Config) {
+		cfg.OnDelta = onDelta
+	}
+}
+
+// WithPremium allows premium models to be used, including as a fallback
+func WithPremium(allowed bool) OptionFunc {
+	return func(cfg *Config) {
+		cfg.AllowPremium = allowed
+	}
+}
+
+// WithRepairAttempts sets how many times the agent asks the model to fix an invalid response
+func WithRepairAttempts(attempts int) OptionFunc {
+	return func(cfg *Config) {
+		if attempts >= 0 {
+			cfg.RepairAttempts = attempts
+		}
+	}
+}
+
 func ToModel(model string) AgentModel {
 	switch model {
 	case string(AgentModelGPT3Dot5):
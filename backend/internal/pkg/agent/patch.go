@@ -0,0 +1,274 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type FilePatchAction string
+
+const (
+	FilePatchActionCreate  FilePatchAction = "create"
+	FilePatchActionReplace FilePatchAction = "replace"
+	FilePatchActionPatch   FilePatchAction = "patch"
+	FilePatchActionDelete  FilePatchAction = "delete"
+)
+
+// CodePatch is the change of the generated code after its workflows changed, files it doesn't list are kept as they are
+type CodePatch struct {
+	Files           []FilePatch     `json:"files" jsonschema_description:"The files to change, files not listed are kept as they are"`
+	InstallCommands []string        `json:"installCommands"`
+	BuildCommands   string          `json:"buildCommands"`
+	RunCommands     string          `json:"runCommands"`
+	EnvVars         []CodeGenEnvVar `json:"envVars"`
+	Tests           []TestCase      `json:"tests,omitempty" jsonschema_description:"The complete test suite when the workflow changes affect it"`
+}
+
+type FilePatch struct {
+	Filename string          `json:"filename"`
+	Action   FilePatchAction `json:"action" jsonschema:"enum=create,enum=replace,enum=patch,enum=delete"`
+	Content  string          `json:"content,omitempty" jsonschema_description:"The complete content of a created or replaced file"`
+	Patch    string          `json:"patch,omitempty" jsonschema_description:"The unified diff of a patched file"`
+}
+
+// ApplyPatch returns a copy of the code with the patch applied, the files the patch doesn't list are kept byte for byte
+func (c *CodeGeneration) ApplyPatch(patch *CodePatch) (*CodeGeneration, error) {
+	files := map[string]int{}
+
+	code := *c
+	code.FileContents = append([]FileContent{}, c.FileContents...)
+
+	for i, file := range code.FileContents {
+		files[file.Filename] = i
+	}
+
+	deleted := map[string]bool{}
+
+	for _, filePatch := range patch.Files {
+		i, exists := files[filePatch.Filename]
+
+		switch filePatch.Action {
+		case FilePatchActionCreate, FilePatchActionReplace:
+			if !exists {
+				files[filePatch.Filename] = len(code.FileContents)
+				code.FileContents = append(code.FileContents, FileContent{Filename: filePatch.Filename})
+				i = files[filePatch.Filename]
+			}
+
+			code.FileContents[i].Content = filePatch.Content
+			delete(deleted, filePatch.Filename)
+		case FilePatchActionPatch:
+			if !exists || deleted[filePatch.Filename] {
+				return nil, fmt.Errorf("can't patch %s, the file doesn't exist", filePatch.Filename)
+			}
+
+			content, err := ApplyUnifiedDiff(code.FileContents[i].Content, filePatch.Patch)
+
+			if err != nil {
+				return nil, fmt.Errorf("can't patch %s: %w", filePatch.Filename, err)
+			}
+
+			code.FileContents[i].Content = content
+		case FilePatchActionDelete:
+			if !exists {
+				return nil, fmt.Errorf("can't delete %s, the file doesn't exist", filePatch.Filename)
+			}
+
+			deleted[filePatch.Filename] = true
+		default:
+			return nil, fmt.Errorf("unknown action %q for %s", filePatch.Action, filePatch.Filename)
+		}
+	}
+
+	if len(deleted) > 0 {
+		kept := make([]FileContent, 0, len(code.FileContents))
+
+		for _, file := range code.FileContents {
+			if !deleted[file.Filename] {
+				kept = append(kept, file)
+			}
+		}
+
+		code.FileContents = kept
+	}
+
+	if len(patch.InstallCommands) > 0 {
+		code.InstallCommands = patch.InstallCommands
+	}
+
+	if strings.TrimSpace(patch.BuildCommands) != "" {
+		code.BuildCommands = patch.BuildCommands
+	}
+
+	if strings.TrimSpace(patch.RunCommands) != "" {
+		code.RunCommands = patch.RunCommands
+	}
+
+	if len(patch.EnvVars) > 0 {
+		code.EnvVars = patch.EnvVars
+	}
+
+	if len(patch.Tests) > 0 {
+		code.Tests = patch.Tests
+	}
+
+	return &code, nil
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+type hunk struct {
+	oldStart int
+	before   []string
+	after    []string
+	// context marks the lines of after copied from before, they keep the line of the file when matched loosely
+	context map[int]int
+}
+
+// ApplyUnifiedDiff applies the hunks of a unified diff to the content. Hunks are located by their
+// context lines so a line number that is off, as models often write them, still applies.
+func ApplyUnifiedDiff(content, diff string) (string, error) {
+	hunks, err := parseHunks(diff)
+
+	if err != nil {
+		return "", err
+	}
+
+	trailingNewline := strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	if content == "" {
+		lines = []string{}
+	}
+
+	cursor := 0
+
+	for n, h := range hunks {
+		at := findHunk(lines, h, cursor)
+
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d doesn't match the file, expected the lines:\n%s", n+1, strings.Join(h.before, "\n"))
+		}
+
+		patched := make([]string, 0, len(lines)-len(h.before)+len(h.after))
+		patched = append(patched, lines[:at]...)
+
+		for i, line := range h.after {
+			if j, ok := h.context[i]; ok {
+				line = lines[at+j]
+			}
+
+			patched = append(patched, line)
+		}
+
+		patched = append(patched, lines[at+len(h.before):]...)
+
+		lines = patched
+		cursor = at + len(h.after)
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	result := strings.Join(lines, "\n")
+
+	if trailingNewline || content == "" {
+		result += "\n"
+	}
+
+	return result, nil
+}
+
+func parseHunks(diff string) ([]hunk, error) {
+	hunks := []hunk{}
+
+	var current *hunk
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.Atoi(match[1])
+			hunks = append(hunks, hunk{oldStart: start, context: map[int]int{}})
+			current = &hunks[len(hunks)-1]
+			continue
+		}
+
+		if current == nil || strings.HasPrefix(line, `\`) {
+			// the file headers before the first hunk and "\ No newline at end of file"
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "-"):
+			current.before = append(current.before, line[1:])
+		case strings.HasPrefix(line, "+"):
+			current.after = append(current.after, line[1:])
+		case strings.HasPrefix(line, " "), line == "":
+			// a blank line is a context line whose leading space was trimmed
+			line = strings.TrimPrefix(line, " ")
+			current.context[len(current.after)] = len(current.before)
+			current.before = append(current.before, line)
+			current.after = append(current.after, line)
+		default:
+			return nil, fmt.Errorf("invalid line in hunk %d: %q", len(hunks), line)
+		}
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("the patch has no @@ hunk")
+	}
+
+	return hunks, nil
+}
+
+// findHunk returns the line the hunk applies at from the cursor, the closest match to the hunk line number wins
+func findHunk(lines []string, h hunk, cursor int) int {
+	if len(h.before) == 0 {
+		return min(max(h.oldStart, cursor), len(lines))
+	}
+
+	expected := h.oldStart - 1
+	best := -1
+
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		// models often get the trailing whitespace wrong
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		for at := cursor; at+len(h.before) <= len(lines); at++ {
+			if !matchesAt(lines, h.before, at, equal) {
+				continue
+			}
+
+			if best < 0 || abs(at-expected) < abs(best-expected) {
+				best = at
+			}
+		}
+
+		if best >= 0 {
+			return best
+		}
+	}
+
+	return best
+}
+
+func matchesAt(lines, expected []string, at int, equal func(a, b string) bool) bool {
+	for i, line := range expected {
+		if !equal(lines[at+i], line) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
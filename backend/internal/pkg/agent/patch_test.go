@@ -0,0 +1,279 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const mainGo = `package main
+
+import "net/http"
+
+func main() {
+	http.HandleFunc("/todos", listTodos)
+
+	http.ListenAndServe(":3000", nil)
+}
+`
+
+func Test_ApplyUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "add_lines",
+			content: mainGo,
+			patch: `--- a/main.go
++++ b/main.go
+@@ -5,5 +5,6 @@
+ func main() {
+ 	http.HandleFunc("/todos", listTodos)
++	http.HandleFunc("/todos/", getTodo)
+
+ 	http.ListenAndServe(":3000", nil)
+ }`,
+			want: `package main
+
+import "net/http"
+
+func main() {
+	http.HandleFunc("/todos", listTodos)
+	http.HandleFunc("/todos/", getTodo)
+
+	http.ListenAndServe(":3000", nil)
+}
+`,
+		},
+		{
+			name:    "line_numbers_off_and_blank_context_trimmed",
+			content: mainGo,
+			patch: `@@ -1,3 +1,3 @@
+ func main() {
+-	http.HandleFunc("/todos", listTodos)
++	http.HandleFunc("/tasks", listTodos)
+
+`,
+			want: `package main
+
+import "net/http"
+
+func main() {
+	http.HandleFunc("/tasks", listTodos)
+
+	http.ListenAndServe(":3000", nil)
+}
+`,
+		},
+		{
+			name:    "multiple_hunks",
+			content: "a\nb\nc\nd\ne\nf\n",
+			patch: `@@ -1,2 +1,2 @@
+-a
++A
+ b
+@@ -5,2 +5,3 @@
+ e
+ f
++g`,
+			want: "A\nb\nc\nd\ne\nf\ng\n",
+		},
+		{
+			name:    "trailing_whitespace_fallback",
+			content: "a  \nb\n",
+			patch: `@@ -1,2 +1,2 @@
+ a
+-b
++B`,
+			want: "a  \nB\n",
+		},
+		{
+			name:    "keeps_missing_trailing_newline",
+			content: "a\nb",
+			patch: `@@ -1,2 +1,2 @@
+ a
+-b
++B
+\ No newline at end of file`,
+			want: "a\nB",
+		},
+		{
+			name:    "context_does_not_match",
+			content: mainGo,
+			patch: `@@ -5,2 +5,2 @@
+ func serve() {
+-	http.ListenAndServe(":3000", nil)
++	http.ListenAndServe(":8080", nil)`,
+			wantErr: true,
+		},
+		{
+			name:    "no_hunk",
+			content: mainGo,
+			patch:   `package main`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyUnifiedDiff(tt.content, tt.patch)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_CodeGeneration_ApplyPatch(t *testing.T) {
+	code := &CodeGeneration{
+		FileContents: []FileContent{
+			{Filename: "main.go", Content: mainGo},
+			{Filename: "todo.go", Content: "package main\n\n// edited by hand\n"},
+			{Filename: "old.go", Content: "package main\n"},
+		},
+		InstallCommands: []string{"go mod tidy"},
+		RunCommands:     "./app",
+	}
+
+	patched, err := code.ApplyPatch(&CodePatch{
+		Files: []FilePatch{
+			{Filename: "main.go", Action: FilePatchActionPatch, Patch: "@@ -6,1 +6,1 @@\n-\thttp.HandleFunc(\"/todos\", listTodos)\n+\thttp.HandleFunc(\"/tasks\", listTodos)"},
+			{Filename: "old.go", Action: FilePatchActionDelete},
+			{Filename: "task.go", Action: FilePatchActionCreate, Content: "package main\n"},
+		},
+		BuildCommands: "go build -o app .",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []FileContent{
+		{Filename: "main.go", Content: `package main
+
+import "net/http"
+
+func main() {
+	http.HandleFunc("/tasks", listTodos)
+
+	http.ListenAndServe(":3000", nil)
+}
+`},
+		{Filename: "todo.go", Content: "package main\n\n// edited by hand\n"},
+		{Filename: "task.go", Content: "package main\n"},
+	}, patched.FileContents)
+	require.Equal(t, []string{"go mod tidy"}, patched.InstallCommands)
+	require.Equal(t, "go build -o app .", patched.BuildCommands)
+	require.Equal(t, "./app", patched.RunCommands)
+	// the patch is applied to a copy
+	require.Equal(t, mainGo, code.FileContents[0].Content)
+	require.Len(t, code.FileContents, 3)
+
+	_, err = code.ApplyPatch(&CodePatch{Files: []FilePatch{{Filename: "missing.go", Action: FilePatchActionPatch, Patch: "@@ -1 +1 @@\n-a\n+b"}}})
+	require.Error(t, err)
+}
+
+func Test_Agent_CodeGeneration_Incremental(t *testing.T) {
+	before := []*Workflow{
+		{Type: WorkflowTypeRequest, ActionID: "request", Method: "GET", Url: "/todos"},
+		{Type: WorkflowTypeResponse, ActionID: "response", Status: "200"},
+	}
+
+	after := []*Workflow{
+		{Type: WorkflowTypeRequest, ActionID: "request", Method: "GET", Url: "/tasks"},
+		{Type: WorkflowTypeResponse, ActionID: "response", Status: "200"},
+	}
+
+	previous := &CodeGeneration{
+		FileContents: []FileContent{
+			{Filename: "main.go", Content: mainGo},
+			{Filename: "todo.go", Content: "package main\n\n// edited by hand\n"},
+		},
+		RunCommands: "./app",
+		Workflows:   before,
+	}
+
+	patch := `{"files": [{"filename": "main.go", "action": "patch", "patch": "@@ -6,1 +6,1 @@\n-\thttp.HandleFunc(\"/todos\", listTodos)\n+\thttp.HandleFunc(\"/tasks\", listTodos)"}], "installCommands": [], "buildCommands": "", "runCommands": "", "envVars": []}`
+
+	tests := []struct {
+		name         string
+		workflows    []*Workflow
+		responses    []string
+		wantRequests int
+		wantMain     string
+	}{
+		{
+			name:         "unchanged_workflows_keep_the_code",
+			workflows:    before,
+			wantRequests: 0,
+			wantMain:     mainGo,
+		},
+		{
+			name:         "changed_workflows_patch_the_affected_files",
+			workflows:    after,
+			responses:    []string{patch},
+			wantRequests: 1,
+			wantMain: `package main
+
+import "net/http"
+
+func main() {
+	http.HandleFunc("/tasks", listTodos)
+
+	http.ListenAndServe(":3000", nil)
+}
+`,
+		},
+		{
+			name:      "patch_that_does_not_apply_is_repaired",
+			workflows: after,
+			responses: []string{
+				`{"files": [{"filename": "main.go", "action": "patch", "patch": "@@ -1 +1 @@\n-func serve() {\n+func main() {"}], "installCommands": [], "buildCommands": "", "runCommands": "", "envVars": []}`,
+				patch,
+			},
+			wantRequests: 2,
+			wantMain: `package main
+
+import "net/http"
+
+func main() {
+	http.HandleFunc("/tasks", listTodos)
+
+	http.ListenAndServe(":3000", nil)
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{responses: tt.responses}
+
+			a := &Agent{
+				cfg:       &Config{Model: AgentModelGPT4, RepairAttempts: 1},
+				providers: map[ProviderName]Provider{ProviderOpenAI: provider},
+			}
+
+			code, _, err := a.CodeGeneration(context.Background(), "a todo api", CodeGenerationOption{
+				Language:  LanguageGo,
+				Workflows: tt.workflows,
+				Previous:  previous,
+			})
+			require.NoError(t, err)
+
+			require.Len(t, provider.requests, tt.wantRequests)
+			require.Equal(t, tt.wantMain, code.FileContents[0].Content)
+			// the files the change doesn't affect are kept byte for byte
+			require.Equal(t, previous.FileContents[1], code.FileContents[1])
+			require.Equal(t, "./app", code.RunCommands)
+			require.Equal(t, tt.workflows, code.Workflows)
+		})
+	}
+}
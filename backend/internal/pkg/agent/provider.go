@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+)
+
+type ProviderName string
+type MessageRole string
+
+// DeltaFunc receives every content delta while a completion is streamed
+type DeltaFunc func(delta string)
+
+const (
+	ProviderOpenAI    ProviderName = "openai"
+	ProviderDeepSeek  ProviderName = "deepseek"
+	ProviderAnthropic ProviderName = "anthropic"
+	ProviderGemini    ProviderName = "gemini"
+	ProviderXAI       ProviderName = "xai"
+
+	MessageRoleUser      MessageRole = "user"
+	MessageRoleAssistant MessageRole = "assistant"
+
+	defaultMaxTokens int64 = 8192
+)
+
+// Provider is implemented by every LLM backend the agent can talk to.
+type Provider interface {
+	Name() ProviderName
+	// SupportsStructuredOutput reports whether the backend enforces a JSON schema response format
+	SupportsStructuredOutput() bool
+	Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error)
+	// Stream behaves like Complete but calls onDelta with every content delta as it arrives
+	Stream(ctx context.Context, req CompletionRequest, onDelta DeltaFunc) (*CompletionResponse, error)
+}
+
+type Message struct {
+	Role    MessageRole `json:"role"`
+	Content string      `json:"content"`
+}
+
+type CompletionRequest struct {
+	Model          string          `json:"model"`
+	System         string          `json:"system"`
+	Messages       []Message       `json:"messages"`
+	MaxTokens      int64           `json:"max_tokens"`
+	ResponseFormat *ResponseSchema `json:"-"`
+}
+
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	CachedTokens     int64 `json:"cached_tokens"`
+}
+
+type CompletionResponse struct {
+	Content string `json:"content"`
+	Model   string `json:"model"`
+	Usage   Usage  `json:"usage"`
+}
+
+// structuredOutputProviders lists the built-in providers enforcing the json_schema response format
+var structuredOutputProviders = map[ProviderName]bool{
+	ProviderOpenAI: true,
+	ProviderGemini: true,
+	ProviderXAI:    true,
+}
+
+// newProviders builds a provider for every backend that has an api key configured and for every custom provider.
+func newProviders(cfg *Config, catalogs []ModeCatalog) map[ProviderName]Provider {
+	providers := map[ProviderName]Provider{}
+
+	if cfg.OpenAIKey != "" {
+		providers[ProviderOpenAI] = newOpenAIProvider(ProviderOpenAI, openaiBaseUrl, cfg.OpenAIKey, structuredOutputProviders[ProviderOpenAI])
+	}
+
+	if cfg.DeepSeekKey != "" {
+		providers[ProviderDeepSeek] = newOpenAIProvider(ProviderDeepSeek, deepSeekBaseUrl, cfg.DeepSeekKey, structuredOutputProviders[ProviderDeepSeek])
+	}
+
+	if cfg.GeminiKey != "" {
+		providers[ProviderGemini] = newOpenAIProvider(ProviderGemini, geminiBaseUrl, cfg.GeminiKey, structuredOutputProviders[ProviderGemini])
+	}
+
+	if cfg.XAIKey != "" {
+		providers[ProviderXAI] = newOpenAIProvider(ProviderXAI, xAIbaseUrl, cfg.XAIKey, structuredOutputProviders[ProviderXAI])
+	}
+
+	if cfg.AnthropicKey != "" {
+		providers[ProviderAnthropic] = newAnthropicProvider(anthropicBaseUrl, cfg.AnthropicKey)
+	}
+
+	// a custom provider named after a built-in one replaces it, e.g. to point the agent at a stub server
+	for _, custom := range cfg.CustomProviders {
+		providers[ProviderName(custom.Name)] = newOpenAIProvider(ProviderName(custom.Name), custom.BaseURL, custom.Key, custom.StructuredOutput)
+	}
+
+	return withReplay(providers, cfg, catalogs)
+}
+
+// provider returns the provider declared by the catalog entry of the given model
+func (a *Agent) provider(model AgentModel) (Provider, ModeCatalog, error) {
+	catalog, err := a.GetModelCatalog(string(model))
+
+	if err != nil {
+		return nil, ModeCatalog{}, err
+	}
+
+	provider, ok := a.providers[catalog.Provider]
+
+	if !ok {
+		return nil, catalog, fmt.Errorf("provider %s is not configured for model %s", catalog.Provider, model)
+	}
+
+	return provider, catalog, nil
+}
+
+// complete sends the completion request to the provider of the configured model.
+// When the provider can't enforce the response schema, the schema is described in the system prompt instead
+// and callers are expected to validate the output with decodeStructured.
+func (a *Agent) complete(ctx context.Context, cfg Config, schema *ResponseSchema, system string, messages ...Message) (*CompletionResponse, error) {
+	provider, catalog, err := a.provider(cfg.Model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens := cfg.MaxTokens
+
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	req := CompletionRequest{
+		Model:     catalog.ProviderModelID(),
+		System:    system,
+		Messages:  messages,
+		MaxTokens: maxTokens,
+	}
+
+	if schema != nil {
+		if provider.SupportsStructuredOutput() {
+			req.ResponseFormat = schema
+		} else {
+			req.System = fmt.Sprintf(b0ResponseSchemaInstruction, system, schema.String())
+		}
+	}
+
+	if cfg.OnDelta != nil {
+		return provider.Stream(ctx, req, cfg.OnDelta)
+	}
+
+	return provider.Complete(ctx, req)
+}
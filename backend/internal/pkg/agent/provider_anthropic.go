@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicBaseUrl    = "https://api.anthropic.com/v1"
+	anthropicAPIVersion = "2023-06-01"
+)
+
+// anthropicProvider is a native client for the Anthropic Messages API.
+type anthropicProvider struct {
+	baseUrl    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type anthropicMessageRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int64     `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+type anthropicMessageResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+// anthropicStreamEvent covers the fields used from every server-sent event of a streamed message
+type anthropicStreamEvent struct {
+	Type    string                   `json:"type"`
+	Message anthropicMessageResponse `json:"message"`
+	Delta   struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicErrorResponse struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newAnthropicProvider(baseUrl, apiKey string) *anthropicProvider {
+	return &anthropicProvider{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+	}
+}
+
+// Name implements Provider.
+func (a *anthropicProvider) Name() ProviderName {
+	return ProviderAnthropic
+}
+
+// SupportsStructuredOutput implements Provider.
+func (a *anthropicProvider) SupportsStructuredOutput() bool {
+	return false
+}
+
+// Complete implements Provider.
+func (a *anthropicProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	res, err := a.send(ctx, req, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var message anthropicMessageResponse
+
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return nil, fmt.Errorf("anthropic: failed to decode response: %w", err)
+	}
+
+	var content strings.Builder
+
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	return &CompletionResponse{
+		Content: content.String(),
+		Model:   message.Model,
+		Usage:   message.Usage.toUsage(),
+	}, nil
+}
+
+// Stream implements Provider.
+func (a *anthropicProvider) Stream(ctx context.Context, req CompletionRequest, onDelta DeltaFunc) (*CompletionResponse, error) {
+	res, err := a.send(ctx, req, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	var (
+		content strings.Builder
+		model   string
+		usage   anthropicUsage
+	)
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+
+		if !ok {
+			continue
+		}
+
+		var event anthropicStreamEvent
+
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return nil, fmt.Errorf("anthropic: failed to decode stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			model = event.Message.Model
+			usage = event.Message.Usage
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				content.WriteString(event.Delta.Text)
+				onDelta(event.Delta.Text)
+			}
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "error":
+			return nil, fmt.Errorf("anthropic: %s: %s", event.Error.Type, event.Error.Message)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &CompletionResponse{
+		Content: content.String(),
+		Model:   model,
+		Usage:   usage.toUsage(),
+	}, nil
+}
+
+// send posts the message request and returns the response when the api accepted it
+func (a *anthropicProvider) send(ctx context.Context, req CompletionRequest, stream bool) (*http.Response, error) {
+	body, err := json.Marshal(anthropicMessageRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		System:    req.System,
+		Messages:  req.Messages,
+		Stream:    stream,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseUrl+"/messages", bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", a.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	res, err := a.httpClient.Do(httpReq)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+
+		raw, _ := io.ReadAll(res.Body)
+
+		var errRes anthropicErrorResponse
+		if err := json.Unmarshal(raw, &errRes); err == nil && errRes.Error.Message != "" {
+			return nil, fmt.Errorf("anthropic: %s (%d): %s", errRes.Error.Type, res.StatusCode, errRes.Error.Message)
+		}
+
+		return nil, fmt.Errorf("anthropic: unexpected status code %d", res.StatusCode)
+	}
+
+	return res, nil
+}
+
+func (u anthropicUsage) toUsage() Usage {
+	return Usage{
+		PromptTokens:     u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens,
+		CompletionTokens: u.OutputTokens,
+		CachedTokens:     u.CacheReadInputTokens,
+	}
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AnthropicProvider_Complete(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		response   string
+		wantErr    bool
+		validate   func(*testing.T, *CompletionResponse)
+	}{
+		{
+			name:       "text_response_with_usage",
+			statusCode: http.StatusOK,
+			response: `{
+				"id": "msg_1",
+				"model": "claude-3-7-sonnet-latest",
+				"content": [{"type": "text", "text": "{\"title\":"}, {"type": "text", "text": "\"b0\"}"}],
+				"stop_reason": "end_turn",
+				"usage": {"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 3}
+			}`,
+			validate: func(t *testing.T, res *CompletionResponse) {
+				require.Equal(t, `{"title":"b0"}`, res.Content)
+				require.Equal(t, "claude-3-7-sonnet-latest", res.Model)
+				require.Equal(t, Usage{PromptTokens: 13, CompletionTokens: 5, CachedTokens: 3}, res.Usage)
+			},
+		},
+		{
+			name:       "api_error",
+			statusCode: http.StatusTooManyRequests,
+			response:   `{"type": "error", "error": {"type": "rate_limit_error", "message": "slow down"}}`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/messages", r.URL.Path)
+				require.Equal(t, "test-key", r.Header.Get("x-api-key"))
+				require.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+
+				var body anthropicMessageRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.Equal(t, "be brief", body.System)
+				require.Equal(t, int64(128), body.MaxTokens)
+				require.Len(t, body.Messages, 1)
+
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			provider := newAnthropicProvider(server.URL, "test-key")
+
+			res, err := provider.Complete(context.Background(), CompletionRequest{
+				Model:     "claude-3-7-sonnet-latest",
+				System:    "be brief",
+				Messages:  []Message{{Role: MessageRoleUser, Content: "hello"}},
+				MaxTokens: 128,
+			})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.validate(t, res)
+		})
+	}
+}
+
+func Test_AnthropicProvider_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicMessageRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`event: message_start
+data: {"type": "message_start", "message": {"model": "claude-3-7-sonnet-latest", "usage": {"input_tokens": 10, "cache_read_input_tokens": 3}}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "{\"title\":"}}
+
+event: ping
+data: {"type": "ping"}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "\"b0\"}"}}
+
+event: message_delta
+data: {"type": "message_delta", "delta": {"stop_reason": "end_turn"}, "usage": {"output_tokens": 5}}
+
+event: message_stop
+data: {"type": "message_stop"}
+
+`))
+	}))
+	defer server.Close()
+
+	provider := newAnthropicProvider(server.URL, "test-key")
+
+	deltas := []string{}
+
+	res, err := provider.Stream(context.Background(), CompletionRequest{
+		Model:     "claude-3-7-sonnet-latest",
+		Messages:  []Message{{Role: MessageRoleUser, Content: "hello"}},
+		MaxTokens: 128,
+	}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"title":`, `"b0"}`}, deltas)
+	require.Equal(t, `{"title":"b0"}`, res.Content)
+	require.Equal(t, "claude-3-7-sonnet-latest", res.Model)
+	require.Equal(t, Usage{PromptTokens: 13, CompletionTokens: 5, CachedTokens: 3}, res.Usage)
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+const (
+	deepSeekBaseUrl = "https://api.deepseek.com/v1"
+	openaiBaseUrl   = "https://api.openai.com/v1"
+	geminiBaseUrl   = "https://generativelanguage.googleapis.com/v1beta/openai/"
+	xAIbaseUrl      = "https://api.x.ai/v1"
+)
+
+// openAIProvider talks to any backend exposing the OpenAI chat completions api.
+type openAIProvider struct {
+	name             ProviderName
+	client           openai.Client
+	structuredOutput bool
+}
+
+func newOpenAIProvider(name ProviderName, baseUrl, apiKey string, structuredOutput bool, opts ...option.RequestOption) *openAIProvider {
+	opts = append(opts, option.WithBaseURL(baseUrl), option.WithAPIKey(apiKey))
+
+	return &openAIProvider{
+		name:             name,
+		client:           openai.NewClient(opts...),
+		structuredOutput: structuredOutput,
+	}
+}
+
+// Name implements Provider.
+func (o *openAIProvider) Name() ProviderName {
+	return o.name
+}
+
+// SupportsStructuredOutput implements Provider.
+func (o *openAIProvider) SupportsStructuredOutput() bool {
+	return o.structuredOutput
+}
+
+// Complete implements Provider.
+func (o *openAIProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	chat, err := o.client.Chat.Completions.New(ctx, o.params(req))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return o.response(chat)
+}
+
+// Stream implements Provider.
+func (o *openAIProvider) Stream(ctx context.Context, req CompletionRequest, onDelta DeltaFunc) (*CompletionResponse, error) {
+	params := o.params(req)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.Bool(true),
+	}
+
+	stream := o.client.Chat.Completions.NewStreaming(ctx, params)
+
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+	cachedTokens := int64(0)
+
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+
+		cachedTokens += chunk.Usage.PromptTokensDetails.CachedTokens
+
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	acc.Usage.PromptTokensDetails.CachedTokens = cachedTokens
+
+	return o.response(&acc.ChatCompletion)
+}
+
+func (o *openAIProvider) params(req CompletionRequest) openai.ChatCompletionNewParams {
+	messages := []openai.ChatCompletionMessageParamUnion{}
+
+	if req.System != "" {
+		messages = append(messages, openai.SystemMessage(req.System))
+	}
+
+	for _, message := range req.Messages {
+		switch message.Role {
+		case MessageRoleAssistant:
+			messages = append(messages, openai.AssistantMessage(message.Content))
+		default:
+			messages = append(messages, openai.UserMessage(message.Content))
+		}
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Messages: messages,
+		Model:    openai.ChatModel(req.Model),
+	}
+
+	if req.MaxTokens > 0 {
+		params.MaxTokens = openai.Int(req.MaxTokens)
+	}
+
+	if req.ResponseFormat != nil {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+				JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:        req.ResponseFormat.Name,
+					Description: openai.String(req.ResponseFormat.Description),
+					Schema:      req.ResponseFormat.Schema,
+					Strict:      openai.Bool(req.ResponseFormat.Strict),
+				},
+			},
+		}
+	}
+
+	return params
+}
+
+func (o *openAIProvider) response(chat *openai.ChatCompletion) (*CompletionResponse, error) {
+	if len(chat.Choices) == 0 {
+		return nil, fmt.Errorf("%s returned no choices", o.name)
+	}
+
+	if chat.Choices[0].Message.Refusal != "" {
+		return nil, fmt.Errorf("%s refused the request: %s", o.name, chat.Choices[0].Message.Refusal)
+	}
+
+	return &CompletionResponse{
+		Content: chat.Choices[0].Message.Content,
+		Model:   chat.Model,
+		Usage: Usage{
+			PromptTokens:     chat.Usage.PromptTokens,
+			CompletionTokens: chat.Usage.CompletionTokens,
+			CachedTokens:     chat.Usage.PromptTokensDetails.CachedTokens,
+		},
+	}, nil
+}
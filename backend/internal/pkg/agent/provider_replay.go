@@ -0,0 +1,192 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mujhtech/b0/config"
+)
+
+// replayFixture is the file saved for every recorded completion, the request is kept to ease reviewing fixtures
+type replayFixture struct {
+	Request  CompletionRequest  `json:"request"`
+	Response CompletionResponse `json:"response"`
+}
+
+// replayProvider answers completions from fixture files keyed by a hash of the request.
+// When it wraps an upstream provider, it records the upstream completions instead.
+type replayProvider struct {
+	name             ProviderName
+	dir              string
+	structuredOutput bool
+	upstream         Provider
+}
+
+// NewReplayProvider returns a provider answering from the completions recorded in dir.
+// structuredOutput must match the recorded provider, it changes the system prompt and so the request hash.
+func NewReplayProvider(name ProviderName, dir string, structuredOutput bool) Provider {
+	return &replayProvider{
+		name:             name,
+		dir:              dir,
+		structuredOutput: structuredOutput,
+	}
+}
+
+// NewRecordProvider returns a provider forwarding completions to upstream and recording them in dir
+func NewRecordProvider(upstream Provider, dir string) Provider {
+	return &replayProvider{
+		name:             upstream.Name(),
+		dir:              dir,
+		structuredOutput: upstream.SupportsStructuredOutput(),
+		upstream:         upstream,
+	}
+}
+
+// Name implements Provider.
+func (r *replayProvider) Name() ProviderName {
+	return r.name
+}
+
+// SupportsStructuredOutput implements Provider.
+func (r *replayProvider) SupportsStructuredOutput() bool {
+	return r.structuredOutput
+}
+
+// Complete implements Provider.
+func (r *replayProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if r.upstream == nil {
+		return r.replay(req)
+	}
+
+	res, err := r.upstream.Complete(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, r.record(req, res)
+}
+
+// Stream implements Provider. A replayed completion is sent as a single delta.
+func (r *replayProvider) Stream(ctx context.Context, req CompletionRequest, onDelta DeltaFunc) (*CompletionResponse, error) {
+	if r.upstream == nil {
+		res, err := r.replay(req)
+
+		if err != nil {
+			return nil, err
+		}
+
+		onDelta(res.Content)
+
+		return res, nil
+	}
+
+	res, err := r.upstream.Stream(ctx, req, onDelta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, r.record(req, res)
+}
+
+func (r *replayProvider) replay(req CompletionRequest) (*CompletionResponse, error) {
+	path, err := r.fixturePath(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no completion recorded for %s request to %s: %s", r.name, req.Model, path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture replayFixture
+
+	if err := json.Unmarshal(content, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+
+	return &fixture.Response, nil
+}
+
+func (r *replayProvider) record(req CompletionRequest, res *CompletionResponse) error {
+	path, err := r.fixturePath(req)
+
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(replayFixture{Request: req, Response: *res}, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(r.dir, os.ModePerm); err != nil { // #nosec G301
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644) // #nosec G306
+}
+
+// fixturePath returns the fixture file of the request, named after the provider and the request hash
+func (r *replayProvider) fixturePath(req CompletionRequest) (string, error) {
+	schemaName := ""
+
+	if req.ResponseFormat != nil {
+		schemaName = req.ResponseFormat.Name
+	}
+
+	key, err := json.Marshal(struct {
+		Request CompletionRequest `json:"request"`
+		Schema  string            `json:"schema"`
+	}{req, schemaName})
+
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(key)
+
+	return filepath.Join(r.dir, fmt.Sprintf("%s-%s.json", r.name, hex.EncodeToString(hash[:8]))), nil
+}
+
+// withReplay wraps the providers for the configured replay mode.
+// In replay mode every provider of the catalog answers from the fixtures, configured or not.
+func withReplay(providers map[ProviderName]Provider, cfg *Config, catalogs []ModeCatalog) map[ProviderName]Provider {
+	switch cfg.Replay.Mode {
+	case config.AgentReplayModeRecord:
+		for name, provider := range providers {
+			providers[name] = NewRecordProvider(provider, cfg.Replay.Dir)
+		}
+	case config.AgentReplayModeReplay:
+		structuredOutput := map[ProviderName]bool{}
+
+		for name, supported := range structuredOutputProviders {
+			structuredOutput[name] = supported
+		}
+
+		for _, custom := range cfg.CustomProviders {
+			structuredOutput[ProviderName(custom.Name)] = custom.StructuredOutput
+		}
+
+		for _, catalog := range catalogs {
+			providers[catalog.Provider] = NewReplayProvider(catalog.Provider, cfg.Replay.Dir, structuredOutput[catalog.Provider])
+		}
+	}
+
+	return providers
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReplayProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	req := CompletionRequest{
+		Model:          "gpt-4",
+		System:         "be brief",
+		Messages:       []Message{{Role: MessageRoleUser, Content: "hello"}},
+		MaxTokens:      128,
+		ResponseFormat: &ProjectTitleAndSlugResponseSchema,
+	}
+
+	upstream := &scriptedProvider{responses: []string{`{"title": "b0"}`}}
+	recorder := NewRecordProvider(upstream, dir)
+
+	recorded, err := recorder.Complete(context.Background(), req)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	replayer := NewReplayProvider(ProviderOpenAI, dir, true)
+
+	tests := []struct {
+		name    string
+		req     CompletionRequest
+		wantErr bool
+	}{
+		{
+			name: "same_request_is_replayed",
+			req:  req,
+		},
+		{
+			name: "different_request_is_not_recorded",
+			req: CompletionRequest{
+				Model:     "gpt-4",
+				System:    "be brief",
+				Messages:  []Message{{Role: MessageRoleUser, Content: "hello again"}},
+				MaxTokens: 128,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deltas []string
+
+			res, err := replayer.Stream(context.Background(), tt.req, func(delta string) {
+				deltas = append(deltas, delta)
+			})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Empty(t, deltas)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, recorded, res)
+			require.Equal(t, []string{recorded.Content}, deltas)
+		})
+	}
+
+	// replaying never reaches the upstream provider
+	require.Len(t, upstream.requests, 1)
+}
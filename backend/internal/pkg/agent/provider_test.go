@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mujhtech/b0/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Agent_CustomProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/chat/completions", r.URL.Path)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "llama3.1", body["model"])
+		// the endpoint doesn't enforce the schema, it is described in the system prompt instead
+		require.Nil(t, body["response_format"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"created": 1740394800,
+			"model": "llama3.1",
+			"choices": [{
+				"index": 0,
+				"finish_reason": "stop",
+				"message": {"role": "assistant", "content": "{\"title\": \"Todo API\", \"slug\": \"todo-api-abcdef\", \"description\": \"A todo api\"}"}
+			}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 8, "total_tokens": 20}
+		}`))
+	}))
+	defer server.Close()
+
+	a := New(&config.Config{
+		Agent: config.Agent{
+			CustomProviders: config.CustomProviders{
+				{
+					Name:    "ollama",
+					BaseURL: server.URL,
+					Models:  []config.CustomProviderModel{{Name: "Llama 3.1", Model: "llama3.1"}},
+				},
+			},
+		},
+	})
+
+	catalog, err := a.GetModelCatalog("ollama/llama3.1")
+	require.NoError(t, err)
+	require.Equal(t, ModeCatalog{
+		Name:          "Llama 3.1",
+		Model:         "ollama/llama3.1",
+		Provider:      "ollama",
+		ProviderModel: "llama3.1",
+		IsEnabled:     true,
+	}, catalog)
+
+	// the custom models are only known to the agent
+	_, err = GetModelCatalog("ollama/llama3.1")
+	require.Error(t, err)
+
+	project, agentToken, err := a.GenerateTitleAndSlug(context.Background(), "build me a todo api", WithModel(catalog.Model))
+	require.NoError(t, err)
+	require.Equal(t, "todo-api-abcdef", project.Slug)
+	require.Equal(t, "ollama/llama3.1", agentToken.Model)
+	require.Equal(t, Usage{PromptTokens: 12, CompletionTokens: 8}, agentToken.Usage)
+}
@@ -0,0 +1,290 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type DiagramFormat string
+
+const (
+	DiagramFormatMermaid DiagramFormat = "mermaid"
+	DiagramFormatDOT     DiagramFormat = "dot"
+
+	// maxDiagramLabel keeps long instructions and conditions readable in the diagram
+	maxDiagramLabel = 60
+)
+
+type diagramShape int
+
+const (
+	diagramShapeBox diagramShape = iota
+	diagramShapeTerminal
+	diagramShapeDecision
+	diagramShapeLoop
+)
+
+type diagramNode struct {
+	id    string
+	label string
+	shape diagramShape
+}
+
+type diagramEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+// diagramExit is an edge waiting for the node that follows
+type diagramExit struct {
+	from  string
+	label string
+}
+
+// diagram is the flowchart of workflows, branches of if and switch nodes join the node after them
+// and loop bodies go back to their loop node
+type diagram struct {
+	nodes []diagramNode
+	edges []diagramEdge
+}
+
+// RenderDiagram renders the workflows as a flowchart in the format
+func RenderDiagram(workflows []*Workflow, format DiagramFormat) (string, error) {
+	switch format {
+	case DiagramFormatMermaid:
+		return RenderMermaid(workflows), nil
+	case DiagramFormatDOT:
+		return RenderDOT(workflows), nil
+	default:
+		return "", fmt.Errorf("unsupported diagram format %q, expected %s or %s", format, DiagramFormatMermaid, DiagramFormatDOT)
+	}
+}
+
+// RenderMermaid renders the workflows as a Mermaid flowchart
+func RenderMermaid(workflows []*Workflow) string {
+	d := newDiagram(workflows)
+
+	var b strings.Builder
+
+	b.WriteString("flowchart TD\n")
+
+	for _, node := range d.nodes {
+		label := mermaidText(node.label)
+
+		switch node.shape {
+		case diagramShapeTerminal:
+			fmt.Fprintf(&b, "    %s([\"%s\"])\n", node.id, label)
+		case diagramShapeDecision:
+			fmt.Fprintf(&b, "    %s{\"%s\"}\n", node.id, label)
+		case diagramShapeLoop:
+			fmt.Fprintf(&b, "    %s{{\"%s\"}}\n", node.id, label)
+		default:
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", node.id, label)
+		}
+	}
+
+	for _, edge := range d.edges {
+		if edge.label == "" {
+			fmt.Fprintf(&b, "    %s --> %s\n", edge.from, edge.to)
+			continue
+		}
+
+		fmt.Fprintf(&b, "    %s -->|\"%s\"| %s\n", edge.from, mermaidText(edge.label), edge.to)
+	}
+
+	return b.String()
+}
+
+// RenderDOT renders the workflows as a Graphviz DOT digraph
+func RenderDOT(workflows []*Workflow) string {
+	d := newDiagram(workflows)
+
+	var b strings.Builder
+
+	b.WriteString("digraph workflow {\n")
+	b.WriteString("    rankdir=TB;\n")
+	b.WriteString("    node [fontname=\"Helvetica\"];\n")
+
+	for _, node := range d.nodes {
+		shape := "box"
+
+		switch node.shape {
+		case diagramShapeTerminal:
+			shape = "oval"
+		case diagramShapeDecision:
+			shape = "diamond"
+		case diagramShapeLoop:
+			shape = "hexagon"
+		}
+
+		fmt.Fprintf(&b, "    %s [label=%s, shape=%s];\n", node.id, dotText(node.label), shape)
+	}
+
+	for _, edge := range d.edges {
+		if edge.label == "" {
+			fmt.Fprintf(&b, "    %s -> %s;\n", edge.from, edge.to)
+			continue
+		}
+
+		fmt.Fprintf(&b, "    %s -> %s [label=%s];\n", edge.from, edge.to, dotText(edge.label))
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// RenderWorkflowMarkdown documents the workflows of an endpoint as a markdown section with a Mermaid flowchart
+func RenderWorkflowMarkdown(title string, workflows []*Workflow) string {
+	return fmt.Sprintf("## %s\n\n```mermaid\n%s```\n", title, RenderMermaid(workflows))
+}
+
+func newDiagram(workflows []*Workflow) *diagram {
+	d := &diagram{}
+
+	nodes := make([]Workflow, 0, len(workflows))
+
+	for _, workflow := range workflows {
+		if workflow != nil {
+			nodes = append(nodes, *workflow)
+		}
+	}
+
+	d.addSequence(nodes, nil)
+
+	return d
+}
+
+// addSequence chains the nodes after the pending exits and returns the exits of the last node
+func (d *diagram) addSequence(nodes []Workflow, exits []diagramExit) []diagramExit {
+	for _, node := range nodes {
+		exits = d.addNode(node, exits)
+	}
+
+	return exits
+}
+
+func (d *diagram) addNode(node Workflow, exits []diagramExit) []diagramExit {
+	id := fmt.Sprintf("n%d", len(d.nodes)+1)
+
+	d.nodes = append(d.nodes, diagramNode{id: id, label: diagramLabel(node), shape: diagramNodeShape(node.Type)})
+
+	for _, exit := range exits {
+		d.edges = append(d.edges, diagramEdge{from: exit.from, to: id, label: exit.label})
+	}
+
+	switch node.Type {
+	case WorkflowTypeResponse, WorkflowTypeError:
+		// the request ends here
+		return nil
+	case WorkflowTypeIf:
+		then := d.addSequence(node.Then, []diagramExit{{from: id, label: "then"}})
+		return append(then, d.addSequence(node.Else, []diagramExit{{from: id, label: "else"}})...)
+	case WorkflowTypeSwitch:
+		branches := []diagramExit{}
+		hasDefault := false
+
+		for _, switchCase := range node.Cases {
+			hasDefault = hasDefault || switchCase.Value == "default"
+			branches = append(branches, d.addSequence(bodyNodes(switchCase.Body), []diagramExit{{from: id, label: switchCase.Value}})...)
+		}
+
+		if !hasDefault {
+			branches = append(branches, diagramExit{from: id, label: "no match"})
+		}
+
+		return branches
+	case WorkflowTypeFor, WorkflowTypeWhile:
+		label := "each"
+
+		if node.Type == WorkflowTypeWhile {
+			label = "true"
+		}
+
+		for _, exit := range d.addSequence(bodyNodes(node.Body), []diagramExit{{from: id, label: label}}) {
+			d.edges = append(d.edges, diagramEdge{from: exit.from, to: id, label: exit.label})
+		}
+
+		return []diagramExit{{from: id, label: "done"}}
+	default:
+		return []diagramExit{{from: id}}
+	}
+}
+
+// bodyNodes returns the nested nodes of a loop or switch case, a body that isn't a list of nodes has none
+func bodyNodes(body interface{}) []Workflow {
+	raw, err := json.Marshal(body)
+
+	if err != nil {
+		return nil
+	}
+
+	var nodes []Workflow
+
+	if err := json.Unmarshal(raw, &nodes); err != nil {
+		return nil
+	}
+
+	return nodes
+}
+
+func diagramNodeShape(nodeType WorkflowType) diagramShape {
+	switch nodeType {
+	case WorkflowTypeRequest, WorkflowTypeResponse, WorkflowTypeError:
+		return diagramShapeTerminal
+	case WorkflowTypeIf, WorkflowTypeSwitch:
+		return diagramShapeDecision
+	case WorkflowTypeFor, WorkflowTypeWhile:
+		return diagramShapeLoop
+	default:
+		return diagramShapeBox
+	}
+}
+
+func diagramLabel(node Workflow) string {
+	var label string
+
+	switch node.Type {
+	case WorkflowTypeRequest:
+		label = strings.TrimSpace(strings.ToUpper(node.Method) + " " + node.Url)
+	case WorkflowTypeResponse, WorkflowTypeError:
+		label = strings.TrimSpace(string(node.Type) + " " + node.Status)
+	case WorkflowTypeIf:
+		label = node.Condition
+	case WorkflowTypeSwitch:
+		label = "switch " + node.Condition
+	case WorkflowTypeFor, WorkflowTypeWhile:
+		label = string(node.Type) + " " + node.Condition
+	case WorkflowTypeVariable:
+		label = "set " + node.Name
+	default:
+		label = string(node.Type)
+
+		if node.Instruction != "" {
+			label += ": " + node.Instruction
+		}
+	}
+
+	// a node missing the field its label is made of is described by its instruction
+	if trimmed := strings.TrimSpace(label); (trimmed == "" || trimmed == string(node.Type)) && node.Instruction != "" {
+		label = string(node.Type) + ": " + node.Instruction
+	}
+
+	if runes := []rune(label); len(runes) > maxDiagramLabel {
+		label = string(runes[:maxDiagramLabel-3]) + "..."
+	}
+
+	return label
+}
+
+// mermaidText escapes a quoted Mermaid label, quotes are written as entity codes
+func mermaidText(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(text)
+}
+
+// dotText quotes a DOT label
+func dotText(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
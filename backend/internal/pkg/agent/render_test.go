@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RenderDiagram(t *testing.T) {
+	workflows := []*Workflow{
+		{Type: WorkflowTypeRequest, Method: "post", Url: "/todos"},
+		{
+			Type:      WorkflowTypeIf,
+			Condition: `context.request.body.title == ""`,
+			Then:      []Workflow{{Type: WorkflowTypeResponse, Status: "400"}},
+		},
+		{
+			Type:      WorkflowTypeFor,
+			Condition: "context.request.body.tags",
+			Body:      []interface{}{map[string]interface{}{"type": "variable", "name": "tag", "value": "{{context.item}}"}},
+		},
+		{
+			Type:      WorkflowTypeSwitch,
+			Condition: "context.request.body.priority",
+			Cases: []WorkflowCase{
+				{Value: "high", Body: []interface{}{map[string]interface{}{"type": "slack", "instruction": "Notify the team"}}},
+				{Value: "default", Body: []interface{}{}},
+			},
+		},
+		{Type: WorkflowTypeResponse, Status: "201"},
+	}
+
+	tests := []struct {
+		name    string
+		format  DiagramFormat
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "mermaid",
+			format: DiagramFormatMermaid,
+			want: `flowchart TD
+    n1(["POST /todos"])
+    n2{"context.request.body.title == #quot;#quot;"}
+    n3(["response 400"])
+    n4{{"for context.request.body.tags"}}
+    n5["set tag"]
+    n6{"switch context.request.body.priority"}
+    n7["slack: Notify the team"]
+    n8(["response 201"])
+    n1 --> n2
+    n2 -->|"then"| n3
+    n2 -->|"else"| n4
+    n4 -->|"each"| n5
+    n5 --> n4
+    n4 -->|"done"| n6
+    n6 -->|"high"| n7
+    n7 --> n8
+    n6 -->|"default"| n8
+`,
+		},
+		{
+			name:   "dot",
+			format: DiagramFormatDOT,
+			want: `digraph workflow {
+    rankdir=TB;
+    node [fontname="Helvetica"];
+    n1 [label="POST /todos", shape=oval];
+    n2 [label="context.request.body.title == \"\"", shape=diamond];
+    n3 [label="response 400", shape=oval];
+    n4 [label="for context.request.body.tags", shape=hexagon];
+    n5 [label="set tag", shape=box];
+    n6 [label="switch context.request.body.priority", shape=diamond];
+    n7 [label="slack: Notify the team", shape=box];
+    n8 [label="response 201", shape=oval];
+    n1 -> n2;
+    n2 -> n3 [label="then"];
+    n2 -> n4 [label="else"];
+    n4 -> n5 [label="each"];
+    n5 -> n4;
+    n4 -> n6 [label="done"];
+    n6 -> n7 [label="high"];
+    n7 -> n8;
+    n6 -> n8 [label="default"];
+}
+`,
+		},
+		{
+			name:    "unsupported_format",
+			format:  "svg",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderDiagram(workflows, tt.format)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RenderWorkflowMarkdown(t *testing.T) {
+	got := RenderWorkflowMarkdown("GET /todos", []*Workflow{
+		{Type: WorkflowTypeRequest, Method: "GET", Url: "/todos"},
+		{Type: WorkflowTypeResponse, Status: "200"},
+	})
+
+	require.Equal(t, "## GET /todos\n\n```mermaid\nflowchart TD\n    n1([\"GET /todos\"])\n    n2([\"response 200\"])\n    n1 --> n2\n```\n", got)
+}
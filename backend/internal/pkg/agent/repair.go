@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+// completeStructured sends the prompt and decodes the response against the schema, see completeConversation.
+func completeStructured[T any](ctx context.Context, a *Agent, cfg Config, schema ResponseSchema, system, prompt string, check func(*T) error) (*T, *AgentToken, error) {
+	return completeConversation(ctx, a, cfg, schema, system, prompt, nil, check)
+}
+
+// completeConversation sends the prompt after the earlier turns of the history and decodes the response against the schema.
+// When the response can't be decoded or fails the check, the error is fed back to the model
+// in a follow-up turn, up to cfg.RepairAttempts times. Every repair turn is recorded in agentToken.Repairs.
+func completeConversation[T any](ctx context.Context, a *Agent, cfg Config, schema ResponseSchema, system, prompt string, history []Message, check func(*T) error) (*T, *AgentToken, error) {
+	agentToken := a.agentToken(cfg.Model)
+	agentToken.Input = fmt.Sprintf(`
+		%s
+
+		%s
+		`, system, prompt)
+
+	messages := conversation(history, prompt)
+
+	completion, err := a.complete(ctx, cfg, &schema, system, messages...)
+
+	if err != nil {
+		return nil, agentToken, &ProviderError{Model: cfg.Model, Err: err}
+	}
+
+	agentToken.Output = completion.Content
+	agentToken.Usage = completion.Usage
+
+	for attempt := 0; ; attempt++ {
+		dst, err := decodeStructured[T](completion.Content, schema)
+
+		if err == nil && check != nil {
+			err = check(dst)
+		}
+
+		if err == nil {
+			return dst, agentToken, nil
+		}
+
+		if attempt >= cfg.RepairAttempts {
+			return nil, agentToken, err
+		}
+
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("invalid %s response from %s, repair attempt %d", schema.Name, cfg.Model, attempt+1)
+
+		repairPrompt := fmt.Sprintf(b0RepairMessage, err.Error())
+
+		messages = append(messages,
+			Message{Role: MessageRoleAssistant, Content: completion.Content},
+			Message{Role: MessageRoleUser, Content: repairPrompt},
+		)
+
+		repairToken := a.agentToken(cfg.Model)
+		repairToken.Input = repairPrompt
+
+		agentToken.Repairs = append(agentToken.Repairs, repairToken)
+
+		completion, err = a.complete(ctx, cfg, &schema, system, messages...)
+
+		if err != nil {
+			return nil, agentToken, &ProviderError{Model: cfg.Model, Err: err}
+		}
+
+		repairToken.Output = completion.Content
+		repairToken.Usage = completion.Usage
+	}
+}
+
+func checkProjectTitleAndSlug(project *ProjectTitleAndSlug) error {
+	if project.Title == "" || project.Slug == "" {
+		return fmt.Errorf("title and slug must not be empty")
+	}
+
+	return nil
+}
+
+// checkWorkflows validates the workflow tree, the node paths of its diagnostics tell the model what to repair
+func checkWorkflows(response *WorkflowGenerationResponse) error {
+	return ValidateWorkflows(response.Workflows)
+}
+
+func checkCodeGeneration(code *CodeGeneration) error {
+	if len(code.FileContents) == 0 {
+		return fmt.Errorf("fileContents must not be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type scriptedProvider struct {
+	responses []string
+	requests  []CompletionRequest
+}
+
+func (s *scriptedProvider) Name() ProviderName {
+	return ProviderOpenAI
+}
+
+func (s *scriptedProvider) SupportsStructuredOutput() bool {
+	return true
+}
+
+func (s *scriptedProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	// the provider fails once the script is over
+	if len(s.requests) == len(s.responses) {
+		return nil, errors.New("provider unavailable")
+	}
+
+	content := s.responses[len(s.requests)]
+	s.requests = append(s.requests, req)
+
+	return &CompletionResponse{
+		Content: content,
+		Usage:   Usage{PromptTokens: 10, CompletionTokens: 5},
+	}, nil
+}
+
+func (s *scriptedProvider) Stream(ctx context.Context, req CompletionRequest, onDelta DeltaFunc) (*CompletionResponse, error) {
+	res, err := s.Complete(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	onDelta(res.Content)
+
+	return res, nil
+}
+
+func Test_Agent_GenerateTitleAndSlug_Repair(t *testing.T) {
+	validResponse := `{"title": "Todo API", "slug": "todo-api-abcdef", "description": "A todo api"}`
+
+	tests := []struct {
+		name           string
+		responses      []string
+		repairAttempts int
+		wantErr        bool
+		wantRepairs    int
+	}{
+		{
+			name:           "valid_first_response",
+			responses:      []string{validResponse},
+			repairAttempts: 2,
+			wantRepairs:    0,
+		},
+		{
+			name:           "repaired_after_invalid_json",
+			responses:      []string{`{"title": "Todo API"`, validResponse},
+			repairAttempts: 2,
+			wantRepairs:    1,
+		},
+		{
+			name:           "repaired_after_failed_check",
+			responses:      []string{`{"title": "", "slug": "", "description": ""}`, `not json`, validResponse},
+			repairAttempts: 2,
+			wantRepairs:    2,
+		},
+		{
+			name:           "repair_attempts_exhausted",
+			responses:      []string{`not json`, `still not json`},
+			repairAttempts: 1,
+			wantErr:        true,
+			wantRepairs:    1,
+		},
+		{
+			name:           "repair_disabled",
+			responses:      []string{`not json`},
+			repairAttempts: 0,
+			wantErr:        true,
+			wantRepairs:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{responses: tt.responses}
+
+			a := &Agent{
+				cfg:       &Config{Model: AgentModelGPT4, RepairAttempts: tt.repairAttempts},
+				providers: map[ProviderName]Provider{ProviderOpenAI: provider},
+			}
+
+			project, agentToken, err := a.GenerateTitleAndSlug(context.Background(), "build me a todo api")
+
+			require.Len(t, agentToken.Repairs, tt.wantRepairs)
+			require.Len(t, provider.requests, tt.wantRepairs+1)
+
+			for i, repair := range agentToken.Repairs {
+				require.Equal(t, tt.responses[i+1], repair.Output)
+				require.Equal(t, Usage{PromptTokens: 10, CompletionTokens: 5}, repair.Usage)
+				// every repair turn carries the whole conversation so far
+				require.Len(t, provider.requests[i+1].Messages, 3+2*i)
+			}
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "todo-api-abcdef", project.Slug)
+		})
+	}
+}
+
+func Test_Agent_GenerateWorkflow_Repair(t *testing.T) {
+	invalidResponse := `{"workflows": [{"action_id": "1", "type": "response", "instruction": "Return the todos", "status": "200"}]}`
+	validResponse := `{"workflows": [
+		{"action_id": "1", "type": "request", "instruction": "List todos", "method": "GET", "url": "/todos"},
+		{"action_id": "2", "type": "response", "instruction": "Return the todos", "status": "200", "body": {"todos": []}}
+	]}`
+
+	provider := &scriptedProvider{responses: []string{invalidResponse, validResponse}}
+
+	a := &Agent{
+		cfg:       &Config{Model: AgentModelGPT4, RepairAttempts: 1},
+		providers: map[ProviderName]Provider{ProviderOpenAI: provider},
+	}
+
+	response, agentToken, err := a.GenerateWorkflow(context.Background(), WorkflowGenerationOption{Prompt: "list my todos"})
+
+	require.NoError(t, err)
+	require.Len(t, response.Workflows, 2)
+	require.Len(t, agentToken.Repairs, 1)
+	require.Len(t, provider.requests, 2)
+
+	// the repair turn names the node to fix
+	messages := provider.requests[1].Messages
+	require.Contains(t, messages[len(messages)-1].Content, "workflows[0]: the first node must be of type")
+}
+
+func Test_Agent_GenerateTitleAndSlug_RepairProviderError(t *testing.T) {
+	provider := &scriptedProvider{responses: []string{`not json`}}
+
+	a := &Agent{
+		cfg:       &Config{Model: AgentModelGPT4, RepairAttempts: 2},
+		providers: map[ProviderName]Provider{ProviderOpenAI: provider},
+	}
+
+	_, agentToken, err := a.GenerateTitleAndSlug(context.Background(), "build me a todo api")
+
+	// a provider failing during a repair turn can be answered by a fallback model
+	var providerErr *ProviderError
+	require.ErrorAs(t, err, &providerErr)
+	require.Len(t, agentToken.Repairs, 1)
+}
@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+type AgentTask string
+
+const (
+	AgentTaskTitleAndSlug       AgentTask = "title_and_slug"
+	AgentTaskWorkflowGeneration AgentTask = "workflow_generation"
+	AgentTaskWorkflowUpdate     AgentTask = "workflow_update"
+	AgentTaskCodeGeneration     AgentTask = "code_generation"
+	AgentTaskChatIntent         AgentTask = "chat_intent"
+)
+
+// ProviderError is returned when the provider failed to answer, the next model of the task route is tried then
+type ProviderError struct {
+	Model AgentModel
+	Err   error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("model %s failed: %v", e.Model, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// modelChain returns the ordered list of models to try for the task.
+// The model selected with WithModel is tried first, followed by the route of the task, or the default model
+// when the task has no route. Premium models are skipped unless they are allowed.
+func (a *Agent) modelChain(cfg Config, task AgentTask) ([]ModeCatalog, error) {
+	candidates := []AgentModel{}
+
+	if cfg.Model != "" && cfg.Model != AgentModelNone {
+		candidates = append(candidates, cfg.Model)
+	}
+
+	if route, ok := cfg.Routes[task]; ok && len(route) > 0 {
+		candidates = append(candidates, route...)
+	} else if a.cfg.Model != cfg.Model {
+		candidates = append(candidates, a.cfg.Model)
+	}
+
+	chain := []ModeCatalog{}
+	seen := map[AgentModel]bool{}
+
+	for _, model := range candidates {
+		if seen[model] {
+			continue
+		}
+
+		seen[model] = true
+
+		catalog, err := a.GetModelCatalog(string(model))
+
+		if err != nil {
+			continue
+		}
+
+		if catalog.IsPremium && !cfg.AllowPremium {
+			continue
+		}
+
+		chain = append(chain, catalog)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no model available for %s", task)
+	}
+
+	return chain, nil
+}
+
+// runWithFallback runs the task against every model of the chain until one answers.
+// Only provider errors move on to the next model, invalid responses are handled by the repair loop.
+func runWithFallback[T any](ctx context.Context, a *Agent, cfg Config, task AgentTask, run func(cfg Config) (*T, *AgentToken, error)) (*T, *AgentToken, error) {
+	chain, err := a.modelChain(cfg, task)
+
+	if err != nil {
+		return nil, a.agentToken(cfg.Model), err
+	}
+
+	var (
+		result     *T
+		agentToken *AgentToken
+	)
+
+	for i, catalog := range chain {
+		modelCfg := cfg
+		modelCfg.Model = catalog.Model
+
+		result, agentToken, err = run(modelCfg)
+
+		var providerErr *ProviderError
+
+		if err == nil || !errors.As(err, &providerErr) || ctx.Err() != nil {
+			return result, agentToken, err
+		}
+
+		if i < len(chain)-1 {
+			zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s failed, falling back to %s", catalog.Model, chain[i+1].Model)
+		}
+	}
+
+	return result, agentToken, err
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingProvider struct {
+	name  ProviderName
+	calls int
+}
+
+func (f *failingProvider) Name() ProviderName {
+	return f.name
+}
+
+func (f *failingProvider) SupportsStructuredOutput() bool {
+	return true
+}
+
+func (f *failingProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	f.calls++
+	return nil, errors.New("provider unavailable")
+}
+
+func (f *failingProvider) Stream(ctx context.Context, req CompletionRequest, onDelta DeltaFunc) (*CompletionResponse, error) {
+	return f.Complete(ctx, req)
+}
+
+func Test_Agent_GenerateTitleAndSlug_Fallback(t *testing.T) {
+	validResponse := `{"title": "Todo API", "slug": "todo-api-abcdef", "description": "A todo api"}`
+
+	tests := []struct {
+		name      string
+		responses []string
+		routes    map[AgentTask][]AgentModel
+		premium   bool
+		wantErr   bool
+		wantModel string
+		// wantPremium is the tier the answering model is billed at
+		wantPremium bool
+		wantCalls   int
+	}{
+		{
+			name:      "falls_back_to_routed_model",
+			responses: []string{validResponse},
+			routes:    map[AgentTask][]AgentModel{AgentTaskTitleAndSlug: {AgentModelGPT4}},
+			wantModel: string(AgentModelGPT4),
+			wantCalls: 1,
+		},
+		{
+			name:      "skips_premium_model",
+			responses: []string{validResponse},
+			routes:    map[AgentTask][]AgentModel{AgentTaskTitleAndSlug: {AgentModelGPT3Dot5, AgentModelGPT4}},
+			wantModel: string(AgentModelGPT4),
+			wantCalls: 1,
+		},
+		{
+			name:        "uses_premium_model_when_allowed",
+			responses:   []string{validResponse},
+			routes:      map[AgentTask][]AgentModel{AgentTaskTitleAndSlug: {AgentModelGPT3Dot5, AgentModelGPT4}},
+			premium:     true,
+			wantModel:   string(AgentModelGPT3Dot5),
+			wantPremium: true,
+			wantCalls:   1,
+		},
+		{
+			name:      "invalid_response_does_not_fall_back",
+			responses: []string{`not json`},
+			routes:    map[AgentTask][]AgentModel{AgentTaskTitleAndSlug: {AgentModelGPT4, AgentModelGPT3Dot5}},
+			wantErr:   true,
+			wantModel: string(AgentModelGPT4),
+			wantCalls: 1,
+		},
+		{
+			name:      "all_models_failed",
+			routes:    map[AgentTask][]AgentModel{AgentTaskTitleAndSlug: {AgentModelDeepSeekR1}},
+			wantErr:   true,
+			wantModel: string(AgentModelDeepSeekR1),
+		},
+	}
+
+	catalogs := append([]ModeCatalog{}, AvailableCatalogs...)
+
+	for i := range catalogs {
+		if catalogs[i].Model == AgentModelGPT3Dot5 {
+			catalogs[i].IsPremium = true
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anthropic := &failingProvider{name: ProviderAnthropic}
+			deepSeek := &failingProvider{name: ProviderDeepSeek}
+			openai := &scriptedProvider{responses: tt.responses}
+
+			a := &Agent{
+				cfg: &Config{Model: AgentModelClaudeSonnet3Dot7, Routes: tt.routes},
+				providers: map[ProviderName]Provider{
+					ProviderAnthropic: anthropic,
+					ProviderDeepSeek:  deepSeek,
+					ProviderOpenAI:    openai,
+				},
+				catalogs: catalogs,
+			}
+
+			project, agentToken, err := a.GenerateTitleAndSlug(context.Background(), "build me a todo api", WithPremium(tt.premium))
+
+			require.Equal(t, 1, anthropic.calls)
+			require.Len(t, openai.requests, tt.wantCalls)
+			require.Equal(t, tt.wantModel, agentToken.Model)
+			require.Equal(t, tt.wantPremium, agentToken.IsPremium)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "todo-api-abcdef", project.Slug)
+		})
+	}
+}
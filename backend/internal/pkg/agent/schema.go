@@ -1,6 +1,12 @@
 package agent
 
-import "github.com/invopop/jsonschema"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
 
 type ProjectTitleAndSlug struct {
 	Title       string `json:"title" jsonschema_description:"The title of the project"`
@@ -8,15 +14,227 @@ type ProjectTitleAndSlug struct {
 	Description string `json:"description" jsonschema_description:"The description of the project"`
 }
 
+type WorkflowGenerationResponse struct {
+	Workflows   []*Workflow `json:"workflows" jsonschema_description:"The list of workflows, each one starting with a request node"`
+	Explanation string      `json:"explanation,omitempty" jsonschema_description:"A short explanation for the user of what was generated or changed"`
+}
+
+// ResponseSchema describes the JSON document a completion must return
+type ResponseSchema struct {
+	Name        string
+	Description string
+	Schema      *jsonschema.Schema
+	Strict      bool
+}
+
 // Generate the JSON schema at initialization time
-var ProjectTitleAndSlugResponseSchema = GenerateSchema[ProjectTitleAndSlug]()
+var (
+	ProjectTitleAndSlugResponseSchema = ResponseSchema{
+		Name:        "project",
+		Description: "Project title, description and slug",
+		Schema:      GenerateSchema[ProjectTitleAndSlug](),
+		Strict:      true,
+	}
+
+	WorkflowResponseSchema = ResponseSchema{
+		Name:        "workflows",
+		Description: "Workflow diagram nodes generated from the user prompt",
+		Schema:      GenerateSchema[WorkflowGenerationResponse](),
+	}
 
-func GenerateSchema[T any]() interface{} {
+	ChatIntentResponseSchema = ResponseSchema{
+		Name:        "chat_intent",
+		Description: "What the user wants to do with the endpoints of the project",
+		Schema:      GenerateSchema[ChatIntent](),
+		Strict:      true,
+	}
+
+	CodeGenerationResponseSchema = ResponseSchema{
+		Name:        "code_generation",
+		Description: "Generated source files and the commands to install, build and run them",
+		Schema:      GenerateSchema[CodeGeneration](),
+	}
+
+	CodePatchResponseSchema = ResponseSchema{
+		Name:        "code_patch",
+		Description: "Changes of the generated source files after the workflows changed",
+		Schema:      GenerateSchema[CodePatch](),
+	}
+)
+
+func GenerateSchema[T any]() *jsonschema.Schema {
+	// references are kept so recursive types like Workflow can be described
 	reflector := jsonschema.Reflector{
 		AllowAdditionalProperties: false,
-		DoNotReference:            true,
+		ExpandedStruct:            true,
 	}
 	var v T
 	schema := reflector.Reflect(v)
+	schema.Version = ""
+	schema.ID = ""
+	return schema
+}
+
+// SchemaError lists every violation found while validating a document against a ResponseSchema
+type SchemaError struct {
+	Schema     string
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("response does not match %s schema: %s", e.Schema, strings.Join(e.Violations, "; "))
+}
+
+// String returns the schema as indented JSON so it can be embedded in prompts
+func (r ResponseSchema) String() string {
+	raw, err := json.MarshalIndent(r.Schema, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+// Validate checks the raw JSON document against the schema
+func (r ResponseSchema) Validate(raw []byte) error {
+	var data interface{}
+
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return &SchemaError{Schema: r.Name, Violations: []string{fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	v := schemaValidator{root: r.Schema}
+	v.validate("$", r.Schema, data)
+
+	if len(v.violations) > 0 {
+		return &SchemaError{Schema: r.Name, Violations: v.violations}
+	}
+
+	return nil
+}
+
+// decodeStructured strips markdown around the completion content, validates it against the schema and decodes it
+func decodeStructured[T any](content string, schema ResponseSchema) (*T, error) {
+	raw := []byte(removeJSONMarkdown(content))
+
+	if err := schema.Validate(raw); err != nil {
+		return nil, err
+	}
+
+	var dst T
+
+	if err := json.Unmarshal(raw, &dst); err != nil {
+		return nil, err
+	}
+
+	return &dst, nil
+}
+
+type schemaValidator struct {
+	root       *jsonschema.Schema
+	violations []string
+}
+
+func (v *schemaValidator) fail(path string, format string, args ...interface{}) {
+	v.violations = append(v.violations, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *schemaValidator) resolve(schema *jsonschema.Schema) *jsonschema.Schema {
+	for schema != nil && schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/$defs/")
+		def, ok := v.root.Definitions[name]
+		if !ok {
+			return nil
+		}
+		schema = def
+	}
 	return schema
 }
+
+func (v *schemaValidator) validate(path string, schema *jsonschema.Schema, data interface{}) {
+	schema = v.resolve(schema)
+
+	if schema == nil || schema == jsonschema.TrueSchema {
+		return
+	}
+
+	if schema == jsonschema.FalseSchema {
+		v.fail(path, "is not allowed")
+		return
+	}
+
+	if schema.Type != "" && !matchesSchemaType(schema.Type, data) {
+		v.fail(path, "expected %s, got %s", schema.Type, jsonTypeOf(data))
+		return
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, value := range schema.Enum {
+			if fmt.Sprint(value) == fmt.Sprint(data) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value %v is not one of %v", data, schema.Enum)
+		}
+	}
+
+	switch value := data.(type) {
+	case map[string]interface{}:
+		for _, required := range schema.Required {
+			if _, ok := value[required]; !ok {
+				v.fail(path, "missing required property %q", required)
+			}
+		}
+
+		for key, item := range value {
+			var property *jsonschema.Schema
+			if schema.Properties != nil {
+				property, _ = schema.Properties.Get(key)
+			}
+
+			if property == nil {
+				if schema.AdditionalProperties == jsonschema.FalseSchema {
+					v.fail(path, "unknown property %q", key)
+				}
+				continue
+			}
+
+			v.validate(path+"."+key, property, item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			v.validate(fmt.Sprintf("%s[%d]", path, i), schema.Items, item)
+		}
+	}
+}
+
+func matchesSchemaType(schemaType string, data interface{}) bool {
+	switch schemaType {
+	case "integer":
+		number, ok := data.(float64)
+		return ok && number == float64(int64(number))
+	default:
+		return schemaType == jsonTypeOf(data)
+	}
+}
+
+func jsonTypeOf(data interface{}) string {
+	switch data.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", data)
+	}
+}
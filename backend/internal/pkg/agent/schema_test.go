@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeStructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  ResponseSchema
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "valid_title_and_slug",
+			schema:  ProjectTitleAndSlugResponseSchema,
+			content: `{"title": "Todo API", "slug": "todo-api", "description": "A todo api"}`,
+		},
+		{
+			name:    "valid_title_and_slug_in_markdown",
+			schema:  ProjectTitleAndSlugResponseSchema,
+			content: "```json\n{\"title\": \"Todo API\", \"slug\": \"todo-api\", \"description\": \"A todo api\"}\n```",
+		},
+		{
+			name:    "refusal_text",
+			schema:  ProjectTitleAndSlugResponseSchema,
+			content: "I'm b0, an AI assistant that can only help with backend projects",
+			wantErr: true,
+		},
+		{
+			name:    "missing_required_property",
+			schema:  ProjectTitleAndSlugResponseSchema,
+			content: `{"title": "Todo API", "slug": "todo-api"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown_property",
+			schema:  ProjectTitleAndSlugResponseSchema,
+			content: `{"title": "Todo API", "slug": "todo-api", "description": "A todo api", "extra": true}`,
+			wantErr: true,
+		},
+		{
+			name:    "valid_nested_workflows",
+			schema:  WorkflowResponseSchema,
+			content: `{"workflows": [{"type": "request", "instruction": "GET /todos", "method": "GET", "url": "/todos", "then": [{"type": "response", "instruction": "return todos", "value": {"ok": true}}]}]}`,
+		},
+		{
+			name:    "invalid_nested_workflow",
+			schema:  WorkflowResponseSchema,
+			content: `{"workflows": [{"type": "request", "instruction": "GET /todos", "then": [{"instruction": 1}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid_code_generation",
+			schema:  CodeGenerationResponseSchema,
+			content: `{"fileContents": "main.go", "installCommands": [], "buildCommands": "", "runCommands": "", "envVars": []}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeStructured[map[string]interface{}](tt.content, tt.schema)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -4,8 +4,10 @@ import "strings"
 
 func removeJSONMarkdown(s string) string {
 	s = strings.ReplaceAll(s, "Generated title and slug:", "")
-	s = strings.ReplaceAll(s, "```json\n", "")
-	s = strings.ReplaceAll(s, "\n```\n", "")
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
 
-	return s
+	return strings.TrimSpace(s)
 }
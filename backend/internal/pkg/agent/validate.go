@@ -0,0 +1,359 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type WorkflowDiagnosticCode string
+
+const (
+	WorkflowDiagnosticInvalidWorkflows  WorkflowDiagnosticCode = "invalid_workflows"
+	WorkflowDiagnosticInvalidNode       WorkflowDiagnosticCode = "invalid_node"
+	WorkflowDiagnosticFirstNode         WorkflowDiagnosticCode = "first_node_not_request"
+	WorkflowDiagnosticUnknownType       WorkflowDiagnosticCode = "unknown_type"
+	WorkflowDiagnosticDuplicateActionID WorkflowDiagnosticCode = "duplicate_action_id"
+	WorkflowDiagnosticInvalidChildren   WorkflowDiagnosticCode = "invalid_children"
+	WorkflowDiagnosticInvalidStatus     WorkflowDiagnosticCode = "invalid_status"
+	WorkflowDiagnosticUndefinedVariable WorkflowDiagnosticCode = "undefined_variable"
+	WorkflowDiagnosticInvalidExpression WorkflowDiagnosticCode = "invalid_expression"
+)
+
+var (
+	AvailableWorkflowTypes = []WorkflowType{
+		WorkflowTypeRequest,
+		WorkflowTypeResponse,
+		WorkflowTypeError,
+		WorkflowTypeIf,
+		WorkflowTypeFor,
+		WorkflowTypeWhile,
+		WorkflowTypeSwitch,
+		WorkflowTypeVariable,
+		WorkflowTypeResend,
+		WorkflowTypeOpenAI,
+		WorkflowTypeSlack,
+		WorkflowTypeDiscord,
+		WorkflowTypeTelegram,
+		WorkflowTypeGithub,
+		WorkflowTypeSupabase,
+		WorkflowTypeStripe,
+	}
+
+	// context values always available to the nodes
+	builtinContextVariables = []string{"request", "response", "env"}
+
+	// context values set by every iteration of a for node
+	loopContextVariables = []string{"item", "index"}
+
+	statusCodeRegex = regexp.MustCompile(`^[1-5][0-9]{2}$`)
+)
+
+// WorkflowDiagnostic is a problem found in a workflow tree, Path points at the node e.g workflows[1].then[0]
+type WorkflowDiagnostic struct {
+	Path    string                 `json:"path"`
+	Code    WorkflowDiagnosticCode `json:"code"`
+	Message string                 `json:"message"`
+}
+
+// WorkflowValidationError is returned when a workflow tree has at least one diagnostic
+type WorkflowValidationError struct {
+	Diagnostics []WorkflowDiagnostic
+}
+
+func (e *WorkflowValidationError) Error() string {
+	messages := make([]string, 0, len(e.Diagnostics))
+
+	for _, diagnostic := range e.Diagnostics {
+		messages = append(messages, fmt.Sprintf("%s: %s", diagnostic.Path, diagnostic.Message))
+	}
+
+	return fmt.Sprintf("invalid workflows: %s", strings.Join(messages, "; "))
+}
+
+// WorkflowDiagnostics returns the diagnostics carried by a WorkflowValidationError, nil for any other error
+func WorkflowDiagnostics(err error) []WorkflowDiagnostic {
+	var validationErr *WorkflowValidationError
+
+	if errors.As(err, &validationErr) {
+		return validationErr.Diagnostics
+	}
+
+	return nil
+}
+
+// ValidateWorkflows checks the workflow tree before it is saved or sent to code generation
+func ValidateWorkflows(workflows []*Workflow) error {
+	raw, err := json.Marshal(workflows)
+
+	if err != nil {
+		return err
+	}
+
+	return ValidateWorkflowsJSON(raw)
+}
+
+// ValidateWorkflowsJSON checks a raw workflow tree, it also reports mistakes the Workflow type can't hold
+// such as then written as a string or a numeric response status.
+func ValidateWorkflowsJSON(raw []byte) error {
+	var workflows interface{}
+
+	if err := json.Unmarshal(raw, &workflows); err != nil {
+		return &WorkflowValidationError{Diagnostics: []WorkflowDiagnostic{{
+			Path:    "workflows",
+			Code:    WorkflowDiagnosticInvalidWorkflows,
+			Message: fmt.Sprintf("invalid JSON: %v", err),
+		}}}
+	}
+
+	v := &workflowValidator{
+		actionIDs: map[string]string{},
+		defined:   map[string]bool{},
+	}
+
+	for _, name := range builtinContextVariables {
+		v.defined[name] = true
+	}
+
+	nodes, ok := workflows.([]interface{})
+
+	switch {
+	case !ok:
+		v.fail("workflows", WorkflowDiagnosticInvalidWorkflows, "expected an array of nodes, got %s", jsonTypeOf(workflows))
+	case len(nodes) == 0:
+		v.fail("workflows", WorkflowDiagnosticInvalidWorkflows, "must contain at least one node")
+	default:
+		if first, _ := nodes[0].(map[string]interface{}); first == nil || first["type"] != string(WorkflowTypeRequest) {
+			v.fail("workflows[0]", WorkflowDiagnosticFirstNode, "the first node must be of type %q", WorkflowTypeRequest)
+		}
+
+		v.walkNodes("workflows", nodes)
+	}
+
+	// expressions are checked last, a variable can be defined anywhere in the tree
+	for _, expression := range v.expressions {
+		for _, err := range expression.expression.Check(v.defined) {
+			if err.Code == ExpressionErrorUndefined {
+				v.fail(expression.path, WorkflowDiagnosticUndefinedVariable, "{{context.%s}} references a variable that is never defined", err.Name)
+				continue
+			}
+
+			v.fail(expression.path, WorkflowDiagnosticInvalidExpression, "invalid expression %q: %s", expression.expression, err)
+		}
+	}
+
+	if len(v.diagnostics) > 0 {
+		return &WorkflowValidationError{Diagnostics: v.diagnostics}
+	}
+
+	return nil
+}
+
+type workflowExpression struct {
+	path       string
+	expression *Expression
+}
+
+type workflowValidator struct {
+	diagnostics []WorkflowDiagnostic
+	actionIDs   map[string]string
+	defined     map[string]bool
+	expressions []workflowExpression
+}
+
+func (v *workflowValidator) fail(path string, code WorkflowDiagnosticCode, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, WorkflowDiagnostic{
+		Path:    path,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *workflowValidator) walkNodes(path string, nodes []interface{}) {
+	for i, node := range nodes {
+		v.walkNode(fmt.Sprintf("%s[%d]", path, i), node)
+	}
+}
+
+// walkChildren validates a nested list of nodes such as then or else
+func (v *workflowValidator) walkChildren(path string, value interface{}) {
+	nodes, ok := value.([]interface{})
+
+	if !ok {
+		v.fail(path, WorkflowDiagnosticInvalidChildren, "expected an array of nodes, got %s", jsonTypeOf(value))
+		return
+	}
+
+	v.walkNodes(path, nodes)
+}
+
+func (v *workflowValidator) walkNode(path string, value interface{}) {
+	node, ok := value.(map[string]interface{})
+
+	if !ok {
+		v.fail(path, WorkflowDiagnosticInvalidNode, "expected a node object, got %s", jsonTypeOf(value))
+		return
+	}
+
+	nodeType, _ := node["type"].(string)
+
+	if !isWorkflowType(nodeType) {
+		v.fail(path, WorkflowDiagnosticUnknownType, "unknown node type %q", fmt.Sprint(node["type"]))
+	}
+
+	if actionID, _ := node["action_id"].(string); actionID != "" {
+		if firstPath, ok := v.actionIDs[actionID]; ok {
+			v.fail(path, WorkflowDiagnosticDuplicateActionID, "action_id %q is already used by %s", actionID, firstPath)
+		} else {
+			v.actionIDs[actionID] = path
+		}
+
+		v.defined[actionID] = true
+	}
+
+	if name, _ := node["name"].(string); nodeType == string(WorkflowTypeVariable) && name != "" {
+		v.defined[name] = true
+	}
+
+	if nodeType == string(WorkflowTypeFor) {
+		for _, name := range loopContextVariables {
+			v.defined[name] = true
+		}
+	}
+
+	if variables, ok := node["variables"].([]interface{}); ok {
+		for _, variable := range variables {
+			if name, ok := variable.(string); ok {
+				v.defined[name] = true
+			}
+		}
+	}
+
+	if status, ok := node["status"]; ok && nodeType == string(WorkflowTypeResponse) {
+		if code, isString := status.(string); !isString || !statusCodeRegex.MatchString(code) {
+			v.fail(path+".status", WorkflowDiagnosticInvalidStatus, "status must be an http status code string e.g \"200\", got %#v", status)
+		}
+	}
+
+	for _, key := range sortedKeys(node) {
+		field := node[key]
+		fieldPath := path + "." + key
+
+		switch {
+		case key == "then" || key == "else":
+			v.walkChildren(fieldPath, field)
+		case key == "cases":
+			v.walkCases(fieldPath, field)
+		case key == "body" && (nodeType == string(WorkflowTypeFor) || nodeType == string(WorkflowTypeWhile)) && isArray(field):
+			v.walkChildren(fieldPath, field)
+		case key == "condition":
+			v.collectCondition(path, field)
+		default:
+			v.collectReferences(path, field)
+		}
+	}
+}
+
+func (v *workflowValidator) walkCases(path string, value interface{}) {
+	cases, ok := value.([]interface{})
+
+	if !ok {
+		v.fail(path, WorkflowDiagnosticInvalidChildren, "expected an array of cases, got %s", jsonTypeOf(value))
+		return
+	}
+
+	for i, item := range cases {
+		casePath := fmt.Sprintf("%s[%d]", path, i)
+		switchCase, ok := item.(map[string]interface{})
+
+		if !ok {
+			v.fail(casePath, WorkflowDiagnosticInvalidNode, "expected a case object, got %s", jsonTypeOf(item))
+			continue
+		}
+
+		for _, key := range sortedKeys(switchCase) {
+			field := switchCase[key]
+
+			if key == "body" && isArray(field) {
+				v.walkChildren(casePath+".body", field)
+				continue
+			}
+
+			v.collectReferences(casePath, field)
+		}
+	}
+}
+
+// collectCondition parses the condition of a node as an expression, an empty condition is left to the node
+func (v *workflowValidator) collectCondition(path string, value interface{}) {
+	condition, ok := value.(string)
+
+	if !ok || strings.TrimSpace(condition) == "" {
+		v.collectReferences(path, value)
+		return
+	}
+
+	expression, err := ParseExpression(condition)
+
+	if err != nil {
+		v.fail(path, WorkflowDiagnosticInvalidExpression, "invalid condition %q: %s", condition, err)
+		return
+	}
+
+	v.expressions = append(v.expressions, workflowExpression{path: path, expression: expression})
+}
+
+// collectReferences records every {{...}} found in the strings of the value, reported on the node path
+func (v *workflowValidator) collectReferences(path string, value interface{}) {
+	switch value := value.(type) {
+	case string:
+		template, err := ParseTemplate(value)
+
+		if err != nil {
+			v.fail(path, WorkflowDiagnosticInvalidExpression, "invalid interpolation in %q: %s", value, err)
+			return
+		}
+
+		for _, expression := range template.Expressions() {
+			v.expressions = append(v.expressions, workflowExpression{path: path, expression: expression})
+		}
+	case []interface{}:
+		for _, item := range value {
+			v.collectReferences(path, item)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(value) {
+			v.collectReferences(path, value[key])
+		}
+	}
+}
+
+func isWorkflowType(nodeType string) bool {
+	for _, workflowType := range AvailableWorkflowTypes {
+		if string(workflowType) == nodeType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(value map[string]interface{}) []string {
+	keys := make([]string, 0, len(value))
+
+	for key := range value {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func isArray(value interface{}) bool {
+	_, ok := value.([]interface{})
+	return ok
+}
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidateWorkflowsJSON(t *testing.T) {
+	tests := []struct {
+		name            string
+		workflows       string
+		wantDiagnostics []WorkflowDiagnostic
+	}{
+		{
+			name: "valid_workflows",
+			workflows: `[
+				{"action_id": "1", "type": "request", "instruction": "Create a todo", "method": "POST", "url": "/todos"},
+				{"action_id": "2", "type": "variable", "name": "title", "value": "{{context.request.body.title}}"},
+				{"action_id": "3", "type": "if", "condition": "{{context.title}} == ''", "then": [
+					{"action_id": "4", "type": "response", "status": "400", "body": {"error": "title is required"}}
+				], "else": [
+					{"action_id": "5", "type": "response", "status": "201", "body": {"title": "{{context.title}}"}}
+				]}
+			]`,
+		},
+		{
+			name:      "empty_workflows",
+			workflows: `[]`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows", Code: WorkflowDiagnosticInvalidWorkflows, Message: "must contain at least one node"},
+			},
+		},
+		{
+			name: "first_node_not_request",
+			workflows: `[
+				{"action_id": "1", "type": "response", "status": "200"}
+			]`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows[0]", Code: WorkflowDiagnosticFirstNode, Message: `the first node must be of type "request"`},
+			},
+		},
+		{
+			name: "duplicate_action_id_and_unknown_type",
+			workflows: `[
+				{"action_id": "1", "type": "request", "url": "/todos"},
+				{"action_id": "1", "type": "database"}
+			]`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows[1]", Code: WorkflowDiagnosticUnknownType, Message: `unknown node type "database"`},
+				{Path: "workflows[1]", Code: WorkflowDiagnosticDuplicateActionID, Message: `action_id "1" is already used by workflows[0]`},
+			},
+		},
+		{
+			name: "then_and_else_as_strings",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "if", "condition": "true", "then": "return the todos", "else": ["not a node"]}
+			]`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows[1].else[0]", Code: WorkflowDiagnosticInvalidNode, Message: "expected a node object, got string"},
+				{Path: "workflows[1].then", Code: WorkflowDiagnosticInvalidChildren, Message: "expected an array of nodes, got string"},
+			},
+		},
+		{
+			name: "non_string_status",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "switch", "condition": "{{context.request.method}}", "cases": [
+					{"value": "GET", "body": [{"type": "response", "status": 200}]},
+					{"value": "POST", "body": [{"type": "response", "status": "201 Created"}]}
+				]}
+			]`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows[1].cases[0].body[0].status", Code: WorkflowDiagnosticInvalidStatus, Message: `status must be an http status code string e.g "200", got 200`},
+				{Path: "workflows[1].cases[1].body[0].status", Code: WorkflowDiagnosticInvalidStatus, Message: `status must be an http status code string e.g "200", got "201 Created"`},
+			},
+		},
+		{
+			name: "undefined_variable",
+			workflows: `[
+				{"type": "request", "url": "/todos/:id", "variables": ["id"]},
+				{"type": "for", "condition": "{{context.todos}}", "body": [
+					{"type": "response", "status": "200", "body": {"id": "{{context.id}}", "user": "{{ context.user.name }}"}}
+				]}
+			]`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows[1].body[0]", Code: WorkflowDiagnosticUndefinedVariable, Message: "{{context.user}} references a variable that is never defined"},
+				{Path: "workflows[1]", Code: WorkflowDiagnosticUndefinedVariable, Message: "{{context.todos}} references a variable that is never defined"},
+			},
+		},
+		{
+			name: "loop_and_env_variables",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "for", "condition": "{{context.request.body.todos}}", "body": [
+					{"type": "if", "condition": "len(context.item.title) > 0 && context.index < 10", "then": [
+						{"type": "github", "url": "https://api.github.com/repos", "headers": ["Authorization: Bearer {{context.env.GITHUB_TOKEN}}"]}
+					], "else": []}
+				]}
+			]`,
+		},
+		{
+			name: "invalid_expression",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "if", "condition": "title is empty", "then": [], "else": []},
+				{"type": "while", "condition": "upper(1) == 'A'", "body": [
+					{"type": "response", "status": "200", "body": {"message": "Hello {{context.request.body.name"}}
+				]}
+			]`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows[1]", Code: WorkflowDiagnosticInvalidExpression, Message: `invalid condition "title is empty": unknown identifier "title", values are read from context e.g context.title at position 1`},
+				{Path: "workflows[2].body[0]", Code: WorkflowDiagnosticInvalidExpression, Message: `invalid interpolation in "Hello {{context.request.body.name": expected }} to close the interpolation, got end of expression at position 34`},
+				{Path: "workflows[2]", Code: WorkflowDiagnosticInvalidExpression, Message: `invalid expression "upper(1) == 'A'": argument 1 of upper must be string, got number at position 7`},
+			},
+		},
+		{
+			name:      "not_an_array",
+			workflows: `{"workflows": []}`,
+			wantDiagnostics: []WorkflowDiagnostic{
+				{Path: "workflows", Code: WorkflowDiagnosticInvalidWorkflows, Message: "expected an array of nodes, got object"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWorkflowsJSON([]byte(tt.workflows))
+
+			if len(tt.wantDiagnostics) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var validationErr *WorkflowValidationError
+			require.True(t, errors.As(err, &validationErr))
+			require.Equal(t, tt.wantDiagnostics, validationErr.Diagnostics)
+		})
+	}
+}
+
+func Test_ValidateWorkflows(t *testing.T) {
+	err := ValidateWorkflows([]*Workflow{
+		{Type: WorkflowTypeRequest, Url: "/todos"},
+		{Type: WorkflowTypeIf, Condition: "true", Then: []Workflow{{Type: WorkflowTypeResponse, Status: "ok"}}},
+	})
+
+	require.EqualError(t, err, `invalid workflows: workflows[1].then[0].status: status must be an http status code string e.g "200", got "ok"`)
+}
@@ -0,0 +1,43 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/mujhtech/b0/config"
+)
+
+// DownloadPath is the route serving the artifacts behind signed links
+const DownloadPath = "/api/platform/artifacts"
+
+var (
+	ErrNotFound    = errors.New("artifact not found")
+	ErrInvalidName = errors.New("invalid artifact name")
+
+	// names are single path segments so an artifact can't escape the store
+	nameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// Store keeps the files built by the jobs, such as project exports, until they're downloaded
+type Store interface {
+	Save(ctx context.Context, name string, content io.Reader) error
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// Prune deletes the artifacts saved before the time and returns how many were deleted
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+func New(cfg *config.Config) (Store, error) {
+	return NewLocalStore(cfg.Artifact.Dir)
+}
+
+func validateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package artifact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	signer := NewSigner("secret")
+	signer.now = func() time.Time { return now }
+
+	link, err := url.Parse(signer.URL(DownloadPath, "todo.zip", now.Add(time.Hour)))
+	require.NoError(t, err)
+	require.Equal(t, DownloadPath+"/todo.zip", link.Path)
+
+	expires, signature := link.Query().Get("expires"), link.Query().Get("signature")
+
+	// a link signed with the secret itself instead of the derived key
+	rawMAC := hmac.New(sha256.New, []byte("secret"))
+	rawMAC.Write([]byte("todo.zip\n" + expires))
+	rawSignature := hex.EncodeToString(rawMAC.Sum(nil))
+
+	tests := []struct {
+		name      string
+		artifact  string
+		expires   string
+		signature string
+		now       time.Time
+		wantErr   error
+	}{
+		{
+			name:      "valid",
+			artifact:  "todo.zip",
+			expires:   expires,
+			signature: signature,
+			now:       now,
+		},
+		{
+			name:      "other_artifact",
+			artifact:  "other.zip",
+			expires:   expires,
+			signature: signature,
+			now:       now,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "extended_expiry",
+			artifact:  "todo.zip",
+			expires:   "9999999999",
+			signature: signature,
+			now:       now,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "signed_with_the_secret",
+			artifact:  "todo.zip",
+			expires:   expires,
+			signature: rawSignature,
+			now:       now,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "malformed_signature",
+			artifact:  "todo.zip",
+			expires:   expires,
+			signature: "not-hex",
+			now:       now,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "expired",
+			artifact:  "todo.zip",
+			expires:   expires,
+			signature: signature,
+			now:       now.Add(2 * time.Hour),
+			wantErr:   ErrLinkExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer.now = func() time.Time { return tt.now }
+
+			err := signer.Verify(tt.artifact, tt.expires, tt.signature)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewLocalStore(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save(ctx, "todo.zip", strings.NewReader("archive")))
+
+	file, err := store.Open(ctx, "todo.zip")
+	require.NoError(t, err)
+
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.Equal(t, "archive", string(content))
+
+	_, err = store.Open(ctx, "missing.zip")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	for _, name := range []string{"../todo.zip", "nested/todo.zip", ".hidden", ""} {
+		require.ErrorIs(t, store.Save(ctx, name, strings.NewReader("archive")), ErrInvalidName)
+
+		_, err = store.Open(ctx, name)
+		require.ErrorIs(t, err, ErrInvalidName)
+	}
+
+	require.NoError(t, store.Save(ctx, "old.zip", strings.NewReader("archive")))
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "old.zip"), old, old))
+
+	pruned, err := store.Prune(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+
+	_, err = store.Open(ctx, "old.zip")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	file, err = store.Open(ctx, "todo.zip")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
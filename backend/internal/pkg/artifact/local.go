@@ -0,0 +1,96 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore keeps the artifacts as files of the directory
+func NewLocalStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &localStore{
+		dir: dir,
+	}, nil
+}
+
+// Save writes the artifact to a temporary file first, a download never sees a partial archive
+func (l *localStore) Save(ctx context.Context, name string, content io.Reader) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(l.dir, ".upload-*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name()) // #nosec G104
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(l.dir, name))
+}
+
+func (l *localStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(l.dir, name)) // #nosec G304
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return file, err
+}
+
+func (l *localStore) Prune(ctx context.Context, before time.Time) (int, error) {
+	entries, err := os.ReadDir(l.dir)
+
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(l.dir, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return pruned, err
+		}
+
+		pruned++
+	}
+
+	return pruned, nil
+}
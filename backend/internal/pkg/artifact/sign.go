@@ -0,0 +1,86 @@
+package artifact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// signingKeyInfo labels the key derived for the download links, it differs from the keys derived for other uses
+const signingKeyInfo = "b0 artifact download links"
+
+var (
+	ErrInvalidSignature = errors.New("invalid artifact signature")
+	ErrLinkExpired      = errors.New("artifact link expired")
+)
+
+// Signer signs the download links of the artifacts, a link is only valid for its artifact and until it expires
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewSigner returns a signer keyed by a key derived from the secret, the links can't be forged from
+// a value signed or encrypted with the secret itself
+func NewSigner(secret string) *Signer {
+	key := make([]byte, sha256.Size)
+
+	// reading less than 255 hashes of the HKDF output can't fail
+	_, _ = io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(signingKeyInfo)), key)
+
+	return &Signer{
+		secret: key,
+		now:    time.Now,
+	}
+}
+
+// URL returns the download path of the artifact signed until the expiry
+func (s *Signer) URL(path, name string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.signature(name, expires))
+
+	return fmt.Sprintf("%s/%s?%s", path, url.PathEscape(name), query.Encode())
+}
+
+// Verify checks the expires and signature query parameters of a download link of the artifact
+func (s *Signer) Verify(name, expires, signature string) error {
+	expected, err := hex.DecodeString(signature)
+
+	if err != nil || !hmac.Equal(expected, s.mac(name, expires)) {
+		return ErrInvalidSignature
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if s.now().Unix() > expiresAt {
+		return ErrLinkExpired
+	}
+
+	return nil
+}
+
+func (s *Signer) signature(name, expires string) string {
+	return hex.EncodeToString(s.mac(name, expires))
+}
+
+func (s *Signer) mac(name, expires string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(name + "\n" + expires))
+
+	return mac.Sum(nil)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 )
 
@@ -254,3 +255,77 @@ func (c *Container) InspectVolume(ctx context.Context, id string) (*volume.Volum
 	volume, _, err := c.client.VolumeInspectWithRaw(ctx, id)
 	return &volume, err
 }
+
+// RunToCompletion creates a container without published ports, copies the files to the working directory,
+// waits for the command to exit and removes the container. A non zero exit code isn't an error.
+func (c *Container) RunToCompletion(ctx context.Context, opts RunContainerOption) (*RunResult, error) {
+	resp, err := c.client.ContainerCreate(ctx, &container.Config{
+		WorkingDir: opts.WorkingDir,
+		Image:      opts.Image,
+		Cmd:        opts.Command,
+		Env:        opts.Env,
+	}, &container.HostConfig{
+		Resources: container.Resources{
+			CPUQuota:   100000,
+			CPUPeriod:  100000,
+			Memory:     512 * 1024 * 1024,
+			MemorySwap: 1024 * 1024 * 1024,
+		},
+		NetworkMode: "bridge",
+	}, nil, nil, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		// the context may be done when the command timed out
+		_ = c.client.ContainerRemove(context.WithoutCancel(ctx), resp.ID, container.RemoveOptions{Force: true})
+	}()
+
+	if opts.Files != nil {
+		if err = c.client.CopyToContainer(ctx, resp.ID, opts.WorkingDir, opts.Files, container.CopyToContainerOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	statusCh, errCh := c.client.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+
+	if err = c.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return nil, err
+	}
+
+	result := &RunResult{}
+
+	select {
+	case err = <-errCh:
+		return nil, err
+	case status := <-statusCh:
+		if status.Error != nil {
+			return nil, fmt.Errorf("failed to wait for container: %s", status.Error.Message)
+		}
+
+		result.ExitCode = status.StatusCode
+	}
+
+	logs, err := c.client.ContainerLogs(ctx, resp.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer logs.Close()
+
+	output, stderr := new(strings.Builder), new(strings.Builder)
+
+	// without a tty the logs are multiplexed
+	if _, err = stdcopy.StdCopy(output, io.MultiWriter(output, stderr), logs); err != nil {
+		return nil, err
+	}
+
+	result.Output = output.String()
+	result.Stderr = stderr.String()
+
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// ProjectLabel is the label of the containers deployed for a project, its value is the project id
+const ProjectLabel = "project_id"
+
+// Crash is a project container that stopped without being asked to
+type Crash struct {
+	ContainerID string
+	ProjectID   string
+	ExitCode    int
+	OOMKilled   bool
+	Time        time.Time
+}
+
+// crashDetector tells the crashes of the containers apart from their stops and restarts.
+// A stop or restart kills the container before it dies, a crash doesn't.
+type crashDetector struct {
+	// interval is how often a container crashing in a loop is reported
+	interval time.Duration
+	stopping map[string]bool
+	oom      map[string]bool
+	reported map[string]time.Time
+}
+
+func newCrashDetector(interval time.Duration) *crashDetector {
+	return &crashDetector{
+		interval: interval,
+		stopping: map[string]bool{},
+		oom:      map[string]bool{},
+		reported: map[string]time.Time{},
+	}
+}
+
+func (d *crashDetector) observe(msg events.Message) (Crash, bool) {
+	id := msg.Actor.ID
+
+	switch msg.Action {
+	case events.ActionKill:
+		d.stopping[id] = true
+	case events.ActionOOM:
+		d.oom[id] = true
+	case events.ActionStart:
+		delete(d.stopping, id)
+		delete(d.oom, id)
+	case events.ActionDestroy:
+		delete(d.stopping, id)
+		delete(d.oom, id)
+		delete(d.reported, id)
+	case events.ActionDie:
+		oomKilled := d.oom[id]
+		stopped := d.stopping[id]
+
+		delete(d.stopping, id)
+		delete(d.oom, id)
+
+		exitCode, _ := strconv.Atoi(msg.Actor.Attributes["exitCode"])
+
+		if !oomKilled && (stopped || exitCode == 0) {
+			return Crash{}, false
+		}
+
+		at := time.Unix(0, msg.TimeNano)
+
+		if last, ok := d.reported[id]; ok && at.Sub(last) < d.interval {
+			return Crash{}, false
+		}
+
+		d.reported[id] = at
+
+		return Crash{
+			ContainerID: id,
+			ProjectID:   msg.Actor.Attributes[ProjectLabel],
+			ExitCode:    exitCode,
+			OOMKilled:   oomKilled,
+			Time:        at,
+		}, true
+	}
+
+	return Crash{}, false
+}
+
+// WatchCrashes calls onCrash for the crashes of the project containers until the context is done or the
+// event stream fails, a container crashing in a loop is reported once every interval
+func (c *Container) WatchCrashes(ctx context.Context, interval time.Duration, onCrash func(Crash)) error {
+	messages, errs := c.client.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("label", ProjectLabel),
+			filters.Arg("event", string(events.ActionKill)),
+			filters.Arg("event", string(events.ActionOOM)),
+			filters.Arg("event", string(events.ActionStart)),
+			filters.Arg("event", string(events.ActionDie)),
+			filters.Arg("event", string(events.ActionDestroy)),
+		),
+	})
+
+	detector := newCrashDetector(interval)
+
+	for {
+		select {
+		case msg := <-messages:
+			if crash, ok := detector.observe(msg); ok {
+				onCrash(crash)
+			}
+		case err := <-errs:
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
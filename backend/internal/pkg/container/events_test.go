@@ -0,0 +1,97 @@
+package container
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_crashDetector(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	message := func(action events.Action, exitCode string, after time.Duration) events.Message {
+		return events.Message{
+			Type:   events.ContainerEventType,
+			Action: action,
+			Actor: events.Actor{
+				ID:         "container-1",
+				Attributes: map[string]string{ProjectLabel: "project-1", "exitCode": exitCode},
+			},
+			TimeNano: start.Add(after).UnixNano(),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		messages []events.Message
+		want     []Crash
+	}{
+		{
+			name: "a restart isn't a crash",
+			messages: []events.Message{
+				message(events.ActionKill, "", 0),
+				message(events.ActionDie, "143", time.Second),
+				message(events.ActionStart, "", 2*time.Second),
+			},
+		},
+		{
+			name: "a clean exit isn't a crash",
+			messages: []events.Message{
+				message(events.ActionDie, "0", 0),
+			},
+		},
+		{
+			name: "a failed exit is a crash",
+			messages: []events.Message{
+				message(events.ActionDie, "1", 0),
+			},
+			want: []Crash{
+				{ContainerID: "container-1", ProjectID: "project-1", ExitCode: 1, Time: start},
+			},
+		},
+		{
+			name: "an out of memory kill is a crash",
+			messages: []events.Message{
+				message(events.ActionOOM, "", 0),
+				message(events.ActionKill, "", 0),
+				message(events.ActionDie, "137", time.Second),
+			},
+			want: []Crash{
+				{ContainerID: "container-1", ProjectID: "project-1", ExitCode: 137, OOMKilled: true, Time: start.Add(time.Second)},
+			},
+		},
+		{
+			name: "a crash loop is reported once every interval",
+			messages: []events.Message{
+				message(events.ActionDie, "1", 0),
+				message(events.ActionStart, "", time.Second),
+				message(events.ActionDie, "1", 2*time.Second),
+				message(events.ActionStart, "", 3*time.Second),
+				message(events.ActionDie, "1", 6*time.Minute),
+			},
+			want: []Crash{
+				{ContainerID: "container-1", ProjectID: "project-1", ExitCode: 1, Time: start},
+				{ContainerID: "container-1", ProjectID: "project-1", ExitCode: 1, Time: start.Add(6 * time.Minute)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := newCrashDetector(5 * time.Minute)
+
+			var crashes []Crash
+
+			for _, msg := range tt.messages {
+				if crash, ok := detector.observe(msg); ok {
+					crash.Time = crash.Time.UTC()
+					crashes = append(crashes, crash)
+				}
+			}
+
+			require.Equal(t, tt.want, crashes)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package container
 
+import "io"
+
 type FilterContainerOption struct {
 	Name string
 	ID   string
@@ -18,3 +20,21 @@ type CreateContainerOption struct {
 	HostConfigBinds []string
 	WorkingDir      string
 }
+
+// RunContainerOption describes a throwaway container running a command to completion
+type RunContainerOption struct {
+	Name       string
+	Image      string
+	Command    []string
+	Env        []string
+	WorkingDir string
+	// Files is a tar archive copied to the working directory before the command starts
+	Files io.Reader
+}
+
+type RunResult struct {
+	ExitCode int64
+	// Output interleaves stdout and stderr in the order they were written
+	Output string
+	Stderr string
+}
@@ -0,0 +1,341 @@
+package gitremote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	remoteName = "origin"
+
+	// DefaultBranch is the branch pushed to when the remote doesn't name one
+	DefaultBranch = "main"
+)
+
+var (
+	ErrInvalidRemote = errors.New("invalid git remote")
+	ErrNoCommits     = errors.New("no commits to push")
+	// ErrDiverged is returned when the branch of the remote has commits b0 didn't push, the history isn't overwritten
+	ErrDiverged = errors.New("the remote branch has commits b0 didn't push, push to an empty repository or a new branch")
+
+	// allowFileRemotes lets the tests push to a local repository, a remote on the worker's file system is refused otherwise
+	allowFileRemotes = false
+)
+
+// Remote is the repository the code is pushed to, the credentials used depend on the protocol of the URL:
+// the username and password (or token) over HTTPS and the private key over SSH
+type Remote struct {
+	URL           string `json:"url"`
+	Branch        string `json:"branch,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	SSHKey        string `json:"ssh_key,omitempty"`
+	SSHPassphrase string `json:"ssh_passphrase,omitempty"`
+	// KnownHosts are the known_hosts lines the SSH host key is checked against, the worker's known_hosts when empty
+	KnownHosts string `json:"known_hosts,omitempty"`
+}
+
+// BranchOrDefault returns the branch of the remote, the default branch when it's not set
+func (r *Remote) BranchOrDefault() string {
+	if r.Branch == "" {
+		return DefaultBranch
+	}
+
+	return r.Branch
+}
+
+// Protocol returns the transport of the URL e.g https, ssh or file
+func (r *Remote) Protocol() (string, error) {
+	if strings.TrimSpace(r.URL) == "" {
+		return "", fmt.Errorf("%w: the url is required", ErrInvalidRemote)
+	}
+
+	endpoint, err := transport.NewEndpoint(r.URL)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRemote, err)
+	}
+
+	return endpoint.Protocol, nil
+}
+
+// Validate checks the URL, the branch and that an SSH remote has a key, the credentials are only checked by a push.
+// Credentials are never sent over plain HTTP.
+func (r *Remote) Validate() error {
+	protocol, err := r.Protocol()
+
+	if err != nil {
+		return err
+	}
+
+	switch protocol {
+	case "https":
+	case "http":
+		if r.Username != "" || r.Password != "" {
+			return fmt.Errorf("%w: credentials require an https url", ErrInvalidRemote)
+		}
+	case "file":
+		if !allowFileRemotes {
+			return fmt.Errorf("%w: unsupported protocol %s", ErrInvalidRemote, protocol)
+		}
+	case "ssh":
+		if r.SSHKey == "" {
+			return fmt.Errorf("%w: the ssh key is required", ErrInvalidRemote)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported protocol %s", ErrInvalidRemote, protocol)
+	}
+
+	if !plumbing.NewBranchReferenceName(r.BranchOrDefault()).IsBranch() || strings.ContainsAny(r.BranchOrDefault(), " ~^:?*[\\") {
+		return fmt.Errorf("%w: invalid branch %q", ErrInvalidRemote, r.Branch)
+	}
+
+	return nil
+}
+
+func (r *Remote) auth() (transport.AuthMethod, error) {
+	protocol, err := r.Protocol()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch protocol {
+	case "https", "http":
+		if r.Password == "" {
+			return nil, nil
+		}
+
+		username := r.Username
+
+		// the git hosts ignore the username of a token but require one
+		if username == "" {
+			username = "b0"
+		}
+
+		return &githttp.BasicAuth{Username: username, Password: r.Password}, nil
+	case "ssh":
+		endpoint, _ := transport.NewEndpoint(r.URL)
+
+		user := endpoint.User
+
+		if user == "" {
+			user = "git"
+		}
+
+		auth, err := gitssh.NewPublicKeys(user, []byte(r.SSHKey), r.SSHPassphrase)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRemote, err)
+		}
+
+		if r.KnownHosts != "" {
+			callback, err := knownHostsCallback(r.KnownHosts)
+
+			if err != nil {
+				return nil, err
+			}
+
+			auth.HostKeyCallback = callback
+		}
+
+		return auth, nil
+	default:
+		return nil, nil
+	}
+}
+
+// knownHostsCallback checks the host keys against the known_hosts lines, the lines are parsed from a temporary file
+func knownHostsCallback(lines string) (ssh.HostKeyCallback, error) {
+	file, err := os.CreateTemp("", "b0-known-hosts-*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(file.Name()) // #nosec G104
+
+	if _, err := file.WriteString(lines); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	callback, err := knownhosts.New(file.Name())
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid known hosts: %v", ErrInvalidRemote, err)
+	}
+
+	return callback, nil
+}
+
+type File struct {
+	Path    string
+	Content string
+}
+
+// Commit replaces the files of a directory of the repository, the other directories are kept as they are
+type Commit struct {
+	Dir     string
+	Files   []File
+	Message string
+	Time    time.Time
+}
+
+type Author struct {
+	Name  string
+	Email string
+}
+
+// Push builds the history of the commits on an empty repository and pushes it to the branch of the remote.
+// The commits are the same every time the same history is built, a push of a longer history fast-forwards the branch.
+// It returns the hashes of the commits in order.
+func Push(ctx context.Context, remote Remote, author Author, commits []Commit) ([]string, error) {
+	if len(commits) == 0 {
+		return nil, ErrNoCommits
+	}
+
+	if err := remote.Validate(); err != nil {
+		return nil, err
+	}
+
+	auth, err := remote.auth()
+
+	if err != nil {
+		return nil, err
+	}
+
+	branch := plumbing.NewBranchReferenceName(remote.BranchOrDefault())
+
+	repo, hashes, err := buildHistory(branch, author, commits)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: remoteName,
+		URLs: []string{remote.URL},
+	}); err != nil {
+		return nil, err
+	}
+
+	err = repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("%s:%s", branch, branch))},
+		Auth:       auth,
+	})
+
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate):
+		return hashes, nil
+	case strings.Contains(err.Error(), "non-fast-forward"):
+		return nil, ErrDiverged
+	default:
+		// the url is left out, it may hold credentials
+		return nil, fmt.Errorf("failed to push: %w", err)
+	}
+}
+
+func buildHistory(branch plumbing.ReferenceName, author Author, commits []Commit) (*git.Repository, []string, error) {
+	fs := memfs.New()
+
+	repo, err := git.Init(memory.NewStorage(), fs)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch)); err != nil {
+		return nil, nil, err
+	}
+
+	worktree, err := repo.Worktree()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, 0, len(commits))
+
+	for _, commit := range commits {
+		dir, err := cleanPath(commit.Dir)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := util.RemoveAll(fs, dir); err != nil {
+			return nil, nil, err
+		}
+
+		for _, file := range commit.Files {
+			name, err := cleanPath(file.Path)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if err := util.WriteFile(fs, path.Join(dir, name), []byte(file.Content), 0o644); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+			return nil, nil, err
+		}
+
+		signature := &object.Signature{
+			Name:  author.Name,
+			Email: author.Email,
+			When:  commit.Time.UTC().Truncate(time.Second),
+		}
+
+		hash, err := worktree.Commit(commit.Message, &git.CommitOptions{
+			Author:            signature,
+			Committer:         signature,
+			AllowEmptyCommits: true,
+		})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hashes = append(hashes, hash.String())
+	}
+
+	return repo, hashes, nil
+}
+
+// cleanPath returns the path relative to the root of the repository, a path leaving the repository is refused
+func cleanPath(p string) (string, error) {
+	name := path.Clean(strings.TrimPrefix(strings.ReplaceAll(p, "\\", "/"), "/"))
+
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") || name == ".git" || strings.HasPrefix(name, ".git/") {
+		return "", fmt.Errorf("invalid path %q", p)
+	}
+
+	return name, nil
+}
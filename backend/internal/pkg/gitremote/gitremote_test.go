@@ -0,0 +1,224 @@
+package gitremote
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPush(t *testing.T) {
+	allowFileRemotes = true
+	t.Cleanup(func() { allowFileRemotes = false })
+
+	author := Author{Name: "b0", Email: "noreply@b0.dev"}
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	commits := []Commit{
+		{
+			Dir:     "list-todos",
+			Files:   []File{{Path: "index.js", Content: "v1"}, {Path: "old.js", Content: "old"}},
+			Message: "Generate code for GET /todos",
+			Time:    start,
+		},
+		{
+			Dir:     "create-todo",
+			Files:   []File{{Path: "index.js", Content: "create"}},
+			Message: "Generate code for POST /todos",
+			Time:    start.Add(time.Minute),
+		},
+		{
+			Dir:     "list-todos",
+			Files:   []File{{Path: "index.js", Content: "v2"}},
+			Message: "Edit index.js",
+			Time:    start.Add(2 * time.Minute),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		remote  func(dir string) Remote
+		commits []Commit
+		// pushes are made in order before the commits are pushed
+		before  [][]Commit
+		wantErr error
+	}{
+		{
+			name:    "push to an empty repository",
+			remote:  func(dir string) Remote { return Remote{URL: dir} },
+			commits: commits,
+		},
+		{
+			name:    "push the same history again",
+			remote:  func(dir string) Remote { return Remote{URL: dir, Branch: "generated"} },
+			commits: commits,
+			before:  [][]Commit{commits},
+		},
+		{
+			name:    "fast-forward a shorter history",
+			remote:  func(dir string) Remote { return Remote{URL: "file://" + dir} },
+			commits: commits,
+			before:  [][]Commit{commits[:1]},
+		},
+		{
+			name:   "refuse a diverged branch",
+			remote: func(dir string) Remote { return Remote{URL: dir} },
+			commits: []Commit{
+				{Dir: "list-todos", Files: []File{{Path: "index.js", Content: "other"}}, Message: "Other", Time: start},
+			},
+			before:  [][]Commit{commits},
+			wantErr: ErrDiverged,
+		},
+		{
+			name:    "refuse an unsupported protocol",
+			remote:  func(dir string) Remote { return Remote{URL: "ftp://example.com/repo.git"} },
+			commits: commits,
+			wantErr: ErrInvalidRemote,
+		},
+		{
+			name:    "refuse an ssh remote without a key",
+			remote:  func(dir string) Remote { return Remote{URL: "git@github.com:b0/todo.git"} },
+			commits: commits,
+			wantErr: ErrInvalidRemote,
+		},
+		{
+			name:    "refuse no commits",
+			remote:  func(dir string) Remote { return Remote{URL: dir} },
+			wantErr: ErrNoCommits,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			bare, err := git.PlainInit(dir, true)
+			require.NoError(t, err)
+
+			remote := tt.remote(dir)
+
+			for _, before := range tt.before {
+				_, err := Push(ctx, remote, author, before)
+				require.NoError(t, err)
+			}
+
+			hashes, err := Push(ctx, remote, author, tt.commits)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, hashes, len(tt.commits))
+
+			ref, err := bare.Reference(plumbing.NewBranchReferenceName(remote.BranchOrDefault()), true)
+			require.NoError(t, err)
+			require.Equal(t, hashes[len(hashes)-1], ref.Hash().String())
+
+			head, err := bare.CommitObject(ref.Hash())
+			require.NoError(t, err)
+			require.Equal(t, "Edit index.js", head.Message)
+			require.Equal(t, author.Email, head.Author.Email)
+			require.True(t, start.Add(2*time.Minute).Equal(head.Author.When))
+
+			require.Equal(t, map[string]string{
+				"list-todos/index.js":  "v2",
+				"create-todo/index.js": "create",
+			}, treeFiles(t, head))
+
+			log, err := bare.Log(&git.LogOptions{From: ref.Hash()})
+			require.NoError(t, err)
+
+			var logged []string
+			require.NoError(t, log.ForEach(func(c *object.Commit) error {
+				logged = append([]string{c.Hash.String()}, logged...)
+				return nil
+			}))
+			require.Equal(t, hashes, logged)
+		})
+	}
+}
+
+func TestRemote_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  Remote
+		wantErr bool
+	}{
+		{
+			name:   "https with a token",
+			remote: Remote{URL: "https://github.com/b0/todo.git", Password: "token"},
+		},
+		{
+			name:   "http without credentials",
+			remote: Remote{URL: "http://git.example.com/b0/todo.git"},
+		},
+		{
+			name:    "http with a token",
+			remote:  Remote{URL: "http://git.example.com/b0/todo.git", Password: "token"},
+			wantErr: true,
+		},
+		{
+			name:    "local path",
+			remote:  Remote{URL: "/var/lib/b0"},
+			wantErr: true,
+		},
+		{
+			name:    "file url",
+			remote:  Remote{URL: "file:///var/lib/b0"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid branch",
+			remote:  Remote{URL: "https://github.com/b0/todo.git", Branch: "feature branch"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.remote.Validate()
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidRemote)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func treeFiles(t *testing.T, commit *object.Commit) map[string]string {
+	t.Helper()
+
+	tree, err := commit.Tree()
+	require.NoError(t, err)
+
+	files := map[string]string{}
+
+	require.NoError(t, tree.Files().ForEach(func(f *object.File) error {
+		reader, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		files[f.Name] = string(content)
+		return nil
+	}))
+
+	return files
+}
@@ -0,0 +1,499 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+)
+
+const contentTypeJSON = "application/json"
+
+// headers documented by the security scheme or the media types instead of parameters
+var reservedHeaders = map[string]bool{
+	"accept":        true,
+	"authorization": true,
+	"content-type":  true,
+}
+
+// Endpoint is an endpoint to document, its request and responses are inferred from the workflows
+type Endpoint struct {
+	Name        string
+	Path        string
+	Method      string
+	Description string
+	IsPublic    bool
+	Workflows   []*agent.Workflow
+}
+
+// Build generates the OpenAPI document of the endpoints
+func Build(info Info, serverURL string, endpoints []Endpoint) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+	}
+
+	if doc.Info.Version == "" {
+		doc.Info.Version = "1.0.0"
+	}
+
+	if serverURL != "" {
+		doc.Servers = []Server{{URL: serverURL}}
+	}
+
+	operationIDs := map[string]int{}
+
+	for _, endpoint := range endpoints {
+		path, pathParams := convertPath(endpoint.Path)
+
+		operation, err := buildOperation(endpoint, pathParams)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to document %s %s: %w", endpoint.Method, endpoint.Path, err)
+		}
+
+		operation.OperationID = uniqueOperationID(operationIDs, endpoint)
+
+		if !endpoint.IsPublic {
+			operation.Security = []SecurityRequirement{{BearerAuth: []string{}}}
+
+			if _, ok := operation.Responses["401"]; !ok {
+				operation.Responses["401"] = &Response{Description: http.StatusText(http.StatusUnauthorized)}
+			}
+
+			doc.Components = &Components{SecuritySchemes: map[string]*SecurityScheme{
+				BearerAuth: {Type: "http", Scheme: "bearer"},
+			}}
+		}
+
+		item, ok := doc.Paths[path]
+
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		if !item.SetOperation(endpoint.Method, operation) {
+			return nil, fmt.Errorf("unsupported method %s for %s", endpoint.Method, endpoint.Path)
+		}
+	}
+
+	return doc, nil
+}
+
+// convertPath converts /todos/:id to /todos/{id} and returns the path parameters in order
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	params := []string{}
+
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			params = append(params, segment[1:len(segment)-1])
+		}
+	}
+
+	return "/" + strings.Join(segments, "/"), params
+}
+
+// uniqueOperationID returns the endpoint name in camel case e.g listTodos, suffixed when already used
+func uniqueOperationID(used map[string]int, endpoint Endpoint) string {
+	name := endpoint.Name
+
+	if strings.TrimSpace(name) == "" {
+		name = endpoint.Method + " " + strings.NewReplacer("/", " ", ":", " ", "{", " ", "}", " ").Replace(endpoint.Path)
+	}
+
+	var id strings.Builder
+
+	for i, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		word = strings.ToLower(word)
+
+		if i > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+
+		id.WriteString(word)
+	}
+
+	used[id.String()]++
+
+	if count := used[id.String()]; count > 1 {
+		return fmt.Sprintf("%s%d", id.String(), count)
+	}
+
+	return id.String()
+}
+
+// operationBuilder collects what the workflow tree tells about the request and the responses
+type operationBuilder struct {
+	request   map[string]interface{}
+	paths     [][]string
+	responses map[string]*Response
+}
+
+func buildOperation(endpoint Endpoint, pathParams []string) (*Operation, error) {
+	raw, err := json.Marshal(endpoint.Workflows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var workflows interface{}
+
+	if err := json.Unmarshal(raw, &workflows); err != nil {
+		return nil, err
+	}
+
+	b := &operationBuilder{responses: map[string]*Response{}}
+	b.walk(workflows)
+
+	operation := &Operation{
+		Summary:     endpoint.Name,
+		Description: endpoint.Description,
+		Responses:   b.responses,
+	}
+
+	for _, name := range pathParams {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	operation.Parameters = append(operation.Parameters, b.parameters("query", "query")...)
+	operation.Parameters = append(operation.Parameters, b.parameters("headers", "header")...)
+
+	operation.RequestBody = b.requestBody(endpoint.Method)
+
+	if len(operation.Responses) == 0 {
+		operation.Responses["204"] = &Response{Description: http.StatusText(http.StatusNoContent)}
+	}
+
+	return operation, nil
+}
+
+func (b *operationBuilder) walk(value interface{}) {
+	switch value := value.(type) {
+	case string:
+		template, err := agent.ParseTemplate(value)
+
+		if err != nil {
+			return
+		}
+
+		for _, expression := range template.Expressions() {
+			b.paths = append(b.paths, expression.Paths()...)
+		}
+	case []interface{}:
+		for _, item := range value {
+			b.walk(item)
+		}
+	case map[string]interface{}:
+		switch nodeType, _ := value["type"].(string); agent.WorkflowType(nodeType) {
+		case agent.WorkflowTypeRequest:
+			if b.request == nil {
+				b.request = value
+			}
+		case agent.WorkflowTypeResponse:
+			b.addResponse(value, http.StatusOK)
+		case agent.WorkflowTypeError:
+			b.addResponse(value, http.StatusInternalServerError)
+		}
+
+		keys := make([]string, 0, len(value))
+
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		// sorted to keep the order of combined responses stable
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field := value[key]
+
+			if condition, ok := field.(string); ok && key == "condition" {
+				if expression, err := agent.ParseExpression(condition); err == nil {
+					b.paths = append(b.paths, expression.Paths()...)
+				}
+
+				continue
+			}
+
+			b.walk(field)
+		}
+	}
+}
+
+// addResponse documents a response or error node, nodes answering the same status are combined with oneOf
+func (b *operationBuilder) addResponse(node map[string]interface{}, defaultStatus int) {
+	status := strconv.Itoa(defaultStatus)
+
+	if code, _ := node["status"].(string); len(code) == 3 {
+		if _, err := strconv.Atoi(code); err == nil {
+			status = code
+		}
+	}
+
+	var schema *Schema
+
+	if body, ok := node["body"]; ok && body != nil {
+		schema = schemaOf(body)
+	} else if agent.WorkflowType(fmt.Sprint(node["type"])) == agent.WorkflowTypeError {
+		schema = &Schema{Type: "object", Properties: map[string]*Schema{"error": {Type: "string"}}}
+	}
+
+	response, ok := b.responses[status]
+
+	if !ok {
+		code, _ := strconv.Atoi(status)
+		description := http.StatusText(code)
+
+		if description == "" {
+			description = "Response"
+		}
+
+		response = &Response{Description: description}
+		b.responses[status] = response
+	}
+
+	for _, line := range headerLines(node["headers"]) {
+		name, _, ok := strings.Cut(line, ":")
+
+		if name = strings.TrimSpace(name); !ok || reservedHeaders[strings.ToLower(name)] {
+			continue
+		}
+
+		if response.Headers == nil {
+			response.Headers = map[string]*Header{}
+		}
+
+		response.Headers[name] = &Header{Schema: &Schema{Type: "string"}}
+	}
+
+	if schema == nil {
+		return
+	}
+
+	if response.Content == nil {
+		response.Content = map[string]*MediaType{contentTypeJSON: {Schema: schema}}
+		return
+	}
+
+	media := response.Content[contentTypeJSON]
+
+	if reflect.DeepEqual(media.Schema, schema) {
+		return
+	}
+
+	if media.Schema.OneOf == nil {
+		media.Schema = &Schema{OneOf: []*Schema{media.Schema}}
+	}
+
+	for _, existing := range media.Schema.OneOf {
+		if reflect.DeepEqual(existing, schema) {
+			return
+		}
+	}
+
+	media.Schema.OneOf = append(media.Schema.OneOf, schema)
+}
+
+// parameters returns the request values read from context.request.<source>.<name> as parameters
+func (b *operationBuilder) parameters(source, in string) []*Parameter {
+	names := map[string]bool{}
+
+	for _, path := range b.paths {
+		if len(path) >= 3 && path[0] == "request" && path[1] == source && !reservedHeaders[strings.ToLower(path[2])] {
+			names[path[2]] = true
+		}
+	}
+
+	parameters := make([]*Parameter, 0, len(names))
+
+	for name := range names {
+		parameters = append(parameters, &Parameter{Name: name, In: in, Schema: &Schema{Type: "string"}})
+	}
+
+	sort.Slice(parameters, func(i, j int) bool {
+		return parameters[i].Name < parameters[j].Name
+	})
+
+	return parameters
+}
+
+// requestBody builds the body schema from the request node body and every context.request.body.* read
+func (b *operationBuilder) requestBody(method string) *RequestBody {
+	var schema *Schema
+
+	if b.request != nil {
+		switch body := b.request["body"].(type) {
+		case map[string]interface{}:
+			schema = schemaOf(body)
+		case string:
+			// a plain string describes the body
+			if body != "" && !strings.Contains(body, "{{") {
+				schema = &Schema{Type: "object", Description: body}
+			}
+		}
+	}
+
+	readsBody := false
+
+	for _, path := range b.paths {
+		if len(path) < 2 || path[0] != "request" || path[1] != "body" {
+			continue
+		}
+
+		readsBody = true
+
+		if schema == nil {
+			schema = &Schema{Type: "object"}
+		}
+
+		addProperty(schema, path[2:])
+	}
+
+	if schema == nil {
+		switch strings.ToUpper(method) {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			schema = &Schema{Type: "object"}
+		default:
+			return nil
+		}
+	}
+
+	return &RequestBody{
+		Required: readsBody,
+		Content:  map[string]*MediaType{contentTypeJSON: {Schema: schema}},
+	}
+}
+
+// addProperty adds the nested property of the path to the schema, a numeric segment reads an array item
+func addProperty(schema *Schema, path []string) {
+	for _, segment := range path {
+		if _, err := strconv.Atoi(segment); err == nil {
+			if schema.Type == "" || schema.Type == "object" && len(schema.Properties) == 0 {
+				schema.Type = "array"
+			}
+
+			if schema.Type != "array" {
+				return
+			}
+
+			if schema.Items == nil {
+				schema.Items = &Schema{}
+			}
+
+			schema = schema.Items
+			continue
+		}
+
+		if schema.Type == "" {
+			schema.Type = "object"
+		}
+
+		if schema.Type != "object" {
+			return
+		}
+
+		if schema.Properties == nil {
+			schema.Properties = map[string]*Schema{}
+		}
+
+		property, ok := schema.Properties[segment]
+
+		if !ok {
+			property = &Schema{}
+			schema.Properties[segment] = property
+		}
+
+		schema = property
+	}
+}
+
+// schemaOf infers the schema of a node value, a single {{...}} is typed from its expression
+func schemaOf(value interface{}) *Schema {
+	switch value := value.(type) {
+	case nil:
+		return &Schema{Type: "null"}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case float64:
+		if value == math.Trunc(value) {
+			return &Schema{Type: "integer"}
+		}
+
+		return &Schema{Type: "number"}
+	case string:
+		template, err := agent.ParseTemplate(value)
+
+		if err != nil {
+			return &Schema{Type: "string"}
+		}
+
+		expressions := template.Expressions()
+
+		if len(expressions) != 1 || !isWholeTemplate(value) {
+			return &Schema{Type: "string"}
+		}
+
+		if expressionType := expressions[0].Type(); expressionType != agent.ExpressionTypeAny {
+			return &Schema{Type: string(expressionType)}
+		}
+
+		return &Schema{}
+	case []interface{}:
+		schema := &Schema{Type: "array"}
+
+		if len(value) > 0 {
+			schema.Items = schemaOf(value[0])
+		}
+
+		return schema
+	case map[string]interface{}:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+		for key, item := range value {
+			schema.Properties[key] = schemaOf(item)
+		}
+
+		return schema
+	default:
+		return &Schema{}
+	}
+}
+
+// isWholeTemplate reports whether the string is a single {{...}}, surrounding spaces aside
+func isWholeTemplate(value string) bool {
+	value = strings.TrimSpace(value)
+
+	return strings.HasPrefix(value, "{{") && strings.HasSuffix(value, "}}") && strings.Count(value, "{{") == 1
+}
+
+func headerLines(value interface{}) []string {
+	items, _ := value.([]interface{})
+	lines := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if line, ok := item.(string); ok {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,132 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Build(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoint  Endpoint
+		workflows string
+		wantPaths string
+	}{
+		{
+			name:     "request_body_and_responses",
+			endpoint: Endpoint{Name: "Create todo", Path: "/todos", Method: "POST", IsPublic: true},
+			workflows: `[
+				{"type": "request", "method": "POST", "url": "/todos", "body": {"title": "string", "done": false}},
+				{"type": "if", "condition": "empty(context.request.body.title) || len(context.request.body.tags) > 5", "then": [
+					{"type": "error", "status": "400", "instruction": "title is required"}
+				], "else": [
+					{"type": "response", "status": "201", "headers": ["X-Todo-Title: {{context.request.body.title}}"], "body": {
+						"title": "{{context.request.body.title}}", "count": "{{len(context.request.body.tags)}}", "owner": "{{context.request.body.owner.name}}"
+					}}
+				]}
+			]`,
+			wantPaths: `{"/todos": {"post": {
+				"operationId": "createTodo",
+				"summary": "Create todo",
+				"requestBody": {"required": true, "content": {"application/json": {"schema": {"type": "object", "properties": {
+					"done": {"type": "boolean"},
+					"owner": {"type": "object", "properties": {"name": {}}},
+					"tags": {},
+					"title": {"type": "string"}
+				}}}}},
+				"responses": {
+					"201": {"description": "Created", "headers": {"X-Todo-Title": {"schema": {"type": "string"}}}, "content": {"application/json": {"schema": {"type": "object", "properties": {
+						"count": {"type": "number"}, "owner": {}, "title": {}
+					}}}}},
+					"400": {"description": "Bad Request", "content": {"application/json": {"schema": {"type": "object", "properties": {"error": {"type": "string"}}}}}}
+				}
+			}}}`,
+		},
+		{
+			name:     "private_endpoint_with_parameters",
+			endpoint: Endpoint{Name: "Get todo", Path: "/users/:user_id/todos/{id}", Method: "GET", Description: "Returns a todo"},
+			workflows: `[
+				{"type": "request", "method": "GET", "url": "/users/:user_id/todos/:id"},
+				{"type": "switch", "condition": "context.request.query.format", "cases": [
+					{"value": "short", "body": [{"type": "response", "status": "200", "body": {"id": "{{context.request.params.id}}"}}]},
+					{"value": "default", "body": [{"type": "response", "status": "200", "body": {"id": "{{context.request.params.id}}", "lang": "{{context.request.headers['accept-language']}}"}}]}
+				]}
+			]`,
+			wantPaths: `{"/users/{user_id}/todos/{id}": {"get": {
+				"operationId": "getTodo",
+				"summary": "Get todo",
+				"description": "Returns a todo",
+				"parameters": [
+					{"name": "user_id", "in": "path", "required": true, "schema": {"type": "string"}},
+					{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}},
+					{"name": "format", "in": "query", "schema": {"type": "string"}},
+					{"name": "accept-language", "in": "header", "schema": {"type": "string"}}
+				],
+				"responses": {
+					"200": {"description": "OK", "content": {"application/json": {"schema": {"oneOf": [
+						{"type": "object", "properties": {"id": {}}},
+						{"type": "object", "properties": {"id": {}, "lang": {}}}
+					]}}}},
+					"401": {"description": "Unauthorized"}
+				},
+				"security": [{"bearerAuth": []}]
+			}}}`,
+		},
+		{
+			name:      "no_response",
+			endpoint:  Endpoint{Name: "Delete todo", Path: "/todos/:id", Method: "DELETE", IsPublic: true},
+			workflows: `[{"type": "request", "method": "DELETE", "url": "/todos/:id"}]`,
+			wantPaths: `{"/todos/{id}": {"delete": {
+				"operationId": "deleteTodo",
+				"summary": "Delete todo",
+				"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+				"responses": {"204": {"description": "No Content"}}
+			}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, json.Unmarshal([]byte(tt.workflows), &tt.endpoint.Workflows))
+
+			doc, err := Build(Info{Title: "Todo"}, "", []Endpoint{tt.endpoint})
+			require.NoError(t, err)
+
+			require.Equal(t, Version, doc.OpenAPI)
+			require.Equal(t, "1.0.0", doc.Info.Version)
+
+			paths, err := json.Marshal(doc.Paths)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.wantPaths, string(paths))
+
+			if tt.endpoint.IsPublic {
+				require.Nil(t, doc.Components)
+			} else {
+				require.Equal(t, &SecurityScheme{Type: "http", Scheme: "bearer"}, doc.Components.SecuritySchemes[BearerAuth])
+			}
+		})
+	}
+}
+
+func Test_Build_SharedPath(t *testing.T) {
+	workflows := []*agent.Workflow{{Type: agent.WorkflowTypeRequest, Url: "/todos"}}
+
+	doc, err := Build(Info{Title: "Todo", Version: "2.0.0"}, "https://todo.b0.dev", []Endpoint{
+		{Name: "Todos", Path: "/todos", Method: "GET", IsPublic: true, Workflows: workflows},
+		{Name: "Todos", Path: "/todos/", Method: "POST", IsPublic: true, Workflows: workflows},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []Server{{URL: "https://todo.b0.dev"}}, doc.Servers)
+	require.Equal(t, "2.0.0", doc.Info.Version)
+	require.Len(t, doc.Paths, 1)
+	require.Equal(t, "todos", doc.Paths["/todos"].Get.OperationID)
+	require.Equal(t, "todos2", doc.Paths["/todos"].Post.OperationID)
+	require.Equal(t, &RequestBody{Content: map[string]*MediaType{contentTypeJSON: {Schema: &Schema{Type: "object"}}}}, doc.Paths["/todos"].Post.RequestBody)
+
+	_, err = Build(Info{Title: "Todo"}, "", []Endpoint{{Path: "/todos", Method: "OPTIONS"}})
+	require.EqualError(t, err, "unsupported method OPTIONS for /todos")
+}
@@ -0,0 +1,321 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"gopkg.in/yaml.v3"
+)
+
+// Parse reads an OpenAPI 3.x document, raw is YAML or JSON since JSON is valid YAML
+func Parse(raw []byte) (*Document, error) {
+	var value interface{}
+
+	if err := yaml.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	if _, ok := value.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("invalid OpenAPI document: expected an object")
+	}
+
+	normalized, err := json.Marshal(normalizeYAML(value))
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	doc := new(Document)
+
+	if err := json.Unmarshal(normalized, doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, only 3.x documents can be imported", doc.OpenAPI)
+	}
+
+	return doc, nil
+}
+
+// normalizeYAML converts the YAML maps to JSON objects, non string keys e.g 200 become strings
+// and the 3.1 type arrays e.g ["string", "null"] become their first non null type
+func normalizeYAML(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			value[key] = normalizeYAML(field)
+
+			if types, ok := field.([]interface{}); ok && key == "type" {
+				value[key] = firstType(types)
+			}
+		}
+
+		return value
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(value))
+
+		for key, field := range value {
+			object[fmt.Sprint(key)] = field
+		}
+
+		return normalizeYAML(object)
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalizeYAML(item)
+		}
+
+		return value
+	default:
+		return value
+	}
+}
+
+func firstType(types []interface{}) string {
+	for _, t := range types {
+		if name, ok := t.(string); ok && name != "null" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// Import converts every operation of the document to an endpoint with a skeleton workflow,
+// a request node followed by a response node per documented status
+func Import(doc *Document) []Endpoint {
+	paths := make([]string, 0, len(doc.Paths))
+
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	endpoints := []Endpoint{}
+
+	for _, path := range paths {
+		item := doc.Paths[path]
+
+		if item == nil {
+			continue
+		}
+
+		operations := item.Operations()
+
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			operation, ok := operations[method]
+
+			if !ok {
+				continue
+			}
+
+			endpoints = append(endpoints, importOperation(doc, ImportPath(path), method, operation))
+		}
+	}
+
+	return endpoints
+}
+
+// ImportPath converts /todos/{id} to the /todos/:id form endpoints use
+func ImportPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+func importOperation(doc *Document, path, method string, operation *Operation) Endpoint {
+	name := operation.Summary
+
+	if name == "" {
+		name = operation.OperationID
+	}
+
+	if name == "" {
+		name = method + " " + path
+	}
+
+	description := operation.Description
+
+	if description == "" {
+		description = operation.Summary
+	}
+
+	request := &agent.Workflow{
+		ActionID:    "request",
+		Type:        agent.WorkflowTypeRequest,
+		Name:        name,
+		Instruction: description,
+		Method:      method,
+		Url:         path,
+	}
+
+	if operation.RequestBody != nil {
+		request.Body = mediaExample(doc, operation.RequestBody.Content)
+	}
+
+	workflows := []*agent.Workflow{request}
+	responses, statuses := documentedResponses(operation.Responses)
+
+	for _, status := range statuses {
+		response := responses[status]
+
+		workflows = append(workflows, &agent.Workflow{
+			ActionID:    "response_" + status,
+			Type:        agent.WorkflowTypeResponse,
+			Instruction: response.Description,
+			Status:      status,
+			Body:        mediaExample(doc, response.Content),
+		})
+	}
+
+	return Endpoint{
+		Name:        name,
+		Path:        path,
+		Method:      method,
+		Description: description,
+		Workflows:   workflows,
+	}
+}
+
+// documentedResponses returns the responses keyed by http status code and the codes in order,
+// default is documented as 200 when the operation has no success response and ranges e.g 2XX are skipped
+func documentedResponses(responses map[string]*Response) (map[string]*Response, []string) {
+	documented := map[string]*Response{}
+	statuses := []string{}
+	hasSuccess := false
+
+	for status, response := range responses {
+		if code, err := strconv.Atoi(status); err == nil && len(status) == 3 && response != nil {
+			documented[status] = response
+			statuses = append(statuses, status)
+			hasSuccess = hasSuccess || code < 300
+		}
+	}
+
+	if response := responses["default"]; response != nil && !hasSuccess {
+		status := strconv.Itoa(http.StatusOK)
+		documented[status] = response
+		statuses = append(statuses, status)
+	}
+
+	sort.Strings(statuses)
+
+	return documented, statuses
+}
+
+// mediaExample returns an example of the JSON content, the first media type is used when there's no JSON
+func mediaExample(doc *Document, content map[string]*MediaType) interface{} {
+	media, ok := content[contentTypeJSON]
+
+	if !ok {
+		types := make([]string, 0, len(content))
+
+		for contentType := range content {
+			types = append(types, contentType)
+		}
+
+		sort.Strings(types)
+
+		if len(types) == 0 {
+			return nil
+		}
+
+		media = content[types[0]]
+	}
+
+	if media == nil {
+		return nil
+	}
+
+	if media.Example != nil {
+		return media.Example
+	}
+
+	return schemaExample(doc, media.Schema, map[string]bool{})
+}
+
+// schemaExample returns an example value of the schema, references are resolved from the components
+// and a reference to a schema being resolved e.g Todo.parent is null
+func schemaExample(doc *Document, schema *Schema, resolving map[string]bool) interface{} {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		if resolving[schema.Ref] {
+			return nil
+		}
+
+		resolving[schema.Ref] = true
+		defer delete(resolving, schema.Ref)
+
+		return schemaExample(doc, resolveSchema(doc, schema.Ref), resolving)
+	}
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.OneOf) > 0:
+		return schemaExample(doc, schema.OneOf[0], resolving)
+	}
+
+	switch schema.Type {
+	case "string":
+		return ""
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "array":
+		item := schemaExample(doc, schema.Items, resolving)
+
+		if item == nil {
+			return []interface{}{}
+		}
+
+		return []interface{}{item}
+	case "object", "":
+		if len(schema.Properties) == 0 {
+			if schema.Type == "" {
+				return nil
+			}
+
+			return map[string]interface{}{}
+		}
+
+		object := make(map[string]interface{}, len(schema.Properties))
+
+		for name, property := range schema.Properties {
+			object[name] = schemaExample(doc, property, resolving)
+		}
+
+		return object
+	default:
+		return nil
+	}
+}
+
+// resolveSchema returns the component schema of a local reference e.g #/components/schemas/Todo
+func resolveSchema(doc *Document, ref string) *Schema {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+
+	if !ok || doc.Components == nil {
+		return nil
+	}
+
+	return doc.Components.Schemas[name]
+}
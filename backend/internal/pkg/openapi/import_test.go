@@ -0,0 +1,145 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/stretchr/testify/require"
+)
+
+const todoSpecYAML = `
+openapi: 3.0.3
+info:
+  title: Todo
+  version: 1.0.0
+paths:
+  /todos/{id}:
+    get:
+      operationId: getTodo
+      responses:
+        200:
+          description: The todo
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Todo'
+        404:
+          description: Todo not found
+  /todos:
+    post:
+      summary: Create todo
+      description: Create a todo, the title is required
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                title:
+                  type: string
+                tags:
+                  type: array
+                  items:
+                    type: string
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              example: {"id": 1}
+        4XX:
+          description: Invalid todo
+    get:
+      responses:
+        default:
+          description: The todos
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Todo'
+components:
+  schemas:
+    Todo:
+      type: object
+      properties:
+        id:
+          type: [integer, "null"]
+        title:
+          type: string
+          example: Buy milk
+        status:
+          type: string
+          enum: [pending, done]
+        parent:
+          $ref: '#/components/schemas/Todo'
+`
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "yaml", raw: todoSpecYAML},
+		{name: "json", raw: `{"openapi": "3.1.0", "info": {"title": "Todo", "version": "1.0.0"}, "paths": {}}`},
+		{name: "swagger", raw: `{"swagger": "2.0", "paths": {}}`, wantErr: `unsupported OpenAPI version "", only 3.x documents can be imported`},
+		{name: "not_an_object", raw: `- openapi`, wantErr: "invalid OpenAPI document: expected an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.raw))
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, doc.Paths)
+		})
+	}
+}
+
+func Test_Import(t *testing.T) {
+	doc, err := Parse([]byte(todoSpecYAML))
+	require.NoError(t, err)
+
+	endpoints := Import(doc)
+
+	raw, err := json.Marshal(endpoints)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `[
+		{"Name": "GET /todos", "Path": "/todos", "Method": "GET", "Description": "", "IsPublic": false, "Workflows": [
+			{"action_id": "request", "type": "request", "instruction": "", "name": "GET /todos", "method": "GET", "url": "/todos"},
+			{"action_id": "response_200", "type": "response", "instruction": "The todos", "status": "200", "body": [
+				{"id": 0, "title": "Buy milk", "status": "pending", "parent": null}
+			]}
+		]},
+		{"Name": "Create todo", "Path": "/todos", "Method": "POST", "Description": "Create a todo, the title is required", "IsPublic": false, "Workflows": [
+			{"action_id": "request", "type": "request", "instruction": "Create a todo, the title is required", "name": "Create todo", "method": "POST", "url": "/todos",
+				"body": {"title": "", "tags": [""]}},
+			{"action_id": "response_201", "type": "response", "instruction": "Created", "status": "201", "body": {"id": 1}}
+		]},
+		{"Name": "getTodo", "Path": "/todos/:id", "Method": "GET", "Description": "", "IsPublic": false, "Workflows": [
+			{"action_id": "request", "type": "request", "instruction": "", "name": "getTodo", "method": "GET", "url": "/todos/:id"},
+			{"action_id": "response_200", "type": "response", "instruction": "The todo", "status": "200", "body": {
+				"id": 0, "title": "Buy milk", "status": "pending", "parent": null
+			}},
+			{"action_id": "response_404", "type": "response", "instruction": "Todo not found", "status": "404"}
+		]}
+	]`, string(raw))
+
+	for _, endpoint := range endpoints {
+		require.NoError(t, agent.ValidateWorkflows(endpoint.Workflows))
+	}
+}
+
+func Test_ImportPath(t *testing.T) {
+	require.Equal(t, "/users/:user_id/todos/:id", ImportPath("/users/{user_id}/todos/{id}"))
+	require.Equal(t, "/", ImportPath("/"))
+}
@@ -44,6 +44,20 @@ func BadRequest(w http.ResponseWriter, r *http.Request, err error) error {
 	return nil
 }
 
+// ValidationError responds with the error and the data describing every validation failure
+func ValidationError(w http.ResponseWriter, r *http.Request, err error, data interface{}) error {
+	_ = render.Render(w, r, ServerResponse{
+		Response: Response{
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		Message: "Validation Error",
+		Data:    data,
+		Error:   err.Error(),
+	})
+
+	return nil
+}
+
 func InternalServerError(w http.ResponseWriter, r *http.Request, err error) error {
 	_ = render.Render(w, r, ServerResponse{
 		Response: Response{
@@ -68,6 +82,14 @@ func Ok(w http.ResponseWriter, r *http.Request, message string, data interface{}
 	return nil
 }
 
+// JSON responds with the data as is, without the message envelope e.g for documents consumed by other tools
+func JSON(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) error {
+	render.Status(r, statusCode)
+	render.JSON(w, r, data)
+
+	return nil
+}
+
 func Created(w http.ResponseWriter, r *http.Request, message string, data interface{}) error {
 	_ = render.Render(w, r, ServerResponse{
 		Response: Response{
@@ -92,6 +114,42 @@ func Unauthorized(w http.ResponseWriter, r *http.Request, err error) error {
 	return nil
 }
 
+func Forbidden(w http.ResponseWriter, r *http.Request, err error) error {
+	_ = render.Render(w, r, ServerResponse{
+		Response: Response{
+			StatusCode: http.StatusForbidden,
+		},
+		Message: "Forbidden",
+		Error:   err.Error(),
+	})
+
+	return nil
+}
+
+func NotFound(w http.ResponseWriter, r *http.Request, err error) error {
+	_ = render.Render(w, r, ServerResponse{
+		Response: Response{
+			StatusCode: http.StatusNotFound,
+		},
+		Message: "Not Found",
+		Error:   err.Error(),
+	})
+
+	return nil
+}
+
+func Gone(w http.ResponseWriter, r *http.Request, err error) error {
+	_ = render.Render(w, r, ServerResponse{
+		Response: Response{
+			StatusCode: http.StatusGone,
+		},
+		Message: "Gone",
+		Error:   err.Error(),
+	})
+
+	return nil
+}
+
 func Redirect(w http.ResponseWriter, r *http.Request, u string, status int, ignoreUrl bool) error {
 	if !ignoreUrl {
 		parsedUrl, err := url.ParseRequestURI(u)
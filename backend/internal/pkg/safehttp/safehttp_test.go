@@ -0,0 +1,49 @@
+package safehttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsForbiddenIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.215.14", want: false},
+		{ip: "2606:2800:21f:cb07:6820:80da:af6b:8b2c", want: false},
+		{ip: "127.0.0.1", want: true},
+		{ip: "::1", want: true},
+		{ip: "10.0.0.5", want: true},
+		{ip: "172.16.0.1", want: true},
+		{ip: "192.168.1.1", want: true},
+		{ip: "169.254.169.254", want: true},
+		{ip: "0.0.0.0", want: true},
+		{ip: "fd00::1", want: true},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, IsForbiddenIP(net.ParseIP(tt.ip)), tt.ip)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	// the test server listens on a loopback address
+	_, err = NewClient(time.Second).Do(req)
+
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+}
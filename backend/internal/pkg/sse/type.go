@@ -11,6 +11,9 @@ var (
 	FailedToPublishTaskCompletedEvent = "failed to publish task completed event"
 	FailedToPublishTaskFailedEvent    = "failed to publish task failed event"
 	FailedToPublishTaskStartedEvent   = "failed to publish task started event"
+	FailedToPublishAgentDeltaEvent    = "failed to publish agent delta event"
+	FailedToPublishTestEvent          = "failed to publish test event"
+	FailedToPublishExportEvent        = "failed to publish export event"
 )
 
 const (
@@ -19,10 +22,23 @@ const (
 	EventTypeTaskCompleted EventType = "task_completed"
 	EventTypeTaskFailed    EventType = "task_failed"
 
+	// EventTypeAgentDelta carries partial model output while a completion is streamed
+	EventTypeAgentDelta EventType = "agent_delta"
+
 	EventTypeLogStarted   EventType = "log_started"
 	EventTypeLogUpdated   EventType = "log_updated"
 	EventTypeLogFailed    EventType = "log_failed"
 	EventTypeLogCompleted EventType = "log_completed"
+
+	// the test events report the HTTP test suite run against a deployed project, one passed or failed event per case
+	EventTypeTestStarted   EventType = "test_started"
+	EventTypeTestPassed    EventType = "test_passed"
+	EventTypeTestFailed    EventType = "test_failed"
+	EventTypeTestCompleted EventType = "test_completed"
+	EventTypeTestSkipped   EventType = "test_skipped"
+
+	// EventTypeProjectExported carries the signed download link of the project archive
+	EventTypeProjectExported EventType = "project_exported"
 )
 
 type UploadProgressStatus string
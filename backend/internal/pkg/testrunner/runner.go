@@ -0,0 +1,198 @@
+package testrunner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+)
+
+const (
+	defaultRequestTimeout = 10 * time.Second
+	defaultPollInterval   = 2 * time.Second
+)
+
+// Result is the outcome of a test case, Failures lists why it failed
+type Result struct {
+	Name       string   `json:"name"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Passed     bool     `json:"passed"`
+	Status     int      `json:"status,omitempty"`
+	Failures   []string `json:"failures,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// Runner executes the HTTP test cases of generated code against a deployed server
+type Runner struct {
+	baseURL      string
+	client       *http.Client
+	pollInterval time.Duration
+}
+
+type OptionFunc func(*Runner)
+
+// WithHTTPClient sets the client the requests are sent with
+func WithHTTPClient(client *http.Client) OptionFunc {
+	return func(r *Runner) {
+		r.client = client
+	}
+}
+
+// WithPollInterval sets how often WaitReady checks the server
+func WithPollInterval(interval time.Duration) OptionFunc {
+	return func(r *Runner) {
+		if interval > 0 {
+			r.pollInterval = interval
+		}
+	}
+}
+
+func New(baseURL string, opts ...OptionFunc) *Runner {
+	r := &Runner{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		client:       &http.Client{Timeout: defaultRequestTimeout},
+		pollInterval: defaultPollInterval,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// WaitReady waits until the server answers a request, with any status, or the context is done.
+// The container installs and builds the code before it serves so this can take minutes.
+func (r *Runner) WaitReady(ctx context.Context) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/", nil)
+
+		if err != nil {
+			return err
+		}
+
+		res, err := r.client.Do(req)
+
+		if err == nil {
+			res.Body.Close()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the server at %s is not ready: %w", r.baseURL, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run executes the test cases in order, onResult is called after each one
+func (r *Runner) Run(ctx context.Context, cases []agent.TestCase, onResult func(Result)) []Result {
+	results := make([]Result, 0, len(cases))
+
+	for _, testCase := range cases {
+		result := r.runCase(ctx, testCase)
+
+		if onResult != nil {
+			onResult(result)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (r *Runner) runCase(ctx context.Context, testCase agent.TestCase) Result {
+	start := time.Now()
+
+	result := Result{
+		Name:   testCase.Name,
+		Method: strings.ToUpper(testCase.Method),
+		Path:   testCase.Path,
+	}
+
+	result.Status, result.Failures = r.check(ctx, testCase)
+	result.Passed = len(result.Failures) == 0
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	return result
+}
+
+func (r *Runner) check(ctx context.Context, testCase agent.TestCase) (int, []string) {
+	var body io.Reader
+
+	if strings.TrimSpace(testCase.Body) != "" {
+		body = strings.NewReader(testCase.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(testCase.Method), r.baseURL+"/"+strings.TrimPrefix(testCase.Path, "/"), body)
+
+	if err != nil {
+		return 0, []string{fmt.Sprintf("invalid request: %v", err)}
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for _, header := range testCase.Headers {
+		req.Header.Set(header.Key, header.Value)
+	}
+
+	res, err := r.client.Do(req)
+
+	if err != nil {
+		return 0, []string{fmt.Sprintf("request failed: %v", err)}
+	}
+
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return res.StatusCode, []string{fmt.Sprintf("failed to read the response body: %v", err)}
+	}
+
+	failures := []string{}
+
+	if testCase.ExpectedStatus != 0 && res.StatusCode != testCase.ExpectedStatus {
+		failures = append(failures, fmt.Sprintf("expected status %d, got %d", testCase.ExpectedStatus, res.StatusCode))
+	}
+
+	if strings.TrimSpace(testCase.ExpectedBody) == "" {
+		return res.StatusCode, failures
+	}
+
+	var expected interface{}
+
+	if err := json.Unmarshal([]byte(testCase.ExpectedBody), &expected); err != nil {
+		return res.StatusCode, append(failures, fmt.Sprintf("invalid expected body: %v", err))
+	}
+
+	var actual interface{}
+
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &actual); err != nil {
+		return res.StatusCode, append(failures, fmt.Sprintf("expected a JSON body, got %q", truncate(string(raw), 200)))
+	}
+
+	return res.StatusCode, append(failures, MatchShape("$", expected, actual)...)
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+
+	return s[:limit] + "..."
+}
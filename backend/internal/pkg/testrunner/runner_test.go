@@ -0,0 +1,167 @@
+package testrunner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MatchShape(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		want     []string
+	}{
+		{
+			name:     "same_shape_different_values",
+			expected: `{"todo": {"id": 1, "title": "Buy milk", "done": false, "tags": ["home"]}}`,
+			actual:   `{"todo": {"id": 7, "title": "Walk", "done": true, "tags": ["a", "b"], "extra": null}}`,
+			want:     []string{},
+		},
+		{
+			name:     "missing_key_and_wrong_type",
+			expected: `{"id": 1, "title": "Buy milk"}`,
+			actual:   `{"id": "1"}`,
+			want:     []string{`$.id: expected number, got string`, `$: missing key "title"`},
+		},
+		{
+			name:     "array_items_match_the_first_example",
+			expected: `[{"id": 1}]`,
+			actual:   `[{"id": 1}, {"name": "x"}]`,
+			want:     []string{`$[1]: missing key "id"`},
+		},
+		{
+			name:     "empty_array_and_null_match_anything",
+			expected: `{"todos": [], "meta": null}`,
+			actual:   `{"todos": [1, "a"], "meta": {"page": 1}}`,
+			want:     []string{},
+		},
+		{
+			name:     "different_root_type",
+			expected: `{"todos": []}`,
+			actual:   `[]`,
+			want:     []string{`$: expected object, got array`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var expected, actual interface{}
+
+			require.NoError(t, json.Unmarshal([]byte(tt.expected), &expected))
+			require.NoError(t, json.Unmarshal([]byte(tt.actual), &actual))
+
+			got := MatchShape("$", expected, actual)
+
+			if len(tt.want) == 0 {
+				require.Empty(t, got)
+				return
+			}
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/todos":
+			_, _ = w.Write([]byte(`{"todos": [{"id": 1, "title": "Buy milk"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/todos":
+			body, _ := io.ReadAll(r.Body)
+
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error": "unauthorized"}`))
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`not found`))
+		}
+	}))
+	defer server.Close()
+
+	cases := []agent.TestCase{
+		{
+			Name:           "lists the todos",
+			Method:         "get",
+			Path:           "/todos",
+			ExpectedStatus: 200,
+			ExpectedBody:   `{"todos": [{"id": 1, "title": "Buy milk"}]}`,
+		},
+		{
+			Name:           "creates a todo",
+			Method:         "POST",
+			Path:           "/todos",
+			Headers:        []agent.TestHeader{{Key: "Authorization", Value: "Bearer token"}},
+			Body:           `{"title": "Walk"}`,
+			ExpectedStatus: 201,
+			ExpectedBody:   `{"title": "Walk"}`,
+		},
+		{
+			Name:           "rejects an anonymous todo",
+			Method:         "POST",
+			Path:           "/todos",
+			Body:           `{"title": "Walk"}`,
+			ExpectedStatus: 401,
+			ExpectedBody:   `{"message": "unauthorized"}`,
+		},
+		{
+			Name:           "gets a todo",
+			Method:         "GET",
+			Path:           "/todos/1",
+			ExpectedStatus: 200,
+			ExpectedBody:   `{"todo": {}}`,
+		},
+	}
+
+	runner := New(server.URL)
+
+	require.NoError(t, runner.WaitReady(context.Background()))
+
+	reported := []string{}
+
+	results := runner.Run(context.Background(), cases, func(result Result) {
+		reported = append(reported, result.Name)
+	})
+
+	require.Equal(t, []string{"lists the todos", "creates a todo", "rejects an anonymous todo", "gets a todo"}, reported)
+
+	require.True(t, results[0].Passed)
+	require.Equal(t, "GET", results[0].Method)
+	require.True(t, results[1].Passed)
+
+	require.False(t, results[2].Passed)
+	require.Equal(t, 401, results[2].Status)
+	require.Equal(t, []string{`$: missing key "message"`}, results[2].Failures)
+
+	require.False(t, results[3].Passed)
+	require.Equal(t, []string{"expected status 200, got 404", `expected a JSON body, got "not found"`}, results[3].Failures)
+}
+
+func TestRunner_WaitReady(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := New(url, WithPollInterval(10*time.Millisecond)).WaitReady(ctx)
+	require.Error(t, err)
+}
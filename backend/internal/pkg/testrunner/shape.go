@@ -0,0 +1,74 @@
+package testrunner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MatchShape returns where the actual JSON value doesn't have the shape of the expected example.
+// Objects must have the keys of the example, extra keys are allowed; every item of an array must have
+// the shape of the first item of the example; the other values must have the same JSON type. A null
+// in the example matches any value.
+func MatchShape(path string, expected, actual interface{}) []string {
+	if expected == nil {
+		return nil
+	}
+
+	if jsonType(expected) != jsonType(actual) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, jsonType(expected), jsonType(actual))}
+	}
+
+	mismatches := []string{}
+
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		object := actual.(map[string]interface{})
+		keys := make([]string, 0, len(expected))
+
+		for key := range expected {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, ok := object[key]
+
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("%s: missing key %q", path, key))
+				continue
+			}
+
+			mismatches = append(mismatches, MatchShape(path+"."+key, expected[key], value)...)
+		}
+	case []interface{}:
+		if len(expected) == 0 {
+			return nil
+		}
+
+		for i, item := range actual.([]interface{}) {
+			mismatches = append(mismatches, MatchShape(fmt.Sprintf("%s[%d]", path, i), expected[0], item)...)
+		}
+	}
+
+	return mismatches
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
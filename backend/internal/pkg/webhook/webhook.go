@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mujhtech/b0/internal/pkg/safehttp"
+)
+
+type EventType string
+
+const (
+	EventWorkflowGenerated EventType = "workflow.generated"
+	EventCodeGenerated     EventType = "code.generated"
+	EventDeployStarted     EventType = "deploy.started"
+	EventDeploySucceeded   EventType = "deploy.succeeded"
+	EventDeployFailed      EventType = "deploy.failed"
+	EventContainerCrashed  EventType = "container.crashed"
+)
+
+// Events are the project events a webhook can subscribe to
+var Events = []EventType{
+	EventWorkflowGenerated,
+	EventCodeGenerated,
+	EventDeployStarted,
+	EventDeploySucceeded,
+	EventDeployFailed,
+	EventContainerCrashed,
+}
+
+const (
+	HeaderEvent     = "X-B0-Event"
+	HeaderDelivery  = "X-B0-Delivery"
+	HeaderTimestamp = "X-B0-Timestamp"
+	HeaderSignature = "X-B0-Signature"
+
+	signaturePrefix = "sha256="
+	// maxResponseBody is how much of the response body is kept in the delivery log
+	maxResponseBody = 1024
+)
+
+var (
+	ErrInvalidURL       = errors.New("the webhook url must be an absolute http or https url")
+	ErrForbiddenAddress = safehttp.ErrForbiddenAddress
+	ErrInvalidEvent     = errors.New("unknown webhook event")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// lookupIPAddr resolves the host of a webhook url, the tests replace it to run without a resolver
+	lookupIPAddr = net.DefaultResolver.LookupIPAddr
+)
+
+// IsValidEvent reports whether the event is one of the project events
+func IsValidEvent(event EventType) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateURL checks the url deliveries are posted to, its host must only resolve to public addresses.
+// The host can resolve to another address by the time of a delivery, the client of safehttp.NewClient checks it again.
+func ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	addrs, err := lookupIPAddr(ctx, u.Hostname())
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	for _, addr := range addrs {
+		if safehttp.IsForbiddenIP(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+
+	return nil
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature of a delivery, the HMAC-SHA256 of the timestamp and the body joined by a dot
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery the way a receiver should
+func Verify(secret string, timestamp int64, body []byte, signature string) error {
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Backoff returns the delay before the nth retry, it doubles from base up to max
+func Backoff(n int, base, max time.Duration) time.Duration {
+	delay := base
+
+	for i := 0; i < n && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		return max
+	}
+
+	return delay
+}
+
+// Payload is the body of the deliveries of an event, every webhook receives the same id.
+// A redelivery sends the same payload again, a receiver can skip an event it already handled by its id.
+type Payload struct {
+	ID        string      `json:"id"`
+	Event     EventType   `json:"event"`
+	ProjectID string      `json:"project_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Request is a signed delivery of an event to a webhook
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	Event      EventType
+	Body       []byte
+}
+
+// Response is what the webhook answered, Body is truncated
+type Response struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// OK reports whether the webhook accepted the delivery
+func (r *Response) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Send posts the signed delivery, the response is returned whatever its status code
+func Send(ctx context.Context, client *http.Client, req Request) (*Response, error) {
+	timestamp := time.Now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "b0-webhook")
+	httpReq.Header.Set(HeaderEvent, string(req.Event))
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	start := time.Now()
+
+	httpRes, err := client.Do(httpReq)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+
+	defer httpRes.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(httpRes.Body, maxResponseBody))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook response: %w", err)
+	}
+
+	return &Response{
+		StatusCode: httpRes.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(start),
+	}, nil
+}
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		wantOK   bool
+		wantBody string
+	}{
+		{
+			name:   "accepted delivery",
+			status: http.StatusNoContent,
+			wantOK: true,
+		},
+		{
+			name:     "rejected delivery keeps the start of the body",
+			status:   http.StatusInternalServerError,
+			response: strings.Repeat("a", maxResponseBody+10),
+			wantBody: strings.Repeat("a", maxResponseBody),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{"event":"deploy.succeeded"}`)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, body, received)
+
+				timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+				require.NoError(t, err)
+
+				require.NoError(t, Verify("secret", timestamp, received, r.Header.Get(HeaderSignature)))
+				require.Equal(t, string(EventDeploySucceeded), r.Header.Get(HeaderEvent))
+				require.Equal(t, "delivery-1", r.Header.Get(HeaderDelivery))
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			res, err := Send(context.Background(), server.Client(), Request{
+				URL:        server.URL,
+				Secret:     "secret",
+				DeliveryID: "delivery-1",
+				Event:      EventDeploySucceeded,
+				Body:       body,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.status, res.StatusCode)
+			require.Equal(t, tt.wantOK, res.OK())
+			require.Equal(t, tt.wantBody, res.Body)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"code.generated"}`)
+	signature := Sign("secret", 1700000000, body)
+
+	require.True(t, strings.HasPrefix(signature, "sha256="))
+	require.NoError(t, Verify("secret", 1700000000, body, signature))
+	require.ErrorIs(t, Verify("other", 1700000000, body, signature), ErrInvalidSignature)
+	require.ErrorIs(t, Verify("secret", 1700000001, body, signature), ErrInvalidSignature)
+	require.ErrorIs(t, Verify("secret", 1700000000, []byte(`{}`), signature), ErrInvalidSignature)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{n: 0, want: 30 * time.Second},
+		{n: 1, want: time.Minute},
+		{n: 3, want: 4 * time.Minute},
+		{n: 10, want: time.Hour},
+		{n: 100, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Backoff(tt.n, 30*time.Second, time.Hour), "retry %d", tt.n)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	hosts := map[string]string{
+		"example.com":          "93.184.215.14",
+		"internal.example.com": "10.0.0.5",
+		"localhost":            "127.0.0.1",
+	}
+
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		}
+
+		if ip, ok := hosts[host]; ok {
+			return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+		}
+
+		return nil, errors.New("no such host")
+	}
+	t.Cleanup(func() { lookupIPAddr = net.DefaultResolver.LookupIPAddr })
+
+	ctx := context.Background()
+
+	require.NoError(t, ValidateURL(ctx, "https://example.com/hooks"))
+	require.NoError(t, ValidateURL(ctx, "http://93.184.215.14:8080/hooks"))
+	require.ErrorIs(t, ValidateURL(ctx, "ftp://example.com"), ErrInvalidURL)
+	require.ErrorIs(t, ValidateURL(ctx, "/hooks"), ErrInvalidURL)
+	require.ErrorIs(t, ValidateURL(ctx, "https://"), ErrInvalidURL)
+	require.ErrorIs(t, ValidateURL(ctx, "https://unknown.example.com"), ErrInvalidURL)
+	require.ErrorIs(t, ValidateURL(ctx, "http://localhost:8080/hooks"), ErrForbiddenAddress)
+	require.ErrorIs(t, ValidateURL(ctx, "https://internal.example.com/hooks"), ErrForbiddenAddress)
+	require.ErrorIs(t, ValidateURL(ctx, "http://169.254.169.254/latest/meta-data"), ErrForbiddenAddress)
+	require.ErrorIs(t, ValidateURL(ctx, "http://[::1]/hooks"), ErrForbiddenAddress)
+}
@@ -0,0 +1,101 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+)
+
+// Context holds the values shared between nodes, available as {{context.*}} in node fields
+type Context map[string]interface{}
+
+// Resolve interpolates every {{...}} found in the value. A string made of a single template keeps the type
+// of the referenced value, otherwise the values are formatted into the string.
+func (c Context) Resolve(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		template, err := agent.ParseTemplate(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid interpolation %q: %w", value, err)
+		}
+
+		resolved, err := template.Evaluate(c)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to interpolate %q: %w", value, err)
+		}
+
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, 0, len(value))
+
+		for _, item := range value {
+			item, err := c.Resolve(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			resolved = append(resolved, item)
+		}
+
+		return resolved, nil
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(value))
+
+		for key, item := range value {
+			item, err := c.Resolve(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			resolved[key] = item
+		}
+
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// ResolveString interpolates the value and formats the result as a string
+func (c Context) ResolveString(value string) (string, error) {
+	resolved, err := c.Resolve(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	return agent.ExpressionString(resolved), nil
+}
+
+// Value evaluates a node condition written in the agent expression language e.g {{context.todos}} or len(context.todos)
+func (c Context) Value(condition string) (interface{}, error) {
+	expression, err := agent.ParseExpression(condition)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	value, err := expression.Evaluate(c)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %w", condition, err)
+	}
+
+	return value, nil
+}
+
+// Evaluate evaluates a node condition and checks the result for truthiness
+// e.g {{context.title}} == "" && len(context.todos) > 0
+func (c Context) Evaluate(condition string) (bool, error) {
+	value, err := c.Value(condition)
+
+	if err != nil {
+		return false, err
+	}
+
+	return agent.ExpressionTruthy(value), nil
+}
@@ -0,0 +1,509 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/safehttp"
+)
+
+const (
+	defaultMaxIterations = 100
+	defaultMaxSteps      = 1000
+	defaultHTTPTimeout   = 10 * time.Second
+	// maxHTTPResponseBody bounds the response of an integration node kept in the context
+	maxHTTPResponseBody = 1 << 20
+)
+
+var (
+	ErrMaxIterations    = errors.New("loop exceeded the maximum number of iterations")
+	ErrMaxSteps         = errors.New("workflow exceeded the maximum number of steps")
+	ErrResponseTooLarge = fmt.Errorf("integration response exceeded %d bytes", maxHTTPResponseBody)
+)
+
+// UnsupportedNodeError is returned for nodes the interpreter can't execute, they need the generated code
+type UnsupportedNodeError struct {
+	Type agent.WorkflowType
+}
+
+func (e *UnsupportedNodeError) Error() string {
+	return fmt.Sprintf("%s nodes are not supported by the workflow interpreter", e.Type)
+}
+
+// Request is the incoming http request the workflow is executed for, available as {{context.request}}
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Params  map[string]string `json:"params"`
+	Query   map[string]string `json:"query"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+	// Env holds the project secrets, available as {{context.env.NAME}}
+	Env map[string]string `json:"-"`
+}
+
+// Response is produced by the first response or error node reached
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// Interpreter executes endpoint workflows directly, without generating code
+type Interpreter struct {
+	client        *http.Client
+	maxIterations int
+	maxSteps      int
+}
+
+type OptionFunc func(*Interpreter)
+
+// WithHTTPClient sets the client used by integration nodes, the default one only reaches public addresses
+func WithHTTPClient(client *http.Client) OptionFunc {
+	return func(i *Interpreter) {
+		i.client = client
+	}
+}
+
+// WithMaxIterations sets how many times a for or while node may loop
+func WithMaxIterations(maxIterations int) OptionFunc {
+	return func(i *Interpreter) {
+		if maxIterations > 0 {
+			i.maxIterations = maxIterations
+		}
+	}
+}
+
+// WithMaxSteps sets how many nodes a single run may execute
+func WithMaxSteps(maxSteps int) OptionFunc {
+	return func(i *Interpreter) {
+		if maxSteps > 0 {
+			i.maxSteps = maxSteps
+		}
+	}
+}
+
+func New(opts ...OptionFunc) *Interpreter {
+	i := &Interpreter{
+		client:        safehttp.NewClient(defaultHTTPTimeout),
+		maxIterations: defaultMaxIterations,
+		maxSteps:      defaultMaxSteps,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// run holds the state of a single execution
+type run struct {
+	interpreter *Interpreter
+	context     Context
+	steps       int
+	response    *Response
+}
+
+// Run executes the workflows for the request. A workflow ending without a response node answers 204.
+func (i *Interpreter) Run(ctx context.Context, workflows []*agent.Workflow, req *Request) (*Response, error) {
+	r := &run{
+		interpreter: i,
+		context:     newContext(req),
+	}
+
+	nodes := make([]agent.Workflow, 0, len(workflows))
+
+	for _, workflow := range workflows {
+		nodes = append(nodes, *workflow)
+	}
+
+	if err := r.execute(ctx, nodes); err != nil {
+		return nil, err
+	}
+
+	if r.response == nil {
+		return &Response{Status: http.StatusNoContent}, nil
+	}
+
+	return r.response, nil
+}
+
+func newContext(req *Request) Context {
+	request := map[string]interface{}{
+		"method":  req.Method,
+		"path":    req.Path,
+		"params":  toMap(req.Params),
+		"query":   toMap(req.Query),
+		"headers": toMap(req.Headers),
+		"body":    req.Body,
+	}
+
+	return Context{
+		"request": request,
+		"env":     toMap(req.Env),
+	}
+}
+
+// execute runs the nodes in order until a response is set
+func (r *run) execute(ctx context.Context, nodes []agent.Workflow) error {
+	for _, node := range nodes {
+		if r.response != nil {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		r.steps++
+
+		if r.steps > r.interpreter.maxSteps {
+			return ErrMaxSteps
+		}
+
+		if err := r.executeNode(ctx, node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *run) executeNode(ctx context.Context, node agent.Workflow) error {
+	switch node.Type {
+	case agent.WorkflowTypeRequest:
+		// the request is already in the context
+		return nil
+	case agent.WorkflowTypeVariable:
+		value, err := r.context.Resolve(node.Value)
+
+		if err != nil {
+			return err
+		}
+
+		r.context[node.Name] = value
+		return nil
+	case agent.WorkflowTypeIf:
+		ok, err := r.context.Evaluate(node.Condition)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return r.execute(ctx, node.Then)
+		}
+
+		return r.execute(ctx, node.Else)
+	case agent.WorkflowTypeSwitch:
+		return r.executeSwitch(ctx, node)
+	case agent.WorkflowTypeFor:
+		return r.executeFor(ctx, node)
+	case agent.WorkflowTypeWhile:
+		return r.executeWhile(ctx, node)
+	case agent.WorkflowTypeResponse:
+		return r.respond(node, http.StatusOK)
+	case agent.WorkflowTypeError:
+		return r.respond(node, http.StatusInternalServerError)
+	case agent.WorkflowTypeResend, agent.WorkflowTypeStripe, agent.WorkflowTypeGithub:
+		return r.executeHTTP(ctx, node)
+	default:
+		return &UnsupportedNodeError{Type: node.Type}
+	}
+}
+
+func (r *run) executeSwitch(ctx context.Context, node agent.Workflow) error {
+	value, err := r.context.Value(node.Condition)
+
+	if err != nil {
+		return err
+	}
+
+	var fallback *agent.WorkflowCase
+
+	for i := range node.Cases {
+		switchCase := node.Cases[i]
+
+		if switchCase.Value == "default" {
+			fallback = &switchCase
+			continue
+		}
+
+		caseValue, err := r.context.ResolveString(switchCase.Value)
+
+		if err != nil {
+			return err
+		}
+
+		if caseValue == agent.ExpressionString(value) {
+			return r.executeBody(ctx, switchCase.Body)
+		}
+	}
+
+	if fallback != nil {
+		return r.executeBody(ctx, fallback.Body)
+	}
+
+	return nil
+}
+
+// executeFor iterates over the collection, or count, the condition evaluates to.
+// Every iteration exposes {{context.item}} and {{context.index}}.
+func (r *run) executeFor(ctx context.Context, node agent.Workflow) error {
+	var items []interface{}
+
+	value, err := r.context.Value(node.Condition)
+
+	if err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case []interface{}:
+		items = value
+	case float64:
+		if int(value) > r.interpreter.maxIterations {
+			return ErrMaxIterations
+		}
+
+		for index := 0; index < int(value); index++ {
+			items = append(items, float64(index))
+		}
+	default:
+		return fmt.Errorf("for condition %q must evaluate to an array or a number", node.Condition)
+	}
+
+	if len(items) > r.interpreter.maxIterations {
+		return ErrMaxIterations
+	}
+
+	for index, item := range items {
+		r.context["item"] = item
+		r.context["index"] = float64(index)
+
+		if err := r.executeBody(ctx, node.Body); err != nil {
+			return err
+		}
+
+		if r.response != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (r *run) executeWhile(ctx context.Context, node agent.Workflow) error {
+	for iteration := 0; ; iteration++ {
+		ok, err := r.context.Evaluate(node.Condition)
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if iteration >= r.interpreter.maxIterations {
+			return ErrMaxIterations
+		}
+
+		if err := r.executeBody(ctx, node.Body); err != nil {
+			return err
+		}
+
+		if r.response != nil {
+			return nil
+		}
+	}
+}
+
+// executeBody runs the nested nodes of a loop or switch case, a body that isn't a list of nodes is ignored
+func (r *run) executeBody(ctx context.Context, body interface{}) error {
+	raw, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	var nodes []agent.Workflow
+
+	if err := json.Unmarshal(raw, &nodes); err != nil {
+		return nil
+	}
+
+	return r.execute(ctx, nodes)
+}
+
+// respond sets the response of the run from a response or error node
+func (r *run) respond(node agent.Workflow, defaultStatus int) error {
+	status := defaultStatus
+
+	resolvedStatus, err := r.context.ResolveString(node.Status)
+
+	if err != nil {
+		return err
+	}
+
+	if code, err := strconv.Atoi(resolvedStatus); err == nil {
+		status = code
+	}
+
+	body, err := r.context.Resolve(node.Body)
+
+	if err != nil {
+		return err
+	}
+
+	if body == nil && node.Type == agent.WorkflowTypeError {
+		body = map[string]interface{}{"error": node.Instruction}
+	}
+
+	headers, err := r.headers(node.Headers)
+
+	if err != nil {
+		return err
+	}
+
+	r.response = &Response{
+		Status:  status,
+		Headers: headers,
+		Body:    body,
+	}
+
+	return nil
+}
+
+// executeHTTP calls the url of an integration node, the decoded response is stored as {{context.<action_id>}}
+func (r *run) executeHTTP(ctx context.Context, node agent.Workflow) error {
+	var body io.Reader
+
+	if node.Body != nil {
+		resolved, err := r.context.Resolve(node.Body)
+
+		if err != nil {
+			return err
+		}
+
+		raw, err := json.Marshal(resolved)
+
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(raw)
+	}
+
+	method := strings.ToUpper(node.Method)
+
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	url, err := r.context.ResolveString(node.Url)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+
+	if err != nil {
+		return err
+	}
+
+	headers, err := r.headers(node.Headers)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := r.interpreter.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("%s node failed: %w", node.Type, err)
+	}
+
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(res.Body, maxHTTPResponseBody+1))
+
+	if err != nil {
+		return err
+	}
+
+	if len(raw) > maxHTTPResponseBody {
+		return fmt.Errorf("%s node failed: %w", node.Type, ErrResponseTooLarge)
+	}
+
+	var decoded interface{}
+
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		decoded = string(raw)
+	}
+
+	result := map[string]interface{}{
+		"status": float64(res.StatusCode),
+		"body":   decoded,
+	}
+
+	if node.ActionID != "" {
+		r.context[node.ActionID] = result
+	}
+
+	return nil
+}
+
+// headers parses "Key: Value" header lines
+func (r *run) headers(lines []string) (map[string]string, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	headers := map[string]string{}
+
+	for _, line := range lines {
+		resolved, err := r.context.ResolveString(line)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, value, ok := strings.Cut(resolved, ":")
+
+		if !ok {
+			continue
+		}
+
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return headers, nil
+}
+
+func toMap(values map[string]string) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+
+	for key, value := range values {
+		m[key] = value
+	}
+
+	return m
+}
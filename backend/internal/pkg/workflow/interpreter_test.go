@@ -0,0 +1,257 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/safehttp"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Interpreter_Run(t *testing.T) {
+	tests := []struct {
+		name         string
+		workflows    string
+		request      *Request
+		opts         []OptionFunc
+		wantResponse *Response
+		wantErr      string
+	}{
+		{
+			name: "if_then",
+			workflows: `[
+				{"type": "request", "method": "POST", "url": "/todos"},
+				{"type": "variable", "name": "title", "value": "{{context.request.body.title}}"},
+				{"type": "if", "condition": "{{context.title}} == ''", "then": [
+					{"type": "response", "status": "400", "body": {"error": "title is required"}}
+				], "else": [
+					{"type": "response", "status": "201", "body": {"title": "{{context.title}}"}}
+				]}
+			]`,
+			request:      &Request{Method: http.MethodPost, Body: map[string]interface{}{"title": ""}},
+			wantResponse: &Response{Status: 400, Body: map[string]interface{}{"error": "title is required"}},
+		},
+		{
+			name: "if_else",
+			workflows: `[
+				{"type": "request", "method": "POST", "url": "/todos"},
+				{"type": "variable", "name": "title", "value": "{{context.request.body.title}}"},
+				{"type": "if", "condition": "{{context.title}} == ''", "then": [
+					{"type": "response", "status": "400", "body": {"error": "title is required"}}
+				], "else": [
+					{"type": "response", "status": "201", "headers": ["X-Todo: {{context.title}}"], "body": {"title": "{{context.title}}"}}
+				]}
+			]`,
+			request: &Request{Method: http.MethodPost, Body: map[string]interface{}{"title": "Buy milk"}},
+			wantResponse: &Response{
+				Status:  201,
+				Headers: map[string]string{"X-Todo": "Buy milk"},
+				Body:    map[string]interface{}{"title": "Buy milk"},
+			},
+		},
+		{
+			name: "switch_default",
+			workflows: `[
+				{"type": "request", "url": "/todos/:id"},
+				{"type": "switch", "condition": "{{context.request.params.id}}", "cases": [
+					{"value": "1", "body": [{"type": "response", "status": "200", "body": {"id": 1}}]},
+					{"value": "default", "body": [{"type": "error", "status": "404", "instruction": "todo not found"}]}
+				]}
+			]`,
+			request:      &Request{Params: map[string]string{"id": "2"}},
+			wantResponse: &Response{Status: 404, Body: map[string]interface{}{"error": "todo not found"}},
+		},
+		{
+			name: "for_over_array",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "for", "condition": "{{context.request.body.todos}}", "body": [
+					{"type": "if", "condition": "{{context.item.done}} == false", "then": [
+						{"type": "response", "status": "200", "body": {"first_pending": "{{context.item.title}}", "index": "{{context.index}}"}}
+					]}
+				]}
+			]`,
+			request: &Request{Body: map[string]interface{}{"todos": []interface{}{
+				map[string]interface{}{"title": "Buy milk", "done": true},
+				map[string]interface{}{"title": "Walk the dog", "done": false},
+			}}},
+			wantResponse: &Response{Status: 200, Body: map[string]interface{}{"first_pending": "Walk the dog", "index": float64(1)}},
+		},
+		{
+			name: "expression_condition",
+			workflows: `[
+				{"type": "request", "method": "POST", "url": "/todos"},
+				{"type": "if", "condition": "len(context.request.body.tags) > 1 && lower(context.request.headers.role) == 'admin'", "then": [
+					{"type": "response", "status": "200", "body": {"tags": "{{join(context.request.body.tags, ', ')}}"}}
+				], "else": [
+					{"type": "response", "status": "403"}
+				]}
+			]`,
+			request: &Request{
+				Headers: map[string]string{"role": "Admin"},
+				Body:    map[string]interface{}{"tags": []interface{}{"home", "work"}},
+			},
+			wantResponse: &Response{Status: 200, Body: map[string]interface{}{"tags": "home, work"}},
+		},
+		{
+			name: "invalid_condition",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "while", "condition": "count < 10", "body": []}
+			]`,
+			request: &Request{},
+			wantErr: `invalid condition "count < 10": unknown identifier "count", values are read from context e.g context.count at position 1`,
+		},
+		{
+			name: "no_response",
+			workflows: `[
+				{"type": "request", "url": "/todos"}
+			]`,
+			request:      &Request{},
+			wantResponse: &Response{Status: http.StatusNoContent},
+		},
+		{
+			name: "env",
+			workflows: `[
+				{"type": "request", "url": "/config"},
+				{"type": "response", "body": {"region": "{{context.env.REGION}}"}}
+			]`,
+			request:      &Request{Env: map[string]string{"REGION": "eu"}},
+			wantResponse: &Response{Status: 200, Body: map[string]interface{}{"region": "eu"}},
+		},
+		{
+			name: "while_iteration_limit",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "while", "condition": "true", "body": [
+					{"type": "variable", "name": "count", "value": "1"}
+				]}
+			]`,
+			request: &Request{},
+			opts:    []OptionFunc{WithMaxIterations(5)},
+			wantErr: ErrMaxIterations.Error(),
+		},
+		{
+			name: "step_limit",
+			workflows: `[
+				{"type": "request", "url": "/todos"},
+				{"type": "for", "condition": "10", "body": [
+					{"type": "variable", "name": "count", "value": "{{context.index}}"}
+				]}
+			]`,
+			request: &Request{},
+			opts:    []OptionFunc{WithMaxSteps(5)},
+			wantErr: ErrMaxSteps.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var workflows []*agent.Workflow
+			require.NoError(t, json.Unmarshal([]byte(tt.workflows), &workflows))
+
+			res, err := New(tt.opts...).Run(context.Background(), workflows, tt.request)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantResponse, res)
+		})
+	}
+}
+
+func Test_Interpreter_UnsupportedNode(t *testing.T) {
+	_, err := New().Run(context.Background(), []*agent.Workflow{
+		{Type: agent.WorkflowTypeRequest, Url: "/todos"},
+		{Type: agent.WorkflowTypeSupabase, Instruction: "Insert the todo"},
+	}, &Request{})
+
+	var unsupportedErr *UnsupportedNodeError
+	require.ErrorAs(t, err, &unsupportedErr)
+	require.Equal(t, agent.WorkflowTypeSupabase, unsupportedErr.Type)
+}
+
+func Test_Interpreter_Integration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "Bearer re_123", r.Header.Get("Authorization"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "ada@example.com", body["to"])
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"id": "email-1"}`))
+	}))
+	defer server.Close()
+
+	var workflows []*agent.Workflow
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"type": "request", "method": "POST", "url": "/welcome"},
+		{"action_id": "send_email", "type": "resend", "method": "post", "url": "`+server.URL+`",
+			"headers": ["Authorization: Bearer {{context.env.RESEND_API_KEY}}"],
+			"body": {"to": "{{context.request.body.email}}"}},
+		{"type": "response", "status": "{{context.send_email.status}}", "body": {"id": "{{context.send_email.body.id}}"}}
+	]`), &workflows))
+
+	res, err := New(WithHTTPClient(server.Client())).Run(context.Background(), workflows, &Request{
+		Method: http.MethodPost,
+		Body:   map[string]interface{}{"email": "ada@example.com"},
+		Env:    map[string]string{"RESEND_API_KEY": "re_123"},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, &Response{Status: http.StatusAccepted, Body: map[string]interface{}{"id": "email-1"}}, res)
+}
+
+func Test_Interpreter_IntegrationLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", maxHTTPResponseBody+1)))
+	}))
+	defer server.Close()
+
+	workflows := []*agent.Workflow{
+		{Type: agent.WorkflowTypeRequest, Method: http.MethodGet, Url: "/proxy"},
+		{ActionID: "fetch", Type: agent.WorkflowTypeGithub, Method: http.MethodGet, Url: server.URL},
+	}
+
+	// the default client refuses the loopback address of the test server
+	_, err := New().Run(context.Background(), workflows, &Request{Method: http.MethodGet})
+	require.ErrorIs(t, err, safehttp.ErrForbiddenAddress)
+
+	_, err = New(WithHTTPClient(server.Client())).Run(context.Background(), workflows, &Request{Method: http.MethodGet})
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+}
+
+func Test_MatchPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		path       string
+		wantParams map[string]string
+		wantOk     bool
+	}{
+		{name: "static", pattern: "/todos", path: "/todos/", wantParams: map[string]string{}, wantOk: true},
+		{name: "colon_param", pattern: "/todos/:id", path: "/todos/1", wantParams: map[string]string{"id": "1"}, wantOk: true},
+		{name: "brace_param", pattern: "/users/{user_id}/todos", path: "/users/42/todos", wantParams: map[string]string{"user_id": "42"}, wantOk: true},
+		{name: "segment_mismatch", pattern: "/todos/:id", path: "/users/1"},
+		{name: "length_mismatch", pattern: "/todos/:id", path: "/todos"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, ok := MatchPath(tt.pattern, tt.path)
+
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.wantParams, params)
+		})
+	}
+}
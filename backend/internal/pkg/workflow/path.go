@@ -0,0 +1,39 @@
+package workflow
+
+import "strings"
+
+// MatchPath matches the request path against an endpoint path such as /todos/:id or /todos/{id}
+// and returns the path parameters.
+func MatchPath(pattern, path string) (map[string]string, bool) {
+	patternSegments := splitPath(pattern)
+	pathSegments := splitPath(path)
+
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+
+	for i, segment := range patternSegments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			params[segment[1:]] = pathSegments[i]
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			params[segment[1:len(segment)-1]] = pathSegments[i]
+		case segment != pathSegments[i]:
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
@@ -1,12 +1,18 @@
 package job
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/danvixent/asynqmon"
 	"github.com/google/uuid"
+	"github.com/guregu/null"
 	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
 	"github.com/mujhtech/b0/internal/pkg/encrypt"
+	"github.com/rs/zerolog"
 )
 
 var (
@@ -19,19 +25,22 @@ type Client struct {
 	client       *asynq.Client
 	inspector    *asynq.Inspector
 	aesCfb       encrypt.Encrypt
+	jobs         store.JobRepository
 }
 
-func NewClient(opts asynq.RedisConnOpt, aesCfb encrypt.Encrypt) *Client {
+func NewClient(opts asynq.RedisConnOpt, aesCfb encrypt.Encrypt, jobs store.JobRepository) *Client {
 
 	return &Client{
 		redisConnOpt: opts,
 		client:       asynq.NewClient(opts),
 		inspector:    asynq.NewInspector(opts),
 		aesCfb:       aesCfb,
+		jobs:         jobs,
 	}
 }
 
-func (c *Client) Enqueue(queue QueueName, job JobName, payload *ClientPayload) error {
+// Enqueue records the job and enqueues its task, the returned id is the id of the job to poll its state
+func (c *Client) Enqueue(ctx context.Context, queue QueueName, job JobName, payload *ClientPayload) (string, error) {
 
 	id := uuid.New().String()
 
@@ -40,12 +49,46 @@ func (c *Client) Enqueue(queue QueueName, job JobName, payload *ClientPayload) e
 	data, err := c.aesCfb.Encrypt(payload.Data)
 
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	opts := []asynq.Option{asynq.Queue(q), asynq.TaskID(id), asynq.ProcessIn(payload.Delay)}
+
+	if payload.MaxRetry > 0 {
+		opts = append(opts, asynq.MaxRetry(payload.MaxRetry))
+	}
+
+	t := asynq.NewTask(string(job), []byte(data), opts...)
+
+	record := &models.Job{
+		ID:        id,
+		ProjectID: null.NewString(payload.ProjectID, payload.ProjectID != ""),
+		Name:      string(job),
+		Queue:     q,
+	}
+
+	record.Transition(models.JobStateQueued, time.Now(), "")
+
+	if err := c.jobs.CreateJob(ctx, record); err != nil {
+		return "", err
+	}
+
+	if err := c.enqueue(q, id, t); err != nil {
+		// the task never runs, the job is failed for its pollers
+		record.Transition(models.JobStateFailed, time.Now(), err.Error())
+
+		if updateErr := c.jobs.UpdateJob(ctx, record); updateErr != nil {
+			zerolog.Ctx(ctx).Error().Err(updateErr).Msgf("failed to record the failure of job %s", id)
+		}
+
+		return "", err
 	}
 
-	t := asynq.NewTask(string(job), []byte(data), asynq.Queue(q), asynq.TaskID(id), asynq.ProcessIn(payload.Delay))
+	return id, nil
+}
 
-	_, err = c.inspector.GetTaskInfo(q, id)
+func (c *Client) enqueue(q, id string, t *asynq.Task) error {
+	_, err := c.inspector.GetTaskInfo(q, id)
 	if err != nil {
 
 		message := err.Error()
@@ -2,9 +2,12 @@ package job
 
 import (
 	"context"
+	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/mujhtech/b0/config"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
 )
 
 type Executor struct {
@@ -12,7 +15,7 @@ type Executor struct {
 	srv *asynq.Server
 }
 
-func NewExecutor(cfg *config.Config, appCtx context.Context, opts asynq.RedisConnOpt) *Executor {
+func NewExecutor(cfg *config.Config, appCtx context.Context, opts asynq.RedisConnOpt, jobs store.JobRepository) *Executor {
 
 	srv := asynq.NewServer(
 		opts,
@@ -21,10 +24,12 @@ func NewExecutor(cfg *config.Config, appCtx context.Context, opts asynq.RedisCon
 			BaseContext: func() context.Context {
 				return appCtx
 			},
+			RetryDelayFunc: retryDelay(cfg),
 		},
 	)
 
 	mux := asynq.NewServeMux()
+	mux.Use(trackJobs(jobs))
 
 	return &Executor{
 		mux: mux,
@@ -32,6 +37,17 @@ func NewExecutor(cfg *config.Config, appCtx context.Context, opts asynq.RedisCon
 	}
 }
 
+// retryDelay backs off the webhook deliveries exponentially, the other jobs keep the default delay of asynq
+func retryDelay(cfg *config.Config) asynq.RetryDelayFunc {
+	return func(n int, err error, t *asynq.Task) time.Duration {
+		if t.Type() == string(JobNameWebhook) {
+			return webhook.Backoff(n, cfg.Webhook.RetryBaseDelay(), cfg.Webhook.RetryMaxDelay())
+		}
+
+		return asynq.DefaultRetryDelayFunc(n, err, t)
+	}
+}
+
 func (e *Executor) Start() error {
 	return e.srv.Start(e.mux)
 }
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/rs/zerolog"
+)
+
+// chatHistoryLimit is the number of earlier chat messages loaded for a prompt, the agent keeps the most recent ones that fit
+const chatHistoryLimit = 50
+
+// chatHistory returns the conversation that preceded the chat message, oldest first.
+// The prompt is answered without history when it can't be loaded.
+func chatHistory(ctx context.Context, store *store.Store, message *models.ChatMessage) []agent.Message {
+	if message == nil {
+		return nil
+	}
+
+	messages, err := store.ChatMessageRepo.FindChatMessages(ctx, chatHistoryFilter(message))
+
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("failed to find the chat history of message %s", message.ID)
+		return nil
+	}
+
+	history := make([]agent.Message, 0, len(messages))
+
+	for _, m := range messages {
+		role := agent.MessageRoleUser
+
+		if m.Role == models.ChatMessageRoleAssistant {
+			role = agent.MessageRoleAssistant
+		}
+
+		history = append(history, agent.Message{Role: role, Content: m.Content})
+	}
+
+	return history
+}
+
+// chatHistoryFilter selects the latest messages of the conversation sent before the message
+func chatHistoryFilter(message *models.ChatMessage) store.ChatMessageFilter {
+	return store.ChatMessageFilter{
+		ProjectID:  message.ProjectID,
+		EndpointID: message.EndpointID.String,
+		Before:     message.CreatedAt,
+		Limit:      chatHistoryLimit,
+	}
+}
+
+// workflowReply describes the workflow changes the way the assistant answers in the chat,
+// the explanation of the model followed by the list of changes so follow-up prompts can refer to them
+func workflowReply(explanation string, before, after []*agent.Workflow) string {
+	changes, err := agent.DiffWorkflows(before, after)
+
+	switch {
+	case err != nil:
+		return joinReply(explanation, "The workflow was updated.")
+	case len(changes) == 0:
+		return joinReply(explanation, "The workflow is unchanged.")
+	}
+
+	lines := []string{"Changes:"}
+
+	for _, change := range changes {
+		lines = append(lines, "- "+change.String())
+	}
+
+	return joinReply(explanation, strings.Join(lines, "\n"))
+}
+
+// joinReply puts the explanation of the model before the summary of what was done
+func joinReply(explanation, summary string) string {
+	if explanation = strings.TrimSpace(explanation); explanation == "" {
+		return summary
+	}
+
+	return explanation + "\n" + summary
+}
+
+// createChatReply stores the answer of the assistant to the chat message
+func createChatReply(ctx context.Context, store *store.Store, message *models.ChatMessage, content string) {
+	err := store.ChatMessageRepo.CreateChatMessage(ctx, &models.ChatMessage{
+		ID:         uuid.New().String(),
+		OwnerID:    message.OwnerID,
+		ProjectID:  message.ProjectID,
+		EndpointID: message.EndpointID,
+		Role:       models.ChatMessageRoleAssistant,
+		Content:    content,
+	})
+
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to create the chat reply to message %s", message.ID)
+	}
+}
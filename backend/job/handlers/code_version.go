@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/services"
+	"github.com/rs/zerolog"
+)
+
+// lastPromptLimit is the number of recent chat messages searched for the last prompt about an endpoint
+const lastPromptLimit = 10
+
+// saveCodeVersion saves the code as the next version of the endpoint, the job goes on when it can't be saved
+func saveCodeVersion(ctx context.Context, store *store.Store, endpoint *models.Endpoint, code *aa.CodeGeneration, message string) {
+	createCodeVersionService := services.CreateCodeVersionService{
+		CodeVersionRepo: store.CodeVersionRepo,
+		Endpoint:        endpoint,
+		Code:            code,
+		Message:         message,
+	}
+
+	version, err := createCodeVersionService.Run(ctx)
+
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to save the code version of endpoint %s", endpoint.ID)
+		return
+	}
+
+	zerolog.Ctx(ctx).Info().Msgf("saved version %s of the code of endpoint %s", version.Version, endpoint.ID)
+}
+
+// generationMessage is the version message of generated code, the last prompt about the endpoint or what was generated
+func generationMessage(ctx context.Context, store *store.Store, endpoint *models.Endpoint, updated bool) string {
+	if prompt := lastPrompt(ctx, store, endpoint); prompt != "" {
+		return prompt
+	}
+
+	if updated {
+		return fmt.Sprintf("Update code for %s %s", endpoint.Method, endpoint.Path)
+	}
+
+	return fmt.Sprintf("Generate code for %s %s", endpoint.Method, endpoint.Path)
+}
+
+// lastPrompt returns the latest chat message of the user about the endpoint, empty when there's none
+func lastPrompt(ctx context.Context, store *store.Store, endpoint *models.Endpoint) string {
+	messages, err := store.ChatMessageRepo.FindChatMessages(ctx, lastPromptFilter(endpoint))
+
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("failed to find the last prompt about endpoint %s", endpoint.ID)
+		return ""
+	}
+
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == models.ChatMessageRoleUser {
+			return messages[i].Content
+		}
+	}
+
+	return ""
+}
+
+func lastPromptFilter(endpoint *models.Endpoint) store.ChatMessageFilter {
+	return store.ChatMessageFilter{
+		ProjectID:  endpoint.ProjectID,
+		EndpointID: endpoint.ID,
+		Limit:      lastPromptLimit,
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+)
+
+// deployRuntime is how the container of a language installs, builds and runs the generated code
+type deployRuntime struct {
+	// installEnv and runEnv prefix the install and run commands e.g NODE_ENV=production
+	installEnv string
+	runEnv     string
+	// the default commands are used when the generated code leaves them empty
+	defaultInstall []string
+	defaultBuild   string
+	defaultRun     string
+	// portEnv are the variables besides B0_PORT the framework reads the server port from
+	portEnv []string
+	env     []string
+}
+
+var deployRuntimes = map[string]deployRuntime{
+	aa.LanguageNodeJS: {
+		installEnv:     "NODE_ENV=development",
+		runEnv:         "NODE_ENV=production",
+		defaultInstall: []string{"npm install"},
+		env:            []string{"NODE_ENV=development"},
+	},
+	aa.LanguagePython: {
+		defaultInstall: []string{"pip install --no-cache-dir -r requirements.txt"},
+		defaultRun:     "uvicorn main:app --host 0.0.0.0 --port $B0_PORT",
+		env:            []string{"PYTHONUNBUFFERED=1"},
+	},
+	aa.LanguageRust: {
+		defaultInstall: []string{"cargo fetch"},
+		defaultBuild:   "cargo build --release",
+		defaultRun:     "./target/release/b0-app",
+		env:            []string{"CARGO_TERM_COLOR=never"},
+	},
+	aa.LanguageJava: {
+		defaultInstall: []string{"mvn -q -B dependency:go-offline"},
+		defaultBuild:   "mvn -q -B package -DskipTests",
+		defaultRun:     "java -jar target/app.jar",
+		// spring boot binds SERVER_PORT to server.port
+		portEnv: []string{"SERVER_PORT"},
+	},
+}
+
+// getDeployRuntime returns the runtime of the language, go and unknown languages run the commands as generated
+func getDeployRuntime(language string) deployRuntime {
+	return deployRuntimes[language]
+}
+
+// command returns the shell command installing, building and running the code in /app
+func (d deployRuntime) command(code *aa.CodeGeneration) string {
+	steps := d.buildSteps(code)
+
+	if run := d.runCommand(code); run != "" {
+		steps = append(steps, run)
+	}
+
+	return strings.Join(steps, " && ")
+}
+
+// runCommand returns the shell command starting the built code
+func (d deployRuntime) runCommand(code *aa.CodeGeneration) string {
+	run := code.RunCommands
+
+	if strings.TrimSpace(run) == "" {
+		run = d.defaultRun
+	}
+
+	if run == "" {
+		return ""
+	}
+
+	return withEnv(d.runEnv, run)
+}
+
+// buildCommand returns the shell command installing and building the code in /app
+func (d deployRuntime) buildCommand(code *aa.CodeGeneration) string {
+	return strings.Join(d.buildSteps(code), " && ")
+}
+
+func (d deployRuntime) buildSteps(code *aa.CodeGeneration) []string {
+	install := code.InstallCommands
+
+	if len(install) == 0 {
+		install = d.defaultInstall
+	}
+
+	build := code.BuildCommands
+
+	if strings.TrimSpace(build) == "" {
+		build = d.defaultBuild
+	}
+
+	steps := []string{"cd /app"}
+
+	if len(install) > 0 {
+		steps = append(steps, withEnv(d.installEnv, strings.Join(install, " && ")))
+	}
+
+	if build != "" {
+		steps = append(steps, build)
+	}
+
+	return steps
+}
+
+// envs returns the container variables wiring the server port
+func (d deployRuntime) envs(port string) []string {
+	envs := []string{fmt.Sprintf("B0_PORT=%s", port)}
+
+	for _, name := range d.portEnv {
+		envs = append(envs, fmt.Sprintf("%s=%s", name, port))
+	}
+
+	return append(envs, d.env...)
+}
+
+func withEnv(env, command string) string {
+	if env == "" {
+		return command
+	}
+
+	return env + " " + command
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"testing"
+
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_deployRuntime(t *testing.T) {
+	tests := []struct {
+		name        string
+		language    string
+		code        *aa.CodeGeneration
+		wantCommand string
+		wantEnvs    []string
+	}{
+		{
+			name:     "go_runs_the_generated_commands",
+			language: aa.LanguageGo,
+			code: &aa.CodeGeneration{
+				InstallCommands: []string{"go mod init b0/todo", "go mod tidy"},
+				BuildCommands:   "go build -o app .",
+				RunCommands:     "./app",
+			},
+			wantCommand: "cd /app && go mod init b0/todo && go mod tidy && go build -o app . && ./app",
+			wantEnvs:    []string{"B0_PORT=3000"},
+		},
+		{
+			name:     "node_sets_node_env",
+			language: aa.LanguageNodeJS,
+			code: &aa.CodeGeneration{
+				InstallCommands: []string{"npm install"},
+				BuildCommands:   "npm run build",
+				RunCommands:     "npm run start",
+			},
+			wantCommand: "cd /app && NODE_ENV=development npm install && npm run build && NODE_ENV=production npm run start",
+			wantEnvs:    []string{"B0_PORT=3000", "NODE_ENV=development"},
+		},
+		{
+			name:        "python_defaults",
+			language:    aa.LanguagePython,
+			code:        &aa.CodeGeneration{},
+			wantCommand: "cd /app && pip install --no-cache-dir -r requirements.txt && uvicorn main:app --host 0.0.0.0 --port $B0_PORT",
+			wantEnvs:    []string{"B0_PORT=3000", "PYTHONUNBUFFERED=1"},
+		},
+		{
+			name:        "rust_defaults",
+			language:    aa.LanguageRust,
+			code:        &aa.CodeGeneration{},
+			wantCommand: "cd /app && cargo fetch && cargo build --release && ./target/release/b0-app",
+			wantEnvs:    []string{"B0_PORT=3000", "CARGO_TERM_COLOR=never"},
+		},
+		{
+			name:     "java_wires_server_port",
+			language: aa.LanguageJava,
+			code: &aa.CodeGeneration{
+				RunCommands: "java -jar target/todo.jar",
+			},
+			wantCommand: "cd /app && mvn -q -B dependency:go-offline && mvn -q -B package -DskipTests && java -jar target/todo.jar",
+			wantEnvs:    []string{"B0_PORT=3000", "SERVER_PORT=3000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := getDeployRuntime(tt.language)
+
+			require.Equal(t, tt.wantCommand, runtime.command(tt.code))
+			require.Equal(t, tt.wantEnvs, runtime.envs("3000"))
+		})
+	}
+}
+
+func Test_AvailableCodeGenerationOptions_Runtimes(t *testing.T) {
+	ids := map[string]bool{}
+
+	for _, option := range aa.AvailableCodeGenerationOptions {
+		require.False(t, ids[option.ID], "duplicate option id %s", option.ID)
+		ids[option.ID] = true
+
+		require.NotEmpty(t, option.Image)
+		// the instructions are formatted with the server port
+		require.NotContains(t, option.FrameworkInsructions, "%", option.Language+" "+option.Framework)
+
+		if option.Language != aa.LanguageGo {
+			_, ok := deployRuntimes[option.Language]
+			require.True(t, ok, "no deploy runtime for %s", option.Language)
+		}
+	}
+}
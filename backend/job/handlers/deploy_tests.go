@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/internal/pkg/testrunner"
+)
+
+// testReadyTimeout bounds the wait for a deployed container to install, build and serve
+const testReadyTimeout = 5 * time.Minute
+
+// runDeployTests runs the test suite of the code against the deployed project, the result of each case is published
+func runDeployTests(ctx context.Context, projectID string, runner *testrunner.Runner, cases []aa.TestCase, event sse.Streamer) []testrunner.Result {
+	sendEvent(ctx, projectID, sse.EventTypeTestStarted, AgentData{
+		Message: fmt.Sprintf("b0 is testing your project with %d test cases...", len(cases)),
+	}, event)
+
+	readyCtx, cancel := context.WithTimeout(ctx, testReadyTimeout)
+	defer cancel()
+
+	if err := runner.WaitReady(readyCtx); err != nil {
+		sendEvent(ctx, projectID, sse.EventTypeTestCompleted, AgentData{
+			Message: "b0 couldn't test your project, it doesn't answer requests",
+			Error:   err.Error(),
+		}, event)
+
+		return nil
+	}
+
+	results := runner.Run(ctx, cases, func(result testrunner.Result) {
+		eventType := sse.EventTypeTestPassed
+
+		if !result.Passed {
+			eventType = sse.EventTypeTestFailed
+		}
+
+		sendEvent(ctx, projectID, eventType, AgentData{
+			Message:    result.Name,
+			TestResult: &result,
+		}, event)
+	})
+
+	passed := 0
+
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+
+	sendEvent(ctx, projectID, sse.EventTypeTestCompleted, AgentData{
+		Message:     fmt.Sprintf("%d of %d test cases passed", passed, len(results)),
+		TestResults: results,
+	}, event)
+
+	return results
+}
+
+// deployedMessage returns the completion message of a deploy whose test suite ran, no results means the project never answered
+func deployedMessage(results []testrunner.Result) string {
+	if len(results) == 0 {
+		return "b0 has deployed your project, but it didn't answer its tests"
+	}
+
+	failed := 0
+
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Sprintf("b0 has deployed your project, %d of %d tests failed", failed, len(results))
+	}
+
+	return fmt.Sprintf("b0 has successfully deployed your project, all %d tests passed", len(results))
+}
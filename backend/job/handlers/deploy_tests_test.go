@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/internal/pkg/testrunner"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_runDeployTests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deps := newHandlerDeps(t, ctrl)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/todos" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"todos": []}`))
+	}))
+	defer server.Close()
+
+	results := runDeployTests(context.Background(), testProjectID, testrunner.New(server.URL), []aa.TestCase{
+		{Name: "lists the todos", Method: "GET", Path: "/todos", ExpectedStatus: 200, ExpectedBody: `{"todos": []}`},
+		{Name: "gets a todo", Method: "GET", Path: "/todos/1", ExpectedStatus: 200},
+	}, deps.event)
+
+	require.Equal(t, []sse.EventType{
+		sse.EventTypeTestStarted,
+		sse.EventTypeTestPassed,
+		sse.EventTypeTestFailed,
+		sse.EventTypeTestCompleted,
+	}, deps.nextEvents(t, 4))
+
+	require.Len(t, results, 2)
+	require.True(t, results[0].Passed)
+	require.Equal(t, []string{"expected status 200, got 404"}, results[1].Failures)
+}
+
+func Test_deployedMessage(t *testing.T) {
+	require.Equal(t, "b0 has deployed your project, but it didn't answer its tests", deployedMessage(nil))
+	require.Equal(t, "b0 has deployed your project, 1 of 2 tests failed", deployedMessage([]testrunner.Result{{Passed: true}, {Passed: false}}))
+	require.Equal(t, "b0 has successfully deployed your project, all 2 tests passed", deployedMessage([]testrunner.Result{{Passed: true}, {Passed: true}}))
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/mujhtech/b0/database/models"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/util"
+)
+
+// exportPort is the port the services of an export listen on
+const exportPort = "8080"
+
+// exportEndpoint is an endpoint of the export and the names of its secrets, the values never leave the secret manager
+type exportEndpoint struct {
+	endpoint *models.Endpoint
+	secrets  []string
+	dir      string
+}
+
+// hasCode reports whether code was generated for the endpoint
+func (e *exportEndpoint) hasCode() bool {
+	return e.endpoint.CodeGeneration != nil && len(e.endpoint.CodeGeneration.FileContents) > 0
+}
+
+// writeProjectExport writes the zip archive of the project, a directory per endpoint with its code,
+// a .env.example and a Dockerfile, and a README describing the endpoints and how to run them
+func writeProjectExport(w io.Writer, project *models.Project, option aa.CodeGenerationOption, endpoints []*exportEndpoint) error {
+	archive := zip.NewWriter(w)
+
+	root := exportRoot(project)
+
+	assignExportDirs(endpoints)
+
+	runtime := getDeployRuntime(option.Language)
+
+	for _, e := range endpoints {
+		if !e.hasCode() {
+			continue
+		}
+
+		code := e.endpoint.CodeGeneration
+
+		for _, file := range code.FileContents {
+			name, ok := exportFilename(file.Filename)
+
+			if !ok {
+				continue
+			}
+
+			if err := writeExportFile(archive, path.Join(root, e.dir, name), file.Content); err != nil {
+				return err
+			}
+		}
+
+		if err := writeExportFile(archive, path.Join(root, e.dir, ".env.example"), exportEnvExample(code, runtime, e.secrets)); err != nil {
+			return err
+		}
+
+		if err := writeExportFile(archive, path.Join(root, e.dir, "Dockerfile"), exportDockerfile(option, runtime, code)); err != nil {
+			return err
+		}
+	}
+
+	if err := writeExportFile(archive, path.Join(root, "README.md"), exportReadme(project, option, runtime, endpoints)); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// exportRoot is the directory of the archive holding the project
+func exportRoot(project *models.Project) string {
+	if root := util.Slugify(project.Slug); root != "" {
+		return root
+	}
+
+	return "project"
+}
+
+// assignExportDirs names the directory of every endpoint after the endpoint, a name used twice is suffixed
+func assignExportDirs(endpoints []*exportEndpoint) {
+	used := map[string]int{}
+
+	for i, e := range endpoints {
+		dir := util.Slugify(e.endpoint.Name)
+
+		if dir == "" {
+			dir = util.Slugify(fmt.Sprintf("%s %s", e.endpoint.Method, strings.ReplaceAll(e.endpoint.Path, "/", " ")))
+		}
+
+		if dir == "" {
+			dir = fmt.Sprintf("endpoint-%d", i+1)
+		}
+
+		used[dir]++
+
+		if used[dir] > 1 {
+			dir = fmt.Sprintf("%s-%d", dir, used[dir])
+		}
+
+		e.dir = dir
+	}
+}
+
+// exportFilename cleans a generated filename, files that would land outside their endpoint directory are skipped
+func exportFilename(filename string) (string, bool) {
+	name := path.Clean(strings.ReplaceAll(filename, "\\", "/"))
+
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+
+	return name, true
+}
+
+func writeExportFile(archive *zip.Writer, name, content string) error {
+	file, err := archive.Create(name)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(file, content)
+
+	return err
+}
+
+// exportEnvExample lists the variables of the code with their generated values and the secrets with empty values
+func exportEnvExample(code *aa.CodeGeneration, runtime deployRuntime, secrets []string) string {
+	var b strings.Builder
+
+	seen := map[string]bool{}
+
+	secret := map[string]bool{}
+
+	for _, name := range secrets {
+		secret[name] = true
+	}
+
+	b.WriteString("# the port the server listens on\n")
+
+	for _, env := range exportPortEnvs(runtime) {
+		key, _, _ := strings.Cut(env, "=")
+
+		seen[key] = true
+		b.WriteString(env + "\n")
+	}
+
+	for _, env := range code.EnvVars {
+		if env.Key == "" || seen[env.Key] || secret[env.Key] {
+			continue
+		}
+
+		seen[env.Key] = true
+		fmt.Fprintf(&b, "%s=%s\n", env.Key, env.Value)
+	}
+
+	names := []string{}
+
+	for _, name := range secrets {
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	if len(names) > 0 {
+		b.WriteString("\n# secrets, fill in their values\n")
+
+		for _, name := range names {
+			b.WriteString(name + "=\n")
+		}
+	}
+
+	return b.String()
+}
+
+// exportPortEnvs returns the variables the server reads its port from
+func exportPortEnvs(runtime deployRuntime) []string {
+	envs := []string{fmt.Sprintf("B0_PORT=%s", exportPort)}
+
+	for _, name := range runtime.portEnv {
+		envs = append(envs, fmt.Sprintf("%s=%s", name, exportPort))
+	}
+
+	return envs
+}
+
+// exportDockerfile builds the image of an endpoint from the same base image and commands as a deploy
+func exportDockerfile(option aa.CodeGenerationOption, runtime deployRuntime, code *aa.CodeGeneration) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "FROM %s\n\n", option.Image)
+	b.WriteString("WORKDIR /app\n\n")
+	b.WriteString("COPY . .\n\n")
+
+	for _, env := range runtime.env {
+		fmt.Fprintf(&b, "ENV %s\n", env)
+	}
+
+	if len(runtime.env) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "RUN %s\n\n", runtime.buildCommand(code))
+
+	for _, env := range exportPortEnvs(runtime) {
+		fmt.Fprintf(&b, "ENV %s\n", env)
+	}
+
+	fmt.Fprintf(&b, "\nEXPOSE %s\n", exportPort)
+
+	if run := runtime.runCommand(code); run != "" {
+		run, err := util.MarshalJSONToString([]string{"/bin/sh", "-c", run})
+
+		if err == nil {
+			fmt.Fprintf(&b, "\nCMD %s\n", run)
+		}
+	}
+
+	return b.String()
+}
+
+// exportReadme describes the project, how to run every endpoint and renders their workflows
+func exportReadme(project *models.Project, option aa.CodeGenerationOption, runtime deployRuntime, endpoints []*exportEndpoint) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", project.Name)
+
+	if project.Description.String != "" {
+		fmt.Fprintf(&b, "%s\n\n", project.Description.String)
+	}
+
+	fmt.Fprintf(&b, "Built with b0 in %s (%s).\n\n", option.Language, option.Framework)
+
+	b.WriteString("## Endpoints\n\n")
+	b.WriteString("| Endpoint | Directory |\n")
+	b.WriteString("| --- | --- |\n")
+
+	for _, e := range endpoints {
+		dir := fmt.Sprintf("`%s/`", e.dir)
+
+		if !e.hasCode() {
+			dir = "not generated yet"
+		}
+
+		fmt.Fprintf(&b, "| %s %s | %s |\n", e.endpoint.Method, e.endpoint.Path, dir)
+	}
+
+	b.WriteString("\n## Running an endpoint\n\n")
+	b.WriteString("Every directory is a standalone service. Copy its `.env.example` to `.env` and fill in the secrets, then run it with docker:\n\n")
+	b.WriteString("```sh\n")
+	b.WriteString("docker build -t <directory> <directory>\n")
+	fmt.Fprintf(&b, "docker run --env-file <directory>/.env -p %s:%s <directory>\n", exportPort, exportPort)
+	b.WriteString("```\n")
+
+	for _, e := range endpoints {
+		b.WriteString("\n")
+		b.WriteString(aa.RenderWorkflowMarkdown(fmt.Sprintf("%s %s", e.endpoint.Method, e.endpoint.Path), e.endpoint.Workflows))
+
+		if !e.hasCode() {
+			b.WriteString("\nThe code of this endpoint hasn't been generated yet, deploy the project to generate it.\n")
+			continue
+		}
+
+		code := e.endpoint.CodeGeneration
+
+		fmt.Fprintf(&b, "\nTo run it without docker, in `%s/`:\n\n```sh\n", e.dir)
+
+		for _, step := range runtime.buildSteps(code)[1:] {
+			b.WriteString(step + "\n")
+		}
+
+		if run := runtime.runCommand(code); run != "" {
+			b.WriteString(run + "\n")
+		}
+
+		b.WriteString("```\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeProjectExport(t *testing.T) {
+	project := &models.Project{
+		ID:          "project-1",
+		Name:        "Todo",
+		Slug:        "todo",
+		Description: null.StringFrom("A todo api"),
+	}
+
+	option := aa.CodeGenerationOption{
+		Language:  aa.LanguageNodeJS,
+		Framework: "Express",
+		Image:     "node:20-alpine",
+	}
+
+	code := &aa.CodeGeneration{
+		FileContents: []aa.FileContent{
+			{Filename: "index.js", Content: "console.log('todo')"},
+			{Filename: "../escape.js", Content: "outside"},
+		},
+		InstallCommands: []string{"npm install"},
+		RunCommands:     "node index.js",
+		EnvVars: []aa.CodeGenEnvVar{
+			{Key: "LOG_LEVEL", Value: "info"},
+			{Key: "DATABASE_URL", Value: "postgres://generated"},
+		},
+	}
+
+	endpoints := []*exportEndpoint{
+		{
+			endpoint: &models.Endpoint{Name: "List todos", Method: models.EndpointMethodGet, Path: "/todos", CodeGeneration: code},
+			secrets:  []string{"DATABASE_URL", "API_KEY"},
+		},
+		{
+			endpoint: &models.Endpoint{Name: "List todos", Method: models.EndpointMethodGet, Path: "/v2/todos", CodeGeneration: code},
+		},
+		{
+			endpoint: &models.Endpoint{Name: "Create todo", Method: models.EndpointMethodPost, Path: "/todos"},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeProjectExport(&buf, project, option, endpoints))
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+
+	for _, file := range reader.File {
+		f, err := file.Open()
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(f)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		files[file.Name] = string(content)
+	}
+
+	require.ElementsMatch(t, []string{
+		"todo/list-todos/index.js",
+		"todo/list-todos/.env.example",
+		"todo/list-todos/Dockerfile",
+		"todo/list-todos-2/index.js",
+		"todo/list-todos-2/.env.example",
+		"todo/list-todos-2/Dockerfile",
+		"todo/README.md",
+	}, keys(files))
+
+	require.Equal(t, "# the port the server listens on\nB0_PORT=8080\nLOG_LEVEL=info\n\n# secrets, fill in their values\nDATABASE_URL=\nAPI_KEY=\n", files["todo/list-todos/.env.example"])
+	require.NotContains(t, files["todo/list-todos/.env.example"], "postgres://generated")
+
+	require.Equal(t, `FROM node:20-alpine
+
+WORKDIR /app
+
+COPY . .
+
+ENV NODE_ENV=development
+
+RUN cd /app && NODE_ENV=development npm install
+
+ENV B0_PORT=8080
+
+EXPOSE 8080
+
+CMD ["/bin/sh","-c","NODE_ENV=production node index.js"]
+`, files["todo/list-todos/Dockerfile"])
+
+	readme := files["todo/README.md"]
+	require.Contains(t, readme, "# Todo\n\nA todo api\n")
+	require.Contains(t, readme, "| GET /todos | `list-todos/` |")
+	require.Contains(t, readme, "| GET /v2/todos | `list-todos-2/` |")
+	require.Contains(t, readme, "| POST /todos | not generated yet |")
+	require.Contains(t, readme, "## POST /todos")
+}
+
+func keys(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+
+	for name := range m {
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/pkg/gitremote"
+)
+
+// projectCommits turns the code versions of the project, oldest first, into one commit each.
+// The code of an endpoint lives in the directory it's exported to, the versions of deleted endpoints are left out.
+// It returns the commits and the versions they were made from in the same order.
+func projectCommits(endpoints []*models.Endpoint, versions []*models.CodeVersion) ([]gitremote.Commit, []*models.CodeVersion) {
+	exports := make([]*exportEndpoint, 0, len(endpoints))
+
+	for _, endpoint := range endpoints {
+		exports = append(exports, &exportEndpoint{endpoint: endpoint})
+	}
+
+	assignExportDirs(exports)
+
+	dirs := make(map[string]string, len(exports))
+
+	for _, e := range exports {
+		dirs[e.endpoint.ID] = e.dir
+	}
+
+	commits := []gitremote.Commit{}
+	pushed := []*models.CodeVersion{}
+
+	for _, version := range versions {
+		dir, ok := dirs[version.EndpointID.String]
+
+		if !version.EndpointID.Valid || !ok {
+			continue
+		}
+
+		commit := gitremote.Commit{
+			Dir:     dir,
+			Message: commitMessage(dir, version),
+			Time:    version.CreatedAt,
+		}
+
+		if version.Content != nil {
+			for _, file := range version.Content.FileContents {
+				name, ok := exportFilename(file.Filename)
+
+				if !ok {
+					continue
+				}
+
+				commit.Files = append(commit.Files, gitremote.File{
+					Path:    name,
+					Content: file.Content,
+				})
+			}
+		}
+
+		commits = append(commits, commit)
+		pushed = append(pushed, version)
+	}
+
+	return commits, pushed
+}
+
+// commitMessage prefixes the message of the version with the directory of its endpoint
+func commitMessage(dir string, version *models.CodeVersion) string {
+	message := version.CommitMsg
+
+	if message == "" {
+		message = "Update code"
+	}
+
+	return dir + ": " + message
+}
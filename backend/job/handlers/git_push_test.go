@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/gitremote"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_projectCommits(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	endpoints := []*models.Endpoint{
+		{ID: "endpoint-1", Name: "List todos", Method: models.EndpointMethodGet, Path: "/todos"},
+		{ID: "endpoint-2", Name: "List todos", Method: models.EndpointMethodGet, Path: "/v2/todos"},
+	}
+
+	versions := []*models.CodeVersion{
+		{
+			ID:         "version-1",
+			EndpointID: null.StringFrom("endpoint-1"),
+			CommitMsg:  "Generate code for GET /todos",
+			Content: &aa.CodeGeneration{FileContents: []aa.FileContent{
+				{Filename: "./index.js", Content: "v1"},
+				{Filename: "../escape.js", Content: "outside"},
+			}},
+			CreatedAt: start,
+		},
+		{
+			ID:         "version-2",
+			EndpointID: null.StringFrom("deleted-endpoint"),
+			CommitMsg:  "Generate code for POST /todos",
+			CreatedAt:  start.Add(time.Minute),
+		},
+		{
+			ID:         "version-3",
+			EndpointID: null.StringFrom("endpoint-2"),
+			Content:    &aa.CodeGeneration{FileContents: []aa.FileContent{{Filename: "src/app.js", Content: "v2"}}},
+			CreatedAt:  start.Add(2 * time.Minute),
+		},
+		{
+			ID:        "version-4",
+			CommitMsg: "No endpoint",
+			CreatedAt: start.Add(3 * time.Minute),
+		},
+	}
+
+	commits, pushed := projectCommits(endpoints, versions)
+
+	require.Equal(t, []gitremote.Commit{
+		{
+			Dir:     "list-todos",
+			Files:   []gitremote.File{{Path: "index.js", Content: "v1"}},
+			Message: "list-todos: Generate code for GET /todos",
+			Time:    start,
+		},
+		{
+			Dir:     "list-todos-2",
+			Files:   []gitremote.File{{Path: "src/app.js", Content: "v2"}},
+			Message: "list-todos-2: Update code",
+			Time:    start.Add(2 * time.Minute),
+		},
+	}, commits)
+
+	require.Equal(t, []*models.CodeVersion{versions[0], versions[2]}, pushed)
+}
@@ -4,28 +4,35 @@ import (
 	"context"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/guregu/null"
 	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/config"
 	"github.com/mujhtech/b0/database/models"
 	"github.com/mujhtech/b0/database/store"
 	aa "github.com/mujhtech/b0/internal/pkg/agent"
 	"github.com/mujhtech/b0/internal/pkg/encrypt"
 	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/internal/pkg/testrunner"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
 	"github.com/rs/zerolog"
 )
 
 type AgentData struct {
-	Log                string         `json:"log,omitempty"`
-	Message            string         `json:"message,omitempty"`
-	Error              string         `json:"error,omitempty"`
-	Workflows          []*aa.Workflow `json:"workflows,omitempty"`
-	Deploying          bool           `json:"deploying,omitempty"`
-	Code               interface{}    `json:"code,omitempty"`
-	ShouldReloadWindow bool           `json:"should_reload_window,omitempty"`
+	Log                string                  `json:"log,omitempty"`
+	Delta              string                  `json:"delta,omitempty"`
+	Message            string                  `json:"message,omitempty"`
+	Error              string                  `json:"error,omitempty"`
+	Workflows          []*aa.Workflow          `json:"workflows,omitempty"`
+	Diagnostics        []aa.WorkflowDiagnostic `json:"diagnostics,omitempty"`
+	Deploying          bool                    `json:"deploying,omitempty"`
+	Code               interface{}             `json:"code,omitempty"`
+	ShouldReloadWindow bool                    `json:"should_reload_window,omitempty"`
+	TestResult         *testrunner.Result      `json:"test_result,omitempty"`
+	TestResults        []testrunner.Result     `json:"test_results,omitempty"`
+	DownloadURL        string                  `json:"download_url,omitempty"`
 }
 
-func HandleCreateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.Agent, event sse.Streamer) func(context.Context, *asynq.Task) error {
+func HandleCreateWorkflow(aesCfb encrypt.Encrypt, cfg *config.Config, store *store.Store, agent *aa.Agent, event sse.Streamer, webhooks *WebhookDispatcher) func(context.Context, *asynq.Task) error {
 	return func(ctx context.Context, t *asynq.Task) error {
 
 		projectId, err := aesCfb.Decrypt(string(t.Payload()))
@@ -40,7 +47,7 @@ func HandleCreateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.
 			return err
 		}
 
-		catalog, err := aa.GetModelCatalog(project.Model.String)
+		catalog, err := agent.GetModelCatalog(project.Model.String)
 
 		if err != nil {
 			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
@@ -49,7 +56,9 @@ func HandleCreateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.
 			return nil
 		}
 
-		if _, err := checkUsageLimit(ctx, store, project); err != nil {
+		user, err := checkUsageLimit(ctx, store, project)
+
+		if err != nil {
 			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
 				Error: err.Error(),
 			}, event)
@@ -60,65 +69,58 @@ func HandleCreateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.
 			Message: "b0 is working on your request...",
 		}, event)
 
-		workflows, agentToken, err := agent.GenerateWorkflow(ctx, aa.WorkflowGenerationOption{
+		generated, agentToken, err := agent.GenerateWorkflow(ctx, aa.WorkflowGenerationOption{
 			Prompt: project.Description.String,
-		}, aa.WithModel(catalog.Model))
+		}, aa.WithModel(catalog.Model), aa.WithPremium(user.CanUsePremiumModels()), streamAgentDeltas(ctx, project.ID, event))
 
 		if err != nil {
-			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
-				Message: agentToken.Output,
-				Error:   err.Error(),
-			}, event)
-
-			return err
+			createAIUsage(ctx, cfg, store, &models.AIUsage{
+				ProjectID: project.ID,
+				OwnerID:   project.OwnerID,
+				Model:     project.Model.String,
+				UsageType: "workflow",
+				IsPremium: catalog.IsPremium,
+			}, agentToken)
+
+			return failWorkflowGeneration(ctx, project.ID, agentToken, err, event)
 		}
 
+		workflows := generated.Workflows
+
 		sendEvent(ctx, project.ID, sse.EventTypeTaskStarted, AgentData{
 			Message: "b0 is currently generating your workflow...",
 		}, event)
 
 		zerolog.Ctx(ctx).Info().Msgf("workflows: %v", workflows)
 
-		// pick first workflow
-		requestWorkflow := workflows[0]
-
-		endpoint := &models.Endpoint{
-			ID:          uuid.New().String(),
-			OwnerID:     project.OwnerID,
-			ProjectID:   project.ID,
-			Name:        requestWorkflow.Name,
-			Description: null.NewString(requestWorkflow.Instruction, requestWorkflow.Instruction != ""),
-			Path:        requestWorkflow.Url,
-			Method:      models.EndpointMethod(requestWorkflow.Method),
-			Workflows:   workflows,
-			Metadata:    null.NewString("{}", true),
-			IsPublic:    false,
-			Status:      models.EndpointStatusDraft,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
+		// every request node starts the workflow of an endpoint
+		endpoints := []*models.Endpoint{}
 
-		err = store.EndpointRepo.CreateEndpoint(ctx, endpoint)
+		for _, endpointWorkflows := range aa.SplitEndpointWorkflows(workflows) {
+			endpoint := newWorkflowEndpoint(project, endpointWorkflows)
 
-		if err != nil {
-			return err
-		}
+			if err := store.EndpointRepo.CreateEndpoint(ctx, endpoint); err != nil {
+				return err
+			}
 
-		//
-		if err = store.AIUsageRepo.CreateAIUsage(ctx, &models.AIUsage{
-			ID:          uuid.New().String(),
-			ProjectID:   project.ID,
-			EndpointID:  null.NewString(endpoint.ID, true),
-			OwnerID:     project.OwnerID,
-			Model:       project.Model.String,
-			UsageType:   "workflow",
-			InputToken:  agentToken.Input,
-			OutputToken: agentToken.Output,
-			IsPremium:   catalog.IsPremium,
-		}); err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create AI usage")
+			endpoints = append(endpoints, endpoint)
 		}
 
+		endpoint := endpoints[0]
+
+		createAIUsage(ctx, cfg, store, &models.AIUsage{
+			ProjectID:  project.ID,
+			EndpointID: null.NewString(endpoint.ID, true),
+			OwnerID:    project.OwnerID,
+			Model:      project.Model.String,
+			UsageType:  "workflow",
+			IsPremium:  catalog.IsPremium,
+		}, agentToken)
+
+		webhooks.Dispatch(ctx, project.ID, webhook.EventWorkflowGenerated, webhookWorkflowData{
+			Endpoints: toWebhookEndpoints(endpoints),
+		})
+
 		sendEvent(ctx, project.ID, sse.EventTypeTaskUpdate, AgentData{
 			Message:            "b0 has successfully generated your workflow, reloading...",
 			Workflows:          workflows,
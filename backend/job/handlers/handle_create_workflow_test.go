@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleCreateWorkflow(t *testing.T) {
+	type testCase struct {
+		name       string
+		prompt     string
+		mockFn     func(s *store.Store)
+		wantEvents []sse.EventType
+		wantErr    bool
+	}
+
+	user := &models.User{ID: "user-id", SubscriptionPlan: "pro"}
+
+	tests := []testCase{
+		{
+			name:   "should create the endpoint from the recorded workflows",
+			prompt: "Build a todo API",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().
+					CreateAIUsage(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, aiUsage *models.AIUsage) error {
+						require.Equal(t, "gpt-4", aiUsage.Model)
+						require.Equal(t, "workflow", aiUsage.UsageType)
+						require.Equal(t, int64(10), aiUsage.PromptTokens)
+						require.Equal(t, int64(5), aiUsage.CompletionTokens)
+						return nil
+					})
+
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().
+					CreateEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, endpoint *models.Endpoint) error {
+						require.Equal(t, "List todos", endpoint.Name)
+						require.Equal(t, "/todos", endpoint.Path)
+						require.Equal(t, models.EndpointMethod("GET"), endpoint.Method)
+						require.Len(t, endpoint.Workflows, 2)
+						return nil
+					})
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeAgentDelta,
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskCompleted,
+			},
+		},
+		{
+			name:   "should fail when the usage limit is reached",
+			prompt: "Build a todo API",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(&models.User{ID: "user-id", SubscriptionPlan: "free"}, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 20}, nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskFailed,
+			},
+		},
+		{
+			name:   "should fail when the recorded workflows are invalid",
+			prompt: "Build a user API",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeAgentDelta,
+				sse.EventTypeTaskFailed,
+			},
+		},
+		{
+			name:   "should fail when the agent can't answer",
+			prompt: "Build a blog API",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskFailed,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			deps := newHandlerDeps(t, ctrl)
+
+			pr, _ := deps.store.ProjectRepo.(*mocks.MockProjectRepository)
+			pr.EXPECT().FindProjectByID(gomock.Any(), testProjectID).Times(1).Return(&models.Project{
+				ID:          testProjectID,
+				OwnerID:     "user-id",
+				Description: null.NewString(tt.prompt, true),
+				Model:       null.NewString("gpt-4", true),
+			}, nil)
+
+			if tt.mockFn != nil {
+				tt.mockFn(deps.store)
+			}
+
+			handler := HandleCreateWorkflow(deps.aesCfb, deps.cfg, deps.store, deps.agent, deps.event, nil)
+
+			err := handler(context.Background(), newTestTask(t, deps.aesCfb, "workflow.create", testProjectID))
+
+			require.Equal(t, tt.wantEvents, deps.collectEvents(t))
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
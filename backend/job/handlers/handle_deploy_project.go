@@ -2,11 +2,10 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/guregu/null"
 	"github.com/hibiken/asynq"
 	"github.com/mujhtech/b0/config"
@@ -17,11 +16,14 @@ import (
 	"github.com/mujhtech/b0/internal/pkg/encrypt"
 	secretmanager "github.com/mujhtech/b0/internal/pkg/secret_manager"
 	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/internal/pkg/testrunner"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+	"github.com/mujhtech/b0/services"
 	"github.com/rs/zerolog"
 )
 
-func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *store.Store, agent *aa.Agent, event sse.Streamer, docker *con.Container, secretManager secretmanager.SecretManager) func(context.Context, *asynq.Task) error {
-	return func(ctx context.Context, t *asynq.Task) error {
+func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *store.Store, agent *aa.Agent, event sse.Streamer, docker *con.Container, secretManager secretmanager.SecretManager, webhooks *WebhookDispatcher) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) (err error) {
 
 		projectId, err := aesCfb.Decrypt(string(t.Payload()))
 
@@ -35,6 +37,17 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 			return err
 		}
 
+		webhooks.Dispatch(ctx, project.ID, webhook.EventDeployStarted, webhookDeployData{})
+
+		// the outcome of the deploy is dispatched to the webhooks with its last event
+		event := &deployEvents{Streamer: event, webhooks: webhooks, projectID: project.ID}
+
+		defer func() {
+			if err != nil {
+				event.fail(ctx, err)
+			}
+		}()
+
 		sendEvent(ctx, project.ID, sse.EventTypeTaskStarted, AgentData{
 			Message: "b0 is working on your request...",
 		}, event)
@@ -49,7 +62,9 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 			return fmt.Errorf("no endpoints found for project: %s", project.ID)
 		}
 
-		endpoint := endpoints[0]
+		// the project is served by one app answering the workflows of all its endpoints, its code is kept on the first endpoint with workflows
+		endpoint := deployEndpoint(endpoints)
+		workflows := projectWorkflows(endpoints)
 
 		// delay 1 seconds
 		time.Sleep(1 * time.Second)
@@ -79,16 +94,16 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 			return nil
 		}
 
-		codeGenOption.Workflows = endpoint.Workflows
+		codeGenOption.Workflows = workflows
 		codeGenOption.FrameworkInsructions = fmt.Sprintf(codeGenOption.FrameworkInsructions, serverPort)
 
 		var code *aa.CodeGeneration
 
-		if endpoint.CodeGeneration != nil && len(endpoint.CodeGeneration.FileContents) > 0 {
+		if endpoint.CodeGeneration != nil && len(endpoint.CodeGeneration.FileContents) > 0 && !codeOutdated(endpoint.CodeGeneration, workflows) {
 			code = endpoint.CodeGeneration
 		} else {
 
-			catalog, err := aa.GetModelCatalog(project.Model.String)
+			catalog, err := agent.GetModelCatalog(project.Model.String)
 
 			if err != nil {
 				sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
@@ -97,20 +112,39 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 				return nil
 			}
 
-			if _, err := checkUsageLimit(ctx, store, project); err != nil {
+			user, err := checkUsageLimit(ctx, store, project)
+
+			if err != nil {
 				sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
 					Error: err.Error(),
 				}, event)
 				return nil
 			}
 
+			message := "b0 has started generating the code"
+
+			if endpoint.CodeGeneration != nil && len(endpoint.CodeGeneration.FileContents) > 0 {
+				// only the files affected by the workflow changes are regenerated
+				codeGenOption.Previous = endpoint.CodeGeneration
+				message = "b0 has started updating the code affected by your workflow changes"
+			}
+
 			sendEvent(ctx, project.ID, sse.EventTypeTaskUpdate, AgentData{
-				Message: "b0 has started generating the code",
+				Message: message,
 			}, event)
 
-			newCode, agentToken, err := agent.CodeGeneration(ctx, project.Description.String, codeGenOption, aa.WithModel(catalog.Model))
+			newCode, agentToken, err := agent.CodeGeneration(ctx, project.Description.String, codeGenOption, aa.WithModel(catalog.Model), aa.WithPremium(user.CanUsePremiumModels()), streamAgentDeltas(ctx, project.ID, event))
 
 			if err != nil {
+				createAIUsage(ctx, cfg, store, &models.AIUsage{
+					ProjectID:  project.ID,
+					EndpointID: null.NewString(endpoint.ID, true),
+					OwnerID:    project.OwnerID,
+					Model:      project.Model.String,
+					UsageType:  "code_generation",
+					IsPremium:  catalog.IsPremium,
+				}, agentToken)
+
 				sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
 					Message: agentToken.Output,
 					Error:   err.Error(),
@@ -130,19 +164,18 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 				return nil
 			}
 
-			if err = store.AIUsageRepo.CreateAIUsage(ctx, &models.AIUsage{
-				ID:          uuid.New().String(),
-				ProjectID:   project.ID,
-				EndpointID:  null.NewString(endpoint.ID, true),
-				OwnerID:     project.OwnerID,
-				Model:       project.Model.String,
-				UsageType:   "code_generation",
-				InputToken:  agentToken.Input,
-				OutputToken: agentToken.Output,
-				IsPremium:   catalog.IsPremium,
-			}); err != nil {
-				zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create AI usage")
-			}
+			saveCodeVersion(ctx, store, endpoint, newCode, generationMessage(ctx, store, endpoint, codeGenOption.Previous != nil))
+
+			webhooks.Dispatch(ctx, project.ID, webhook.EventCodeGenerated, newWebhookCodeData(endpoint, newCode))
+
+			createAIUsage(ctx, cfg, store, &models.AIUsage{
+				ProjectID:  project.ID,
+				EndpointID: null.NewString(endpoint.ID, true),
+				OwnerID:    project.OwnerID,
+				Model:      project.Model.String,
+				UsageType:  "code_generation",
+				IsPremium:  catalog.IsPremium,
+			}, agentToken)
 
 			zerolog.Ctx(ctx).Info().Msgf("code generation: %v", newCode)
 
@@ -161,6 +194,31 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 			return nil
 		}
 
+		verifier := &buildVerifier{
+			cfg:     cfg,
+			store:   store,
+			agent:   agent,
+			event:   event,
+			sandbox: docker,
+		}
+
+		if code, err = verifier.verify(ctx, project, endpoint, codeGenOption, code); err != nil {
+			var buildErr *BuildError
+
+			message := "b0 failed to verify the build of your project"
+
+			if errors.As(err, &buildErr) {
+				message = "b0 couldn't build your project, the deploy was stopped"
+			}
+
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: message,
+				Error:   err.Error(),
+			}, event)
+
+			return nil
+		}
+
 		isFolderExist, err := checkIfProjectFolderExists(project.OwnerID, project.Slug)
 
 		if err != nil {
@@ -277,43 +335,15 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 					return err
 				}
 
-				isNodeProject := false
+				runtime := getDeployRuntime(project.Language)
 
-				if strings.Contains(project.Language, "Node") {
-					isNodeProject = true
-				}
+				commands := []string{"/bin/sh", "-c", runtime.command(code)}
 
-				projectCommand := ""
-
-				if isNodeProject {
-					projectCommand = fmt.Sprintf(`
-						cd /app && \
-						NODE_ENV=development %s && \
-						%s && \
-						NODE_ENV=production %s
-					`, strings.Join(code.InstallCommands, " && "), code.BuildCommands, code.RunCommands)
-				} else {
-					projectCommand = fmt.Sprintf(`
-						cd /app && \
-						%s && \
-						%s && \
-						%s
-					`, strings.Join(code.InstallCommands, " && "), code.BuildCommands, code.RunCommands)
-				}
-
-				commands := []string{"/bin/sh", "-c", projectCommand}
-
-				envs := []string{
-					fmt.Sprintf("B0_PORT=%s", serverPort),
-				}
-
-				if isNodeProject {
-					envs = append(envs, "NODE_ENV=development")
-				}
+				envs := runtime.envs(serverPort)
 
 				if code.EnvVars != nil {
 					for _, env := range code.EnvVars {
-						if env.Key == "B0_PORT" {
+						if env.Key == "B0_PORT" || env.Key == "SERVER_PORT" {
 							continue
 						}
 
@@ -326,18 +356,31 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 					}
 				}
 
-				secrets, err := GetEnvVars(ctx, secretManager, project.ID, endpoint.ID)
+				// the app serves every endpoint, so it gets the env vars of each of them
+				for _, served := range endpoints {
+					if served.ID != endpoint.ID && len(served.Workflows) == 0 {
+						continue
+					}
 
-				if err != nil {
-					sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
-						Message: "b0 failed to get env vars",
-						Error:   err.Error(),
-					}, event)
-					return nil
-				}
+					findEnvVarsService := services.FindEnvVarsService{
+						SecretManager: secretManager,
+						ProjectID:     project.ID,
+						EndpointID:    served.ID,
+					}
 
-				for _, secret := range secrets {
-					envs = append(envs, fmt.Sprintf("%s=%s", secret.Name, secret.Value))
+					secrets, err := findEnvVarsService.Run(ctx)
+
+					if err != nil {
+						sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+							Message: "b0 failed to get env vars",
+							Error:   err.Error(),
+						}, event)
+						return nil
+					}
+
+					for _, secret := range secrets {
+						envs = append(envs, fmt.Sprintf("%s=%s", secret.Name, secret.Value))
+					}
 				}
 
 				newContainerID, err := docker.CreateContainer(ctx, con.CreateContainerOption{
@@ -356,8 +399,8 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 						fmt.Sprintf("traefik.http.routers.%s.tls", project.Slug):                       "true",
 						fmt.Sprintf("traefik.http.routers.%s.tls.certresolver", project.Slug):          "myresolver",
 						fmt.Sprintf("traefik.http.services.%s.loadbalancer.server.port", project.Slug): serverPort,
-						"project_id":   project.ID,
-						"project_name": project.Name,
+						con.ProjectLabel: project.ID,
+						"project_name":   project.Name,
 					},
 				})
 
@@ -444,10 +487,27 @@ func HandleDeployProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *stor
 		// delay 1 seconds
 		time.Sleep(1 * time.Second)
 
-		sendEvent(ctx, project.ID, sse.EventTypeTaskCompleted, AgentData{
+		// the deploy is only complete once its test suite ran, its outcome is part of the completion
+		completed := AgentData{
 			Message:   "b0 has successfully deployed your project",
 			Deploying: true,
-		}, event)
+		}
+
+		if len(code.Tests) > 0 {
+			if project.Port.Valid && cfg.Job.DeployTestHost != "" {
+				runner := testrunner.New(fmt.Sprintf("http://%s:%s", cfg.Job.DeployTestHost, project.Port.String))
+				completed.TestResults = runDeployTests(ctx, project.ID, runner, code.Tests, event)
+				completed.Message = deployedMessage(completed.TestResults)
+			} else {
+				sendEvent(ctx, project.ID, sse.EventTypeTestSkipped, AgentData{
+					Message: "b0 skipped the tests of your project, there is no host to reach it on",
+				}, event)
+
+				completed.Message = "b0 has deployed your project, its tests were skipped"
+			}
+		}
+
+		sendEvent(ctx, project.ID, sse.EventTypeTaskCompleted, completed, event)
 
 		return nil
 	}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// The deployment itself needs a docker daemon, only the steps before it are covered here
+func TestHandleDeployProject(t *testing.T) {
+	type testCase struct {
+		name       string
+		project    *models.Project
+		mockFn     func(s *store.Store)
+		wantEvents []sse.EventType
+		wantErr    bool
+	}
+
+	project := &models.Project{
+		ID:          testProjectID,
+		OwnerID:     "user-id",
+		Slug:        "todo-api",
+		Description: null.NewString("Build a todo API", true),
+		Model:       null.NewString("gpt-4", true),
+		Language:    "Go",
+		Framework:   "Chi",
+	}
+
+	tests := []testCase{
+		{
+			name:    "should fail when the language is not supported",
+			project: &models.Project{ID: testProjectID, OwnerID: "user-id", Language: "Cobol", Framework: "None"},
+			mockFn: func(s *store.Store) {
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().FindEndpointByProjectID(gomock.Any(), testProjectID).Times(1).Return([]*models.Endpoint{{ID: "endpoint-id"}}, nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskFailed,
+			},
+		},
+		{
+			name:    "should fail when the code generation can't be answered",
+			project: project,
+			mockFn: func(s *store.Store) {
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().FindEndpointByProjectID(gomock.Any(), testProjectID).Times(1).Return([]*models.Endpoint{{ID: "endpoint-id"}}, nil)
+
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(&models.User{ID: "user-id", SubscriptionPlan: "pro"}, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskFailed,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			deps := newHandlerDeps(t, ctrl)
+
+			pr, _ := deps.store.ProjectRepo.(*mocks.MockProjectRepository)
+			pr.EXPECT().FindProjectByID(gomock.Any(), testProjectID).Times(1).Return(tt.project, nil)
+
+			if tt.mockFn != nil {
+				tt.mockFn(deps.store)
+			}
+
+			handler := HandleDeployProject(deps.aesCfb, deps.cfg, deps.store, deps.agent, deps.event, nil, nil, nil)
+
+			err := handler(context.Background(), newTestTask(t, deps.aesCfb, "project.project", testProjectID))
+
+			require.Equal(t, tt.wantEvents, deps.collectEvents(t))
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_deployEndpoint(t *testing.T) {
+	users := []*aa.Workflow{{Type: aa.WorkflowTypeRequest, Instruction: "GET /users"}}
+	todos := []*aa.Workflow{{Type: aa.WorkflowTypeRequest, Instruction: "GET /todos"}, {Type: aa.WorkflowTypeResponse, Instruction: "respond"}}
+
+	endpoints := []*models.Endpoint{
+		{ID: "empty"},
+		{ID: "users", Workflows: users},
+		{ID: "todos", Workflows: todos},
+	}
+
+	require.Equal(t, "users", deployEndpoint(endpoints).ID)
+	require.Equal(t, "empty", deployEndpoint(endpoints[:1]).ID)
+
+	workflows := projectWorkflows(endpoints)
+
+	require.Equal(t, append(append([]*aa.Workflow{}, users...), todos...), workflows)
+	require.Len(t, aa.SplitEndpointWorkflows(workflows), 2)
+}
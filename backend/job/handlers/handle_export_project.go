@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/config"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/artifact"
+	"github.com/mujhtech/b0/internal/pkg/encrypt"
+	secretmanager "github.com/mujhtech/b0/internal/pkg/secret_manager"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/services"
+	"github.com/rs/zerolog"
+)
+
+// HandleExportProject archives the generated code of the project and publishes a signed download link of the archive
+func HandleExportProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *store.Store, event sse.Streamer, secretManager secretmanager.SecretManager, artifacts artifact.Store) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+
+		projectId, err := aesCfb.Decrypt(string(t.Payload()))
+
+		if err != nil {
+			return err
+		}
+
+		project, err := store.ProjectRepo.FindProjectByID(ctx, projectId)
+
+		if err != nil {
+			return err
+		}
+
+		sendEvent(ctx, project.ID, sse.EventTypeTaskStarted, AgentData{
+			Message: "b0 is exporting your project...",
+		}, event)
+
+		endpoints, err := store.EndpointRepo.FindEndpointByProjectID(ctx, project.ID)
+
+		if err != nil {
+			return err
+		}
+
+		codeGenOption, err := aa.GetLanguageCodeGeneration(project.Language, project.Framework)
+
+		if err != nil {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Error: "failed to find supported language option",
+			}, event)
+
+			return nil
+		}
+
+		findEnvVarsService := services.FindEnvVarsService{
+			SecretManager: secretManager,
+			ProjectID:     project.ID,
+		}
+
+		projectSecrets, err := findEnvVarsService.Run(ctx)
+
+		if err != nil {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 failed to get env vars",
+				Error:   err.Error(),
+			}, event)
+			return nil
+		}
+
+		exports := make([]*exportEndpoint, 0, len(endpoints))
+
+		generated := 0
+
+		for _, endpoint := range endpoints {
+			e := &exportEndpoint{
+				endpoint: endpoint,
+			}
+
+			if !e.hasCode() {
+				exports = append(exports, e)
+				continue
+			}
+
+			findEnvVarsService.EndpointID = endpoint.ID
+
+			secrets, err := findEnvVarsService.Run(ctx)
+
+			if err != nil {
+				sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+					Message: "b0 failed to get env vars",
+					Error:   err.Error(),
+				}, event)
+				return nil
+			}
+
+			// only the names of the secrets are exported
+			for _, secret := range projectSecrets {
+				e.secrets = append(e.secrets, secret.Name)
+			}
+
+			for _, secret := range secrets {
+				e.secrets = append(e.secrets, secret.Name)
+			}
+
+			exports = append(exports, e)
+			generated++
+		}
+
+		if generated == 0 {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 has no generated code to export yet, deploy your project first",
+			}, event)
+			return nil
+		}
+
+		var archive bytes.Buffer
+
+		if err := writeProjectExport(&archive, project, codeGenOption, exports); err != nil {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 failed to export your project",
+				Error:   err.Error(),
+			}, event)
+			return nil
+		}
+
+		name := fmt.Sprintf("%s-%s.zip", exportRoot(project), time.Now().UTC().Format("20060102150405"))
+
+		if err := artifacts.Save(ctx, name, &archive); err != nil {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 failed to save the export of your project",
+				Error:   err.Error(),
+			}, event)
+			return err
+		}
+
+		downloadURL := artifact.NewSigner(cfg.EncryptionKey).URL(artifact.DownloadPath, name, time.Now().Add(cfg.Artifact.DownloadExpiry()))
+
+		zerolog.Ctx(ctx).Info().Msgf("exported project %s to %s", project.ID, name)
+
+		sendEvent(ctx, project.ID, sse.EventTypeProjectExported, AgentData{
+			Message:     "b0 has exported your project",
+			DownloadURL: downloadURL,
+		}, event)
+
+		sendEvent(ctx, project.ID, sse.EventTypeTaskCompleted, AgentData{
+			Message: "b0 has exported your project",
+		}, event)
+
+		return nil
+	}
+}
+
+// HandlePruneArtifacts deletes the artifacts whose download links have expired
+func HandlePruneArtifacts(cfg *config.Config, artifacts artifact.Store) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+
+		pruned, err := artifacts.Prune(ctx, time.Now().Add(-cfg.Artifact.DownloadExpiry()))
+
+		if err != nil {
+			return err
+		}
+
+		zerolog.Ctx(ctx).Info().Msgf("pruned %d expired artifacts", pruned)
+
+		return nil
+	}
+}
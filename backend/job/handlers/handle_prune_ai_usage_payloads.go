@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/rs/zerolog"
+)
+
+// HandlePruneAIUsagePayloads deletes the prompts and responses whose retention has expired
+func HandlePruneAIUsagePayloads(store *store.Store) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+
+		deleted, err := store.AIUsagePayloadRepo.DeleteExpiredAIUsagePayloads(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		zerolog.Ctx(ctx).Info().Msgf("pruned %d expired ai usage payloads", deleted)
+
+		return nil
+	}
+}
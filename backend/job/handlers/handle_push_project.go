@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/config"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/encrypt"
+	"github.com/mujhtech/b0/internal/pkg/gitremote"
+	secretmanager "github.com/mujhtech/b0/internal/pkg/secret_manager"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/rs/zerolog"
+)
+
+// HandlePushProject commits every code version of the project and pushes the commits to the git remote of the project
+func HandlePushProject(aesCfb encrypt.Encrypt, cfg *config.Config, store *store.Store, event sse.Streamer, secretManager secretmanager.SecretManager) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+
+		projectId, err := aesCfb.Decrypt(string(t.Payload()))
+
+		if err != nil {
+			return err
+		}
+
+		project, err := store.ProjectRepo.FindProjectByID(ctx, projectId)
+
+		if err != nil {
+			return err
+		}
+
+		sendEvent(ctx, project.ID, sse.EventTypeTaskStarted, AgentData{
+			Message: "b0 is pushing your project...",
+		}, event)
+
+		remote, err := GetGitRemote(ctx, secretManager, project.ID)
+
+		if err != nil {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 failed to get the git remote",
+				Error:   err.Error(),
+			}, event)
+			return nil
+		}
+
+		if remote == nil || remote.URL == "" {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 has no git remote to push to, set up the git remote of your project first",
+			}, event)
+			return nil
+		}
+
+		endpoints, err := store.EndpointRepo.FindEndpointByProjectID(ctx, project.ID)
+
+		if err != nil {
+			return err
+		}
+
+		versions, err := store.CodeVersionRepo.FindProjectCodeVersions(ctx, project.ID)
+
+		if err != nil {
+			return err
+		}
+
+		commits, pushed := projectCommits(endpoints, versions)
+
+		if len(commits) == 0 {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 has no generated code to push yet, deploy your project first",
+			}, event)
+			return nil
+		}
+
+		hashes, err := gitremote.Push(ctx, *remote, gitremote.Author{
+			Name:  cfg.Git.AuthorName,
+			Email: cfg.Git.AuthorEmail,
+		}, commits)
+
+		if err != nil {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+				Message: "b0 failed to push your project",
+				Error:   err.Error(),
+			}, event)
+			return nil
+		}
+
+		branch := remote.BranchOrDefault()
+
+		for i, version := range pushed {
+			if version.CommitID == hashes[i] && version.Branch == branch {
+				continue
+			}
+
+			if err := store.CodeVersionRepo.UpdateCodeVersionCommit(ctx, version.ID, hashes[i], branch); err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to save the commit of code version %s", version.ID)
+			}
+		}
+
+		zerolog.Ctx(ctx).Info().Msgf("pushed %d commits of project %s to %s", len(hashes), project.ID, branch)
+
+		sendEvent(ctx, project.ID, sse.EventTypeTaskCompleted, AgentData{
+			Message: fmt.Sprintf("b0 has pushed %d commits to %s", len(hashes), branch),
+		}, event)
+
+		return nil
+	}
+}
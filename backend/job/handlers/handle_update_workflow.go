@@ -2,27 +2,51 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/guregu/null"
 	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/config"
 	"github.com/mujhtech/b0/database/models"
 	"github.com/mujhtech/b0/database/store"
 	aa "github.com/mujhtech/b0/internal/pkg/agent"
 	"github.com/mujhtech/b0/internal/pkg/encrypt"
 	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
 	"github.com/mujhtech/b0/internal/util"
 	"github.com/rs/zerolog"
 )
 
 type UpdateWorkflowPayload struct {
-	ProjectId  string `json:"project_id"`
-	EndpointId string `json:"endpoint_id"`
+	ProjectId string `json:"project_id"`
+	// EndpointId is the endpoint the user is chatting about, empty when the chat is about the whole project
+	EndpointId string `json:"endpoint_id,omitempty"`
 	Prompt     string `json:"prompt"`
+	// ChatMessageId is the stored chat message of the prompt, the conversation before it is sent to the agent
+	// and the answer is stored after it
+	ChatMessageId string `json:"chat_message_id,omitempty"`
+	// Modify updates the workflows of the endpoint without classifying the prompt, e.g to implement an imported endpoint
+	Modify bool `json:"modify,omitempty"`
 }
 
-func HandleUpdateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.Agent, event sse.Streamer) func(context.Context, *asynq.Task) error {
+// workflowChat answers a chat prompt, it modifies an endpoint, creates new endpoints or deletes one
+type workflowChat struct {
+	cfg         *config.Config
+	store       *store.Store
+	agent       *aa.Agent
+	event       sse.Streamer
+	webhooks    *WebhookDispatcher
+	project     *models.Project
+	catalog     aa.ModeCatalog
+	user        *models.User
+	prompt      string
+	chatMessage *models.ChatMessage
+	history     []aa.Message
+}
+
+func HandleUpdateWorkflow(aesCfb encrypt.Encrypt, cfg *config.Config, store *store.Store, agent *aa.Agent, event sse.Streamer, webhooks *WebhookDispatcher) func(context.Context, *asynq.Task) error {
 	return func(ctx context.Context, t *asynq.Task) error {
 
 		rawPayload, err := aesCfb.Decrypt(string(t.Payload()))
@@ -43,13 +67,23 @@ func HandleUpdateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.
 			return err
 		}
 
-		endpoint, err := store.EndpointRepo.FindEndpointByID(ctx, payload.EndpointId)
+		endpoints, err := store.EndpointRepo.FindEndpointByProjectID(ctx, project.ID)
 
 		if err != nil {
 			return err
 		}
 
-		catalog, err := aa.GetModelCatalog(project.Model.String)
+		var chatMessage *models.ChatMessage
+
+		if payload.ChatMessageId != "" {
+			chatMessage, err = store.ChatMessageRepo.FindChatMessageByID(ctx, payload.ChatMessageId)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		catalog, err := agent.GetModelCatalog(project.Model.String)
 
 		if err != nil {
 			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
@@ -58,7 +92,9 @@ func HandleUpdateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.
 			return nil
 		}
 
-		if _, err := checkUsageLimit(ctx, store, project); err != nil {
+		user, err := checkUsageLimit(ctx, store, project)
+
+		if err != nil {
 			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
 				Error: err.Error(),
 			}, event)
@@ -69,62 +105,281 @@ func HandleUpdateWorkflow(aesCfb encrypt.Encrypt, store *store.Store, agent *aa.
 			Message: "b0 is working on your request...",
 		}, event)
 
-		workflows, agentToken, err := agent.GenerateWorkflow(ctx, aa.WorkflowGenerationOption{
-			Prompt:    payload.Prompt,
-			Workflows: endpoint.Workflows,
-		}, aa.WithModel(catalog.Model))
+		chat := &workflowChat{
+			cfg:         cfg,
+			store:       store,
+			agent:       agent,
+			event:       event,
+			webhooks:    webhooks,
+			project:     project,
+			catalog:     catalog,
+			user:        user,
+			prompt:      payload.Prompt,
+			chatMessage: chatMessage,
+			history:     chatHistory(ctx, store, chatMessage),
+		}
 
-		if err != nil {
-			sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
-				Message: agentToken.Output,
-				Error:   err.Error(),
-			}, event)
+		// a project without endpoints can only grow
+		intent := &aa.ChatIntent{Intent: aa.ChatIntentCreateEndpoints}
 
-			return err
+		switch {
+		case payload.Modify:
+			intent = &aa.ChatIntent{Intent: aa.ChatIntentModifyEndpoint, EndpointID: payload.EndpointId}
+
+			if findEndpoint(endpoints, payload.EndpointId) == nil {
+				sendEvent(ctx, project.ID, sse.EventTypeTaskFailed, AgentData{
+					Message: "b0 couldn't find the endpoint to update",
+				}, event)
+				return nil
+			}
+		case len(endpoints) > 0:
+			intent, err = chat.classify(ctx, payload.EndpointId, endpoints)
+
+			if err != nil {
+				return err
+			}
 		}
 
-		sendEvent(ctx, project.ID, sse.EventTypeTaskStarted, AgentData{
-			Message: "b0 is currently generating your workflow...",
-		}, event)
+		switch intent.Intent {
+		case aa.ChatIntentCreateEndpoints:
+			return chat.createEndpoints(ctx, intent, endpoints)
+		case aa.ChatIntentDeleteEndpoint:
+			return chat.deleteEndpoint(ctx, intent, findEndpoint(endpoints, intent.EndpointID))
+		default:
+			return chat.modifyEndpoint(ctx, intent, findEndpoint(endpoints, intent.EndpointID))
+		}
+	}
+}
 
-		zerolog.Ctx(ctx).Info().Msgf("workflows: %v", workflows)
+// classify asks the agent what the prompt does with the endpoints of the project
+func (c *workflowChat) classify(ctx context.Context, endpointID string, endpoints []*models.Endpoint) (*aa.ChatIntent, error) {
+	intent, agentToken, err := c.agent.ClassifyChatIntent(ctx, aa.ChatIntentOption{
+		Prompt:     c.prompt,
+		EndpointID: endpointID,
+		Endpoints:  chatEndpoints(endpoints),
+		History:    c.history,
+	}, aa.WithModel(c.catalog.Model), aa.WithPremium(c.user.CanUsePremiumModels()))
 
-		err = store.EndpointRepo.UpdateEndpoint(ctx, endpoint.ID, &models.Endpoint{
-			Workflows: workflows,
-		})
+	createAIUsage(ctx, c.cfg, c.store, &models.AIUsage{
+		ProjectID:  c.project.ID,
+		EndpointID: null.NewString(endpointID, endpointID != ""),
+		OwnerID:    c.project.OwnerID,
+		Model:      c.project.Model.String,
+		UsageType:  "chat_intent",
+		IsPremium:  c.catalog.IsPremium,
+	}, agentToken)
 
-		if err != nil {
+	if err != nil {
+		sendEvent(ctx, c.project.ID, sse.EventTypeTaskFailed, AgentData{
+			Message: "b0 couldn't understand your request, please try again",
+			Error:   err.Error(),
+		}, c.event)
+
+		return nil, err
+	}
+
+	return intent, nil
+}
+
+// modifyEndpoint updates the workflows of the endpoint from the prompt
+func (c *workflowChat) modifyEndpoint(ctx context.Context, intent *aa.ChatIntent, endpoint *models.Endpoint) error {
+	generated, agentToken, err := c.agent.GenerateWorkflow(ctx, aa.WorkflowGenerationOption{
+		Prompt:    c.prompt,
+		Workflows: endpoint.Workflows,
+		History:   c.history,
+	}, aa.WithModel(c.catalog.Model), aa.WithPremium(c.user.CanUsePremiumModels()), streamAgentDeltas(ctx, c.project.ID, c.event))
+
+	workflows, err := c.checkWorkflows(ctx, endpoint.ID, generated, agentToken, err)
+
+	if workflows == nil {
+		return err
+	}
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskStarted, AgentData{
+		Message: "b0 is currently generating your workflow...",
+	}, c.event)
+
+	zerolog.Ctx(ctx).Info().Msgf("workflows: %v", workflows)
+
+	err = c.store.EndpointRepo.UpdateEndpoint(ctx, endpoint.ID, &models.Endpoint{
+		Workflows: workflows,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.createAIUsage(ctx, endpoint.ID, agentToken)
+
+	c.reply(ctx, workflowReply(explanationOf(generated, intent), endpoint.Workflows, workflows))
+
+	c.webhooks.Dispatch(ctx, c.project.ID, webhook.EventWorkflowGenerated, webhookWorkflowData{
+		Endpoints: toWebhookEndpoints([]*models.Endpoint{endpoint}),
+	})
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskUpdate, AgentData{
+		Message:            "b0 has successfully updated your workflow, reloading...",
+		Workflows:          workflows,
+		ShouldReloadWindow: true,
+	}, c.event)
+
+	// delay 1 seconds
+	time.Sleep(1 * time.Second)
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskCompleted, AgentData{
+		Message: "b0 has successfully generated your workflow",
+	}, c.event)
+
+	return nil
+}
+
+// createEndpoints creates an endpoint for every request node of the workflows generated from the prompt
+func (c *workflowChat) createEndpoints(ctx context.Context, intent *aa.ChatIntent, existing []*models.Endpoint) error {
+	generated, agentToken, err := c.agent.GenerateWorkflow(ctx, aa.WorkflowGenerationOption{
+		Prompt:    c.prompt,
+		History:   c.history,
+		Endpoints: chatEndpoints(existing),
+	}, aa.WithModel(c.catalog.Model), aa.WithPremium(c.user.CanUsePremiumModels()), streamAgentDeltas(ctx, c.project.ID, c.event))
+
+	workflows, err := c.checkWorkflows(ctx, "", generated, agentToken, err)
+
+	if workflows == nil {
+		return err
+	}
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskStarted, AgentData{
+		Message: "b0 is currently creating your endpoints...",
+	}, c.event)
+
+	created := []string{}
+	endpoints := []*models.Endpoint{}
+	endpointID := ""
+
+	for _, endpointWorkflows := range aa.SplitEndpointWorkflows(workflows) {
+		endpoint := newWorkflowEndpoint(c.project, endpointWorkflows)
+
+		if err := c.store.EndpointRepo.CreateEndpoint(ctx, endpoint); err != nil {
 			return err
 		}
 
-		//
-		if err = store.AIUsageRepo.CreateAIUsage(ctx, &models.AIUsage{
-			ID:          uuid.New().String(),
-			ProjectID:   project.ID,
-			EndpointID:  null.NewString(endpoint.ID, true),
-			OwnerID:     project.OwnerID,
-			Model:       project.Model.String,
-			UsageType:   "workflow",
-			InputToken:  agentToken.Input,
-			OutputToken: agentToken.Output,
-			IsPremium:   catalog.IsPremium,
-		}); err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create AI usage")
+		if endpointID == "" {
+			endpointID = endpoint.ID
 		}
 
-		sendEvent(ctx, project.ID, sse.EventTypeTaskUpdate, AgentData{
-			Message:            "b0 has successfully updated your workflow, reloading...",
-			Workflows:          workflows,
-			ShouldReloadWindow: true,
-		}, event)
+		created = append(created, "- "+endpointLabel(endpoint))
+		endpoints = append(endpoints, endpoint)
+	}
 
-		// delay 1 seconds
-		time.Sleep(1 * time.Second)
+	c.createAIUsage(ctx, endpointID, agentToken)
 
-		sendEvent(ctx, project.ID, sse.EventTypeTaskCompleted, AgentData{
-			Message: "b0 has successfully generated your workflow",
-		}, event)
+	c.reply(ctx, joinReply(explanationOf(generated, intent), "Created endpoints:\n"+strings.Join(created, "\n")))
+
+	c.webhooks.Dispatch(ctx, c.project.ID, webhook.EventWorkflowGenerated, webhookWorkflowData{
+		Endpoints: toWebhookEndpoints(endpoints),
+	})
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskUpdate, AgentData{
+		Message:            fmt.Sprintf("b0 has successfully created %d endpoint(s), reloading...", len(created)),
+		Workflows:          workflows,
+		ShouldReloadWindow: true,
+	}, c.event)
+
+	// delay 1 seconds
+	time.Sleep(1 * time.Second)
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskCompleted, AgentData{
+		Message: "b0 has successfully created your endpoints",
+	}, c.event)
 
-		return nil
+	return nil
+}
+
+// deleteEndpoint deletes the endpoint the prompt asked to remove
+func (c *workflowChat) deleteEndpoint(ctx context.Context, intent *aa.ChatIntent, endpoint *models.Endpoint) error {
+	if err := c.store.EndpointRepo.DeleteEndpoint(ctx, endpoint.ID); err != nil {
+		return err
 	}
+
+	c.reply(ctx, joinReply(intent.Explanation, "Deleted endpoint: "+endpointLabel(endpoint)))
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskUpdate, AgentData{
+		Message:            fmt.Sprintf("b0 has deleted the endpoint %s, reloading...", endpointLabel(endpoint)),
+		ShouldReloadWindow: true,
+	}, c.event)
+
+	sendEvent(ctx, c.project.ID, sse.EventTypeTaskCompleted, AgentData{
+		Message: "b0 has successfully deleted your endpoint",
+	}, c.event)
+
+	return nil
+}
+
+// checkWorkflows returns the validated workflows of the agent, or nil and the error to return from the task
+// after the failure was recorded and published
+func (c *workflowChat) checkWorkflows(ctx context.Context, endpointID string, generated *aa.WorkflowGenerationResponse, agentToken *aa.AgentToken, err error) ([]*aa.Workflow, error) {
+	if err != nil {
+		c.createAIUsage(ctx, endpointID, agentToken)
+
+		return nil, failWorkflowGeneration(ctx, c.project.ID, agentToken, err, c.event)
+	}
+
+	return generated.Workflows, nil
+}
+
+func (c *workflowChat) createAIUsage(ctx context.Context, endpointID string, agentToken *aa.AgentToken) {
+	createAIUsage(ctx, c.cfg, c.store, &models.AIUsage{
+		ProjectID:  c.project.ID,
+		EndpointID: null.NewString(endpointID, endpointID != ""),
+		OwnerID:    c.project.OwnerID,
+		Model:      c.project.Model.String,
+		UsageType:  "workflow",
+		IsPremium:  c.catalog.IsPremium,
+	}, agentToken)
+}
+
+// reply stores the answer to the chat message, prompts sent without a chat message aren't part of a conversation
+func (c *workflowChat) reply(ctx context.Context, content string) {
+	if c.chatMessage != nil {
+		createChatReply(ctx, c.store, c.chatMessage, content)
+	}
+}
+
+// findEndpoint returns the endpoint with the id, the intent was checked against the endpoints of the project
+func findEndpoint(endpoints []*models.Endpoint, id string) *models.Endpoint {
+	for _, endpoint := range endpoints {
+		if endpoint.ID == id {
+			return endpoint
+		}
+	}
+
+	return nil
+}
+
+func endpointLabel(endpoint *models.Endpoint) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", strings.ToUpper(string(endpoint.Method)), endpoint.Path))
+}
+
+// explanationOf prefers the explanation of the generated workflows to the one of the intent
+func explanationOf(generated *aa.WorkflowGenerationResponse, intent *aa.ChatIntent) string {
+	if generated.Explanation != "" {
+		return generated.Explanation
+	}
+
+	return intent.Explanation
+}
+
+// chatEndpoints summarizes the endpoints of the project for the agent
+func chatEndpoints(endpoints []*models.Endpoint) []aa.ChatEndpoint {
+	summaries := make([]aa.ChatEndpoint, 0, len(endpoints))
+
+	for _, endpoint := range endpoints {
+		summaries = append(summaries, aa.ChatEndpoint{
+			ID:     endpoint.ID,
+			Name:   endpoint.Name,
+			Method: string(endpoint.Method),
+			Path:   endpoint.Path,
+		})
+	}
+
+	return summaries
 }
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/internal/util"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleUpdateWorkflow(t *testing.T) {
+	type testCase struct {
+		name          string
+		prompt        string
+		chatMessageId string
+		modify        bool
+		mockFn        func(s *store.Store)
+		wantEvents    []sse.EventType
+		wantErr       bool
+	}
+
+	user := &models.User{ID: "user-id", SubscriptionPlan: "pro"}
+
+	tests := []testCase{
+		{
+			name:   "should update the endpoint with the recorded workflows",
+			prompt: "Filter the todos by due date",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().
+					UpdateEndpoint(gomock.Any(), "endpoint-id", gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, _ string, endpoint *models.Endpoint) error {
+						require.Len(t, endpoint.Workflows, 2)
+						require.Equal(t, []string{"due_before"}, endpoint.Workflows[0].Variables)
+						return nil
+					})
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeAgentDelta,
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskCompleted,
+			},
+		},
+		{
+			name:   "should update the endpoint without classifying a targeted prompt",
+			prompt: "Filter the todos by due date",
+			modify: true,
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				// only the usage of the workflow generation, no chat intent
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().UpdateEndpoint(gomock.Any(), "endpoint-id", gomock.Any()).Times(1).Return(nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeAgentDelta,
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskCompleted,
+			},
+		},
+		{
+			name:          "should answer a follow-up prompt with the chat history",
+			prompt:        "Use the due_at field",
+			chatMessageId: "message-3",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+				message := &models.ChatMessage{
+					ID:         "message-3",
+					OwnerID:    "user-id",
+					ProjectID:  testProjectID,
+					EndpointID: null.NewString("endpoint-id", true),
+					Role:       models.ChatMessageRoleUser,
+					Content:    "Use the due_at field",
+					CreatedAt:  time.Date(2025, 3, 1, 10, 2, 0, 0, time.UTC),
+				}
+
+				cr, _ := s.ChatMessageRepo.(*mocks.MockChatMessageRepository)
+				cr.EXPECT().FindChatMessageByID(gomock.Any(), "message-3").Times(1).Return(message, nil)
+				cr.EXPECT().
+					FindChatMessages(gomock.Any(), store.ChatMessageFilter{
+						ProjectID:  testProjectID,
+						EndpointID: "endpoint-id",
+						Before:     message.CreatedAt,
+						Limit:      chatHistoryLimit,
+					}).
+					Times(1).
+					Return([]*models.ChatMessage{
+						{ID: "message-1", Role: models.ChatMessageRoleUser, Content: "Filter the todos by due date"},
+						{ID: "message-2", Role: models.ChatMessageRoleAssistant, Content: "Which field holds the due date of a todo?\nThe workflow is unchanged."},
+					}, nil)
+				cr.EXPECT().
+					CreateChatMessage(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, reply *models.ChatMessage) error {
+						require.Equal(t, models.ChatMessageRoleAssistant, reply.Role)
+						require.Equal(t, "endpoint-id", reply.EndpointID.String)
+						require.Contains(t, reply.Content, "filtered by their due_at field")
+						require.Contains(t, reply.Content, `- added workflows[0].variables: ["due_before"]`)
+						return nil
+					})
+
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().UpdateEndpoint(gomock.Any(), "endpoint-id", gomock.Any()).Times(1).Return(nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeAgentDelta,
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskCompleted,
+			},
+		},
+		{
+			name:   "should create the endpoints asked for in the chat",
+			prompt: "Add endpoints to create and delete a todo",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+				created := []*models.Endpoint{}
+
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().
+					CreateEndpoint(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ context.Context, endpoint *models.Endpoint) error {
+						created = append(created, endpoint)
+
+						if len(created) == 2 {
+							require.Equal(t, "/todos", created[0].Path)
+							require.Equal(t, models.EndpointMethod("POST"), created[0].Method)
+							require.Len(t, created[0].Workflows, 2)
+							require.Equal(t, "/todos/{id}", created[1].Path)
+							require.Equal(t, models.EndpointMethod("DELETE"), created[1].Method)
+							require.Len(t, created[1].Workflows, 2)
+						}
+
+						return nil
+					})
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeAgentDelta,
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskCompleted,
+			},
+		},
+		{
+			name:   "should delete the endpoint asked for in the chat",
+			prompt: "Remove the endpoint listing the todos",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().DeleteEndpoint(gomock.Any(), "endpoint-id").Times(1).Return(nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskCompleted,
+			},
+		},
+		{
+			name:   "should fail when the agent can't answer",
+			prompt: "Add authentication",
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(user, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskStarted,
+				sse.EventTypeTaskFailed,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			deps := newHandlerDeps(t, ctrl)
+
+			pr, _ := deps.store.ProjectRepo.(*mocks.MockProjectRepository)
+			pr.EXPECT().FindProjectByID(gomock.Any(), testProjectID).Times(1).Return(&models.Project{
+				ID:      testProjectID,
+				OwnerID: "user-id",
+				Model:   null.NewString("gpt-4", true),
+			}, nil)
+
+			er, _ := deps.store.EndpointRepo.(*mocks.MockEndpointRepository)
+			er.EXPECT().FindEndpointByProjectID(gomock.Any(), testProjectID).Times(1).Return([]*models.Endpoint{{
+				ID:        "endpoint-id",
+				ProjectID: testProjectID,
+				Name:      "List todos",
+				Method:    "GET",
+				Path:      "/todos",
+				Workflows: []*aa.Workflow{
+					{Type: aa.WorkflowTypeRequest, Instruction: "List the todos", Name: "List todos", Url: "/todos", Method: "GET"},
+					{Type: aa.WorkflowTypeResponse, Instruction: "Return the todos", Status: "200", Body: map[string]interface{}{"todos": []interface{}{}}},
+				},
+			}}, nil)
+
+			if tt.mockFn != nil {
+				tt.mockFn(deps.store)
+			}
+
+			payload, err := util.MarshalJSONToString(UpdateWorkflowPayload{
+				ProjectId:     testProjectID,
+				EndpointId:    "endpoint-id",
+				Prompt:        tt.prompt,
+				ChatMessageId: tt.chatMessageId,
+				Modify:        tt.modify,
+			})
+			require.NoError(t, err)
+
+			handler := HandleUpdateWorkflow(deps.aesCfb, deps.cfg, deps.store, deps.agent, deps.event, nil)
+
+			err = handler(context.Background(), newTestTask(t, deps.aesCfb, "workflow.update", payload))
+
+			require.Equal(t, tt.wantEvents, deps.collectEvents(t))
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
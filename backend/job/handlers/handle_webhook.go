@@ -2,49 +2,82 @@ package handlers
 
 import (
 	"context"
+	"errors"
 
 	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/config"
+	"github.com/mujhtech/b0/database/models"
 	"github.com/mujhtech/b0/database/store"
 	"github.com/mujhtech/b0/internal/pkg/encrypt"
+	"github.com/mujhtech/b0/internal/pkg/safehttp"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+	"github.com/rs/zerolog"
 )
 
-func HandleWebhook(aesCfb encrypt.Encrypt, store *store.Store) func(context.Context, *asynq.Task) error {
+// HandleWebhook makes an attempt of a webhook delivery, a failed attempt is retried by asynq with backoff until
+// the retries run out. Every attempt is recorded on the delivery.
+func HandleWebhook(aesCfb encrypt.Encrypt, cfg *config.Config, store *store.Store) func(context.Context, *asynq.Task) error {
+	client := safehttp.NewClient(cfg.Webhook.Timeout())
+
 	return func(ctx context.Context, t *asynq.Task) error {
 
-		// payload, err := aesCfb.Decrypt(string(t.Payload()))
-		// if err != nil {
-		// 	return err
-		// }
-
-		// var data struct {
-		// 	Event string          `json:"event"`
-		// 	Data  json.RawMessage `json:"data"`
-		// }
-
-		// if err := json.Unmarshal([]byte(payload), &data); err != nil {
-		// 	return err
-		// }
-
-		// // Get webhooks for the app that match this event
-		// webhooks, err := store.WebhookRepo.FindWebhooksByEvent(ctx, data.Event)
-		// if err != nil {
-		// 	return err
-		// }
-
-		// // Send webhook notifications
-		// for _, webhook := range webhooks {
-		// 	if err := sendWebhookNotification(webhook.URL, data); err != nil {
-		// 		// Log error but continue with other webhooks
-		// 		log.Error().Err(err).Msg("failed to send webhook notification")
-		// 	}
-		// }
+		deliveryId, err := aesCfb.Decrypt(string(t.Payload()))
+
+		if err != nil {
+			return err
+		}
+
+		delivery, err := store.WebhookDeliveryRepo.FindWebhookDeliveryByID(ctx, deliveryId)
+
+		if err != nil {
+			return err
+		}
+
+		hook, err := store.WebhookRepo.FindWebhookByID(ctx, delivery.WebhookID)
+
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+
+		if hook == nil || !hook.IsActive {
+			// the delivery is dropped, the webhook was deleted or disabled after the event
+			delivery.Status = models.WebhookDeliveryStatusFailed
+			delivery.Error = "the webhook was deleted or disabled"
+
+			reportJobFailure(ctx, errors.New(delivery.Error))
+
+			return store.WebhookDeliveryRepo.UpdateWebhookDelivery(ctx, delivery)
+		}
+
+		res, sendErr := webhook.Send(ctx, client, webhook.Request{
+			URL:        hook.URL,
+			Secret:     hook.Secret,
+			DeliveryID: delivery.ID,
+			Event:      delivery.Event,
+			Body:       delivery.Payload,
+		})
+
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, ok := asynq.GetMaxRetry(ctx)
+
+		attemptErr := recordWebhookAttempt(delivery, res, sendErr, !ok || retried >= maxRetry)
+
+		if err := store.WebhookDeliveryRepo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to record the attempt of webhook delivery %s", delivery.ID)
+		}
+
+		if attemptErr != nil && delivery.Status == models.WebhookDeliveryStatusPending {
+			return attemptErr
+		}
+
+		if attemptErr != nil {
+			zerolog.Ctx(ctx).Warn().Err(attemptErr).Msgf("webhook delivery %s failed after %d attempts", delivery.ID, delivery.Attempts)
+			reportJobFailure(ctx, attemptErr)
+			return nil
+		}
+
+		reportJobResult(ctx, "delivered %s to webhook %s in %d attempts", delivery.Event, hook.ID, delivery.Attempts)
 
 		return nil
 	}
 }
-
-// func sendWebhookNotification(url string, data interface{}) error {
-// 	// Implement HTTP POST to webhook URL
-// 	// Add retry logic, timeout etc
-// 	return nil
-// }
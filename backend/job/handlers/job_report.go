@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mujhtech/b0/internal/pkg/sse"
+)
+
+// maxJobResult is how much of the result summary of a job is kept
+const maxJobResult = 1024
+
+type jobReportKey struct{}
+
+// JobReport is the outcome a job reports while it runs, the last completed task is its result and a failed task
+// fails the job even when its handler returns without an error
+type JobReport struct {
+	mu      sync.Mutex
+	result  string
+	failure string
+}
+
+// WithJobReport returns a context the handler of a job reports its outcome to
+func WithJobReport(ctx context.Context) (context.Context, *JobReport) {
+	report := &JobReport{}
+	return context.WithValue(ctx, jobReportKey{}, report), report
+}
+
+// Result is the summary of what the job did
+func (r *JobReport) Result() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.result
+}
+
+// Failure is the error of the failed task of the job, empty when no task failed
+func (r *JobReport) Failure() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.failure
+}
+
+func (r *JobReport) set(result, failure string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.result = truncateJobResult(result)
+
+	if failure != "" {
+		r.failure = failure
+	}
+}
+
+// reportJobResult records the summary of what the job did, when the job is reported on
+func reportJobResult(ctx context.Context, format string, args ...interface{}) {
+	if report, ok := ctx.Value(jobReportKey{}).(*JobReport); ok {
+		report.set(fmt.Sprintf(format, args...), "")
+	}
+}
+
+// reportJobFailure fails the job, when the job is reported on, though its handler returns without an error
+func reportJobFailure(ctx context.Context, err error) {
+	if report, ok := ctx.Value(jobReportKey{}).(*JobReport); ok {
+		report.set("", err.Error())
+	}
+}
+
+// reportJobEvent records the outcome of the task events of the job
+func reportJobEvent(ctx context.Context, eventType sse.EventType, data AgentData) {
+	report, ok := ctx.Value(jobReportKey{}).(*JobReport)
+
+	if !ok {
+		return
+	}
+
+	switch eventType {
+	case sse.EventTypeTaskCompleted:
+		report.set(data.Message, "")
+	case sse.EventTypeTaskFailed:
+		failure := data.Error
+
+		// the message of a failed task can be the whole output of the agent, the error is the failure
+		if failure == "" {
+			failure = truncateJobResult(data.Message)
+		}
+
+		report.set("", failure)
+	}
+}
+
+func truncateJobResult(result string) string {
+	if len(result) <= maxJobResult {
+		return result
+	}
+
+	return result[:maxJobResult]
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		report      func(ctx context.Context)
+		wantResult  string
+		wantFailure string
+	}{
+		{
+			name: "the completed task is the result",
+			report: func(ctx context.Context) {
+				reportJobEvent(ctx, sse.EventTypeTaskStarted, AgentData{Message: "b0 is deploying your project"})
+				reportJobEvent(ctx, sse.EventTypeTaskCompleted, AgentData{Message: "b0 has successfully deployed your project"})
+			},
+			wantResult: "b0 has successfully deployed your project",
+		},
+		{
+			name: "a failed task fails the job with its error",
+			report: func(ctx context.Context) {
+				reportJobEvent(ctx, sse.EventTypeTaskFailed, AgentData{Message: "the agent output", Error: "unsupported language"})
+			},
+			wantFailure: "unsupported language",
+		},
+		{
+			name: "a failed task without error fails the job with its message",
+			report: func(ctx context.Context) {
+				reportJobEvent(ctx, sse.EventTypeTaskFailed, AgentData{Message: "b0 failed to generate your workflow"})
+			},
+			wantFailure: "b0 failed to generate your workflow",
+		},
+		{
+			name: "a reported failure fails the job",
+			report: func(ctx context.Context) {
+				reportJobFailure(ctx, errors.New("the webhook answered with status 500"))
+			},
+			wantFailure: "the webhook answered with status 500",
+		},
+		{
+			name: "the result is truncated",
+			report: func(ctx context.Context) {
+				reportJobResult(ctx, "%s", strings.Repeat("a", maxJobResult+1))
+			},
+			wantResult: strings.Repeat("a", maxJobResult),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, report := WithJobReport(context.Background())
+
+			tt.report(ctx)
+
+			require.Equal(t, tt.wantResult, report.Result())
+			require.Equal(t, tt.wantFailure, report.Failure())
+		})
+	}
+}
@@ -6,17 +6,22 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"math/rand/v2"
 
 	"github.com/docker/docker/pkg/archive"
-	"github.com/mujhtech/b0/api/dto"
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/config"
 	"github.com/mujhtech/b0/database/models"
 	"github.com/mujhtech/b0/database/store"
 	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/gitremote"
 	secretmanager "github.com/mujhtech/b0/internal/pkg/secret_manager"
 	"github.com/mujhtech/b0/internal/pkg/sse"
 	"github.com/mujhtech/b0/internal/util"
+	"github.com/mujhtech/b0/services"
 	"github.com/rs/zerolog"
 )
 
@@ -37,15 +42,32 @@ func sendEvent(ctx context.Context, projectID string, eventType sse.EventType, d
 		errorMsg = sse.FailedToPublishTaskCompletedEvent
 	case sse.EventTypeTaskFailed:
 		errorMsg = sse.FailedToPublishTaskFailedEvent
+	case sse.EventTypeAgentDelta:
+		errorMsg = sse.FailedToPublishAgentDeltaEvent
+	case sse.EventTypeTestStarted, sse.EventTypeTestPassed, sse.EventTypeTestFailed, sse.EventTypeTestCompleted:
+		errorMsg = sse.FailedToPublishTestEvent
+	case sse.EventTypeProjectExported:
+		errorMsg = sse.FailedToPublishExportEvent
 	default:
 		errorMsg = "unknown event type"
 	}
 
+	reportJobEvent(ctx, eventType, data)
+
 	if err := event.Publish(ctx, projectID, eventType, data); err != nil {
 		zerolog.Ctx(ctx).Error().Msgf("%s: %v", errorMsg, err)
 	}
 }
 
+// streamAgentDeltas publishes every partial completion of the agent to the project channel
+func streamAgentDeltas(ctx context.Context, projectID string, event sse.Streamer) agent.OptionFunc {
+	return agent.WithDeltaHandler(func(delta string) {
+		sendEvent(ctx, projectID, sse.EventTypeAgentDelta, AgentData{
+			Delta: delta,
+		}, event)
+	})
+}
+
 func checkIfProjectFolderExists(userId, projectSlug string) (bool, error) {
 	// check if folder exists
 	path := filepath.Join(TempFolder, userId, projectSlug)
@@ -161,29 +183,118 @@ func checkUsageLimit(ctx context.Context, s *store.Store, project *models.Projec
 	return user, nil
 }
 
-func GetEnvVars(ctx context.Context, secretManager secretmanager.SecretManager, projectId, endpointId string) ([]*dto.Secret, error) {
-
-	secrets := []*dto.Secret{}
+// createAIUsage records the usage of the agent token, failures are only logged so they never fail the job
+func createAIUsage(ctx context.Context, cfg *config.Config, s *store.Store, usage *models.AIUsage, agentToken *agent.AgentToken) {
+	createAIUsageService := services.CreateAIUsageService{
+		AIUsageRepo:        s.AIUsageRepo,
+		AIUsagePayloadRepo: s.AIUsagePayloadRepo,
+		Usage:              usage,
+		AgentToken:         agentToken,
+		PromptRetention:    cfg.Agent.PromptRetention(),
+	}
 
-	secretId := projectId
+	if _, err := createAIUsageService.Run(ctx); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create AI usage")
+	}
+}
 
-	if endpointId != "" {
-		secretId = fmt.Sprintf("%s_%s", secretId, endpointId)
+// failWorkflowGeneration publishes the failure of a workflow generation and returns the error of the task.
+// Workflows still invalid after the repair turns fail with their diagnostics and aren't retried.
+func failWorkflowGeneration(ctx context.Context, projectID string, agentToken *agent.AgentToken, err error, event sse.Streamer) error {
+	if diagnostics := agent.WorkflowDiagnostics(err); diagnostics != nil {
+		sendEvent(ctx, projectID, sse.EventTypeTaskFailed, AgentData{
+			Message:     "b0 generated an invalid workflow, please try again",
+			Error:       err.Error(),
+			Diagnostics: diagnostics,
+		}, event)
+
+		return nil
 	}
 
-	secretName := fmt.Sprintf("projects/b0/%s/env-variables", secretId)
+	sendEvent(ctx, projectID, sse.EventTypeTaskFailed, AgentData{
+		Message: agentToken.Output,
+		Error:   err.Error(),
+	}, event)
 
-	secret, err := secretManager.GetSecret(ctx, secretName)
+	return err
+}
+
+// GitRemoteSecretName is the name of the secret holding the git remote of the project and its credentials
+func GitRemoteSecretName(projectId string) string {
+	return fmt.Sprintf("projects/b0/%s/git-remote", projectId)
+}
+
+// GetGitRemote returns the git remote of the project, nil when none was configured
+func GetGitRemote(ctx context.Context, secretManager secretmanager.SecretManager, projectId string) (*gitremote.Remote, error) {
+	secret, err := secretManager.GetSecret(ctx, GitRemoteSecretName(projectId))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if secret != nil {
-		if err := util.UnmarshalJSON(secret, &secrets); err != nil {
-			return nil, err
+	if secret == nil {
+		return nil, nil
+	}
+
+	remote := new(gitremote.Remote)
+
+	if err := util.UnmarshalJSON(secret, remote); err != nil {
+		return nil, err
+	}
+
+	return remote, nil
+}
+
+// newWorkflowEndpoint returns the draft endpoint of the workflows, its name, path and method are those of the request node
+func newWorkflowEndpoint(project *models.Project, workflows []*agent.Workflow) *models.Endpoint {
+	requestWorkflow := workflows[0]
+
+	return &models.Endpoint{
+		ID:          uuid.New().String(),
+		OwnerID:     project.OwnerID,
+		ProjectID:   project.ID,
+		Name:        requestWorkflow.Name,
+		Description: null.NewString(requestWorkflow.Instruction, requestWorkflow.Instruction != ""),
+		Path:        requestWorkflow.Url,
+		Method:      models.EndpointMethod(requestWorkflow.Method),
+		Workflows:   workflows,
+		Metadata:    null.NewString("{}", true),
+		IsPublic:    false,
+		Status:      models.EndpointStatusDraft,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// codeOutdated reports whether the workflows changed since the code was generated, code that didn't record its workflows is outdated
+func codeOutdated(code *agent.CodeGeneration, workflows []*agent.Workflow) bool {
+	if code.Workflows == nil {
+		return true
+	}
+
+	changes, err := agent.DiffWorkflows(code.Workflows, workflows)
+
+	return err != nil || len(changes) > 0
+}
+
+// deployEndpoint returns the endpoint keeping the code of the deployed app, the first endpoint with workflows
+func deployEndpoint(endpoints []*models.Endpoint) *models.Endpoint {
+	for _, endpoint := range endpoints {
+		if len(endpoint.Workflows) > 0 {
+			return endpoint
 		}
 	}
 
-	return secrets, nil
+	return endpoints[0]
+}
+
+// projectWorkflows returns the workflows of every endpoint in order, each endpoint's workflow starts with its request node
+func projectWorkflows(endpoints []*models.Endpoint) []*agent.Workflow {
+	var workflows []*agent.Workflow
+
+	for _, endpoint := range endpoints {
+		workflows = append(workflows, endpoint.Workflows...)
+	}
+
+	return workflows
 }
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/config"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/encrypt"
+	"github.com/mujhtech/b0/internal/pkg/pubsub"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testProjectID = "project-id"
+
+// newTestConfig returns a config answering agent completions from the fixtures in testdata/agent.
+// Fixtures are recorded by running the agent with AGENT_REPLAY_MODE=record and AGENT_REPLAY_DIR set.
+func newTestConfig() *config.Config {
+	return &config.Config{
+		Agent: config.Agent{
+			Replay: config.AgentReplay{
+				Mode: config.AgentReplayModeReplay,
+				Dir:  "testdata/agent",
+			},
+		},
+		Pubsub: config.Pubsub{
+			App:         "b0",
+			Namespace:   "b0",
+			ChannelSize: 100,
+		},
+	}
+}
+
+func newTestStore(ctrl *gomock.Controller) *store.Store {
+	return &store.Store{
+		UserRepo:            mocks.NewMockUserRepository(ctrl),
+		ProjectRepo:         mocks.NewMockProjectRepository(ctrl),
+		EndpointRepo:        mocks.NewMockEndpointRepository(ctrl),
+		AIUsageRepo:         mocks.NewMockAIUsageRepository(ctrl),
+		AIUsagePayloadRepo:  mocks.NewMockAIUsagePayloadRepository(ctrl),
+		ChatMessageRepo:     mocks.NewMockChatMessageRepository(ctrl),
+		CodeVersionRepo:     mocks.NewMockCodeVersionRepository(ctrl),
+		WebhookRepo:         mocks.NewMockWebhookRepository(ctrl),
+		WebhookDeliveryRepo: mocks.NewMockWebhookDeliveryRepository(ctrl),
+		JobRepo:             mocks.NewMockJobRepository(ctrl),
+	}
+}
+
+// newTestTask encrypts the payload the way the job client does
+func newTestTask(t *testing.T, aesCfb encrypt.Encrypt, name, payload string) *asynq.Task {
+	encrypted, err := aesCfb.Encrypt([]byte(payload))
+	require.NoError(t, err)
+
+	return asynq.NewTask(name, []byte(encrypted))
+}
+
+// handlerDeps holds the dependencies shared by the agent job handlers under test
+type handlerDeps struct {
+	aesCfb encrypt.Encrypt
+	cfg    *config.Config
+	store  *store.Store
+	agent  *aa.Agent
+	event  sse.Streamer
+	events <-chan *sse.Event
+}
+
+func newHandlerDeps(t *testing.T, ctrl *gomock.Controller) *handlerDeps {
+	cfg := newTestConfig()
+
+	aesCfb, err := encrypt.NewAesCfb("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	inMemory, err := pubsub.NewInMemory(cfg)
+	require.NoError(t, err)
+
+	event := sse.NewStreamer(inMemory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _, cleanup := event.Subscribe(ctx, testProjectID)
+
+	t.Cleanup(func() {
+		_ = cleanup(ctx)
+		cancel()
+	})
+
+	return &handlerDeps{
+		aesCfb: aesCfb,
+		cfg:    cfg,
+		store:  newTestStore(ctrl),
+		agent:  aa.New(cfg),
+		event:  event,
+		events: events,
+	}
+}
+
+// collectEvents returns the types of the events published until the task completed or failed
+func (d *handlerDeps) collectEvents(t *testing.T) []sse.EventType {
+	eventTypes := []sse.EventType{}
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case event := <-d.events:
+			eventTypes = append(eventTypes, event.Type)
+
+			if event.Type == sse.EventTypeTaskCompleted || event.Type == sse.EventTypeTaskFailed {
+				return eventTypes
+			}
+		case <-timeout:
+			t.Fatalf("task didn't complete, received events: %v", eventTypes)
+			return eventTypes
+		}
+	}
+}
+
+// nextEvents returns the types of the next n published events
+func (d *handlerDeps) nextEvents(t *testing.T, n int) []sse.EventType {
+	eventTypes := []sse.EventType{}
+	timeout := time.After(5 * time.Second)
+
+	for len(eventTypes) < n {
+		select {
+		case event := <-d.events:
+			eventTypes = append(eventTypes, event.Type)
+		case <-timeout:
+			t.Fatalf("expected %d events, received events: %v", n, eventTypes)
+			return eventTypes
+		}
+	}
+
+	return eventTypes
+}
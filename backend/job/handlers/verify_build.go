@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/config"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	con "github.com/mujhtech/b0/internal/pkg/container"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+)
+
+const (
+	// buildTimeout bounds a sandboxed install and build
+	buildTimeout = 10 * time.Minute
+	// maxBuildOutput is the tail of the build output sent to the model and in the diagnostic
+	maxBuildOutput = 8000
+)
+
+// buildSandbox runs a command to completion in a throwaway container, *con.Container implements it
+type buildSandbox interface {
+	PullImage(ctx context.Context, imageRef string) error
+	RunToCompletion(ctx context.Context, opts con.RunContainerOption) (*con.RunResult, error)
+}
+
+// BuildError is returned when the generated code still fails to build after the fix attempts
+type BuildError struct {
+	ExitCode int64
+	Attempts int
+	Output   string
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("build failed with exit code %d after %d fix attempts:\n%s", e.ExitCode, e.Attempts, e.Output)
+}
+
+// buildVerifier builds the generated code in a sandbox before it's deployed, the agent fixes the code that fails
+type buildVerifier struct {
+	cfg     *config.Config
+	store   *store.Store
+	agent   *aa.Agent
+	event   sse.Streamer
+	sandbox buildSandbox
+}
+
+// verify returns the code once it builds, every fix is saved to the endpoint
+func (v *buildVerifier) verify(ctx context.Context, project *models.Project, endpoint *models.Endpoint, option aa.CodeGenerationOption, code *aa.CodeGeneration) (*aa.CodeGeneration, error) {
+	attempts := v.cfg.Agent.BuildFixAttempts
+
+	if err := v.sandbox.PullImage(ctx, option.Image); err != nil {
+		return nil, fmt.Errorf("failed to pull %s: %w", option.Image, err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		sendEvent(ctx, project.ID, sse.EventTypeTaskUpdate, AgentData{
+			Message: fmt.Sprintf("b0 is verifying the build of your project (attempt %d of %d)...", attempt+1, attempts+1),
+		}, v.event)
+
+		result, err := v.build(ctx, project, option, code)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to run the build: %w", err)
+		}
+
+		if result.ExitCode == 0 {
+			sendEvent(ctx, project.ID, sse.EventTypeTaskUpdate, AgentData{
+				Message: "b0 has successfully built your project",
+			}, v.event)
+
+			return code, nil
+		}
+
+		output := tailOutput(result.Output, maxBuildOutput)
+
+		if attempt >= attempts {
+			return nil, &BuildError{ExitCode: result.ExitCode, Attempts: attempts, Output: output}
+		}
+
+		sendEvent(ctx, project.ID, sse.EventTypeTaskUpdate, AgentData{
+			Message: fmt.Sprintf("the build failed with exit code %d, b0 is fixing %s...", result.ExitCode, strings.Join(code.FilesIn(output), ", ")),
+			Error:   output,
+		}, v.event)
+
+		if code, err = v.fix(ctx, project, endpoint, option, code, output); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (v *buildVerifier) build(ctx context.Context, project *models.Project, option aa.CodeGenerationOption, code *aa.CodeGeneration) (*con.RunResult, error) {
+	files, err := codeToTar(code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, buildTimeout)
+	defer cancel()
+
+	runtime := getDeployRuntime(project.Language)
+
+	return v.sandbox.RunToCompletion(ctx, con.RunContainerOption{
+		Name:       fmt.Sprintf("%s-build-%d", project.Slug, time.Now().Unix()),
+		Image:      option.Image,
+		Command:    []string{"/bin/sh", "-c", runtime.buildCommand(code)},
+		Env:        runtime.envs(generatePort()),
+		WorkingDir: "/app",
+		Files:      files,
+	})
+}
+
+func (v *buildVerifier) fix(ctx context.Context, project *models.Project, endpoint *models.Endpoint, option aa.CodeGenerationOption, code *aa.CodeGeneration, output string) (*aa.CodeGeneration, error) {
+	catalog, err := v.agent.GetModelCatalog(project.Model.String)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := checkUsageLimit(ctx, v.store, project)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fixed, agentToken, err := v.agent.FixCode(ctx, option, code, output, aa.WithModel(catalog.Model), aa.WithPremium(user.CanUsePremiumModels()), streamAgentDeltas(ctx, project.ID, v.event))
+
+	createAIUsage(ctx, v.cfg, v.store, &models.AIUsage{
+		ProjectID:  project.ID,
+		EndpointID: null.NewString(endpoint.ID, true),
+		OwnerID:    project.OwnerID,
+		Model:      project.Model.String,
+		UsageType:  "code_fix",
+		IsPremium:  catalog.IsPremium,
+	}, agentToken)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fix the build: %w", err)
+	}
+
+	endpoint.CodeGeneration = fixed
+
+	if err = v.store.EndpointRepo.UpdateEndpoint(ctx, endpoint.ID, endpoint); err != nil {
+		return nil, err
+	}
+
+	saveCodeVersion(ctx, v.store, endpoint, fixed, "Fix the build")
+
+	return fixed, nil
+}
+
+// codeToTar archives the files of the code for the sandbox
+func codeToTar(code *aa.CodeGeneration) (*bytes.Buffer, error) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+
+	for _, file := range code.FileContents {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: strings.TrimPrefix(file.Filename, "/"),
+			Mode: 0o644,
+			Size: int64(len(file.Content)),
+		}); err != nil {
+			return nil, err
+		}
+
+		if _, err := tw.Write([]byte(file.Content)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// tailOutput keeps the last limit bytes of the output, build errors are usually at the end
+func tailOutput(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+
+	return "..." + output[len(output)-limit:]
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	con "github.com/mujhtech/b0/internal/pkg/container"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// scriptedSandbox answers the builds with the results in order
+type scriptedSandbox struct {
+	results  []*con.RunResult
+	commands []string
+}
+
+func (s *scriptedSandbox) PullImage(ctx context.Context, imageRef string) error {
+	return nil
+}
+
+func (s *scriptedSandbox) RunToCompletion(ctx context.Context, opts con.RunContainerOption) (*con.RunResult, error) {
+	result := s.results[len(s.commands)]
+	s.commands = append(s.commands, opts.Command[2])
+
+	return result, nil
+}
+
+func TestBuildVerifier_Verify(t *testing.T) {
+	type testCase struct {
+		name         string
+		attempts     int
+		results      []*con.RunResult
+		mockFn       func(s *store.Store)
+		wantEvents   []sse.EventType
+		wantMainFile string
+		wantErr      error
+	}
+
+	mainFile := "package main\n\nfunc main() {\n\tfmt.Println(\"todo\")\n}\n"
+	buildOutput := "# b0/todo-api\n./main.go:4:2: undefined: fmt\n"
+
+	tests := []testCase{
+		{
+			name:         "should pass the code that builds",
+			attempts:     2,
+			results:      []*con.RunResult{{ExitCode: 0}},
+			wantEvents:   []sse.EventType{sse.EventTypeTaskUpdate, sse.EventTypeTaskUpdate},
+			wantMainFile: mainFile,
+		},
+		{
+			name:       "should block the code that still fails to build",
+			attempts:   0,
+			results:    []*con.RunResult{{ExitCode: 1, Output: buildOutput}},
+			wantEvents: []sse.EventType{sse.EventTypeTaskUpdate},
+			wantErr:    &BuildError{ExitCode: 1, Attempts: 0, Output: buildOutput},
+		},
+		{
+			name:     "should fix the files listed in the build output",
+			attempts: 1,
+			results:  []*con.RunResult{{ExitCode: 1, Output: buildOutput}, {ExitCode: 0}},
+			mockFn: func(s *store.Store) {
+				ur, _ := s.UserRepo.(*mocks.MockUserRepository)
+				ur.EXPECT().FindUserByID(gomock.Any(), "user-id").Times(1).Return(&models.User{ID: "user-id", SubscriptionPlan: "pro"}, nil)
+
+				ar, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ar.EXPECT().GetTotalUsage(gomock.Any(), gomock.Any()).Times(1).Return(&store.TotalAIUsage{TotalUsage: 3}, nil)
+				ar.EXPECT().CreateAIUsage(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().UpdateEndpoint(gomock.Any(), "endpoint-id", gomock.Any()).Times(1).Return(nil)
+
+				cr, _ := s.CodeVersionRepo.(*mocks.MockCodeVersionRepository)
+				cr.EXPECT().FindLatestCodeVersion(gomock.Any(), "endpoint-id").Times(1).Return(&models.CodeVersion{Version: "1", ContentHash: "generated"}, nil)
+				cr.EXPECT().CreateCodeVersion(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, version *models.CodeVersion) error {
+					require.Equal(t, "2", version.Version)
+					require.Equal(t, "Fix the build", version.CommitMsg)
+					return nil
+				})
+			},
+			wantEvents: []sse.EventType{
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeAgentDelta,
+				sse.EventTypeTaskUpdate,
+				sse.EventTypeTaskUpdate,
+			},
+			wantMainFile: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"todo\")\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			deps := newHandlerDeps(t, ctrl)
+			deps.cfg.Agent.BuildFixAttempts = tt.attempts
+
+			if tt.mockFn != nil {
+				tt.mockFn(deps.store)
+			}
+
+			sandbox := &scriptedSandbox{results: tt.results}
+
+			verifier := &buildVerifier{
+				cfg:     deps.cfg,
+				store:   deps.store,
+				agent:   deps.agent,
+				event:   deps.event,
+				sandbox: sandbox,
+			}
+
+			code, err := verifier.verify(context.Background(), &models.Project{
+				ID:       testProjectID,
+				OwnerID:  "user-id",
+				Slug:     "todo-api",
+				Model:    null.NewString("gpt-4", true),
+				Language: aa.LanguageGo,
+			}, &models.Endpoint{ID: "endpoint-id"}, aa.CodeGenerationOption{
+				Language:             aa.LanguageGo,
+				Framework:            "Chi",
+				Image:                "golang:1.23-alpine3.20",
+				FrameworkInsructions: "- For router, use go-chi/chi/v5",
+			}, &aa.CodeGeneration{
+				FileContents:    []aa.FileContent{{Filename: "main.go", Content: mainFile}},
+				InstallCommands: []string{"go mod init b0/todo-api", "go mod tidy"},
+				BuildCommands:   "go build -o app .",
+				RunCommands:     "./app",
+			})
+
+			require.Equal(t, tt.wantEvents, deps.nextEvents(t, len(tt.wantEvents)))
+			require.Equal(t, "cd /app && go mod init b0/todo-api && go mod tidy && go build -o app .", sandbox.commands[0])
+
+			if tt.wantErr != nil {
+				require.Equal(t, tt.wantErr, err)
+				require.Nil(t, code)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, sandbox.commands, len(tt.results))
+			require.Equal(t, tt.wantMainFile, code.FileContents[0].Content)
+		})
+	}
+}
+
+func Test_codeToTar(t *testing.T) {
+	buf, err := codeToTar(&aa.CodeGeneration{FileContents: []aa.FileContent{
+		{Filename: "main.go", Content: "package main"},
+		{Filename: "/internal/todo/todo.go", Content: "package todo"},
+	}})
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	tr := tar.NewReader(buf)
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+
+		files[header.Name] = string(content)
+	}
+
+	require.Equal(t, map[string]string{"main.go": "package main", "internal/todo/todo.go": "package todo"}, files)
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	aa "github.com/mujhtech/b0/internal/pkg/agent"
+	con "github.com/mujhtech/b0/internal/pkg/container"
+	"github.com/mujhtech/b0/internal/pkg/sse"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+	"github.com/mujhtech/b0/internal/util"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// crashReportInterval is how often a container crashing in a loop is reported to the webhooks
+	crashReportInterval = 5 * time.Minute
+	// containerWatchRetryDelay is how long the crash watcher waits before it reconnects to docker
+	containerWatchRetryDelay = 30 * time.Second
+)
+
+// WebhookDispatcher delivers the events of the projects to the webhooks subscribed to them
+type WebhookDispatcher struct {
+	store *store.Store
+	// enqueue enqueues the delivery job of a recorded delivery
+	enqueue func(ctx context.Context, deliveryID string) error
+}
+
+func NewWebhookDispatcher(store *store.Store, enqueue func(ctx context.Context, deliveryID string) error) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		store:   store,
+		enqueue: enqueue,
+	}
+}
+
+// Dispatch records a delivery of the event for every webhook of the project subscribed to it and enqueues them.
+// The job goes on when the event can't be dispatched, a nil dispatcher dispatches nothing.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context, projectID string, event webhook.EventType, data interface{}) {
+	if d == nil {
+		return
+	}
+
+	webhooks, err := d.store.WebhookRepo.FindWebhooksByEvent(ctx, projectID, event)
+
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to find the webhooks of event %s", event)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := util.MarshalJSON(webhook.Payload{
+		ID:        uuid.New().String(),
+		Event:     event,
+		ProjectID: projectID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to marshal the payload of event %s", event)
+		return
+	}
+
+	for _, hook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			ID:        uuid.New().String(),
+			WebhookID: hook.ID,
+			ProjectID: projectID,
+			Event:     event,
+			Payload:   payload,
+			Status:    models.WebhookDeliveryStatusPending,
+		}
+
+		if err := d.store.WebhookDeliveryRepo.CreateWebhookDelivery(ctx, delivery); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to create the delivery of event %s to webhook %s", event, hook.ID)
+			continue
+		}
+
+		if err := d.enqueue(ctx, delivery.ID); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to enqueue webhook delivery %s", delivery.ID)
+		}
+	}
+}
+
+// WatchContainerCrashes dispatches the crashes of the project containers until the context is done,
+// the watcher reconnects when the docker event stream fails
+func WatchContainerCrashes(ctx context.Context, docker *con.Container, webhooks *WebhookDispatcher) {
+	for {
+		err := docker.WatchCrashes(ctx, crashReportInterval, func(crash con.Crash) {
+			zerolog.Ctx(ctx).Warn().Msgf("container %s of project %s crashed with exit code %d", crash.ContainerID, crash.ProjectID, crash.ExitCode)
+
+			webhooks.Dispatch(ctx, crash.ProjectID, webhook.EventContainerCrashed, webhookCrashData{
+				ContainerID: crash.ContainerID,
+				ExitCode:    crash.ExitCode,
+				OOMKilled:   crash.OOMKilled,
+				CrashedAt:   crash.Time.UTC(),
+			})
+		})
+
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("failed to watch the containers, retrying")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(containerWatchRetryDelay):
+		}
+	}
+}
+
+// recordWebhookAttempt records the outcome of an attempt of the delivery, the returned error fails the attempt.
+// The delivery stays pending until it succeeds or its last attempt fails.
+func recordWebhookAttempt(delivery *models.WebhookDelivery, res *webhook.Response, sendErr error, lastAttempt bool) error {
+	delivery.Attempts++
+	delivery.DeliveredAt = null.TimeFrom(time.Now())
+	delivery.ResponseCode = null.Int{}
+	delivery.ResponseBody = ""
+	delivery.DurationMs = 0
+	delivery.Error = ""
+
+	err := sendErr
+
+	if res != nil {
+		delivery.ResponseCode = null.IntFrom(int64(res.StatusCode))
+		delivery.ResponseBody = res.Body
+		delivery.DurationMs = res.Duration.Milliseconds()
+
+		if !res.OK() {
+			err = fmt.Errorf("the webhook answered with status %d", res.StatusCode)
+		}
+	}
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		return nil
+	}
+
+	delivery.Error = err.Error()
+	delivery.Status = models.WebhookDeliveryStatusPending
+
+	if lastAttempt {
+		delivery.Status = models.WebhookDeliveryStatusFailed
+	}
+
+	return err
+}
+
+// deployEvents publishes the events of a deploy and dispatches its outcome to the webhooks, the first failed task
+// is the deploy.failed event and the completed task is the deploy.succeeded event
+type deployEvents struct {
+	sse.Streamer
+	webhooks  *WebhookDispatcher
+	projectID string
+	finished  bool
+}
+
+func (d *deployEvents) Publish(ctx context.Context, id string, eventType sse.EventType, data interface{}) error {
+	switch eventType {
+	case sse.EventTypeTaskFailed:
+		d.finish(ctx, webhook.EventDeployFailed, data)
+	case sse.EventTypeTaskCompleted:
+		d.finish(ctx, webhook.EventDeploySucceeded, data)
+	}
+
+	return d.Streamer.Publish(ctx, id, eventType, data)
+}
+
+// fail dispatches the failure of a deploy that stopped with an error without publishing it
+func (d *deployEvents) fail(ctx context.Context, err error) {
+	d.finish(ctx, webhook.EventDeployFailed, AgentData{Error: err.Error()})
+}
+
+func (d *deployEvents) finish(ctx context.Context, event webhook.EventType, data interface{}) {
+	if d.finished {
+		return
+	}
+
+	d.finished = true
+
+	deployData := webhookDeployData{}
+
+	if agentData, ok := data.(AgentData); ok {
+		deployData.Message = agentData.Message
+		deployData.Error = agentData.Error
+	}
+
+	d.webhooks.Dispatch(ctx, d.projectID, event, deployData)
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, store.ErrNotFound)
+}
+
+// webhookEndpoint is an endpoint in the data of the webhook events
+type webhookEndpoint struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+func toWebhookEndpoint(endpoint *models.Endpoint) webhookEndpoint {
+	return webhookEndpoint{
+		ID:     endpoint.ID,
+		Name:   endpoint.Name,
+		Method: string(endpoint.Method),
+		Path:   endpoint.Path,
+	}
+}
+
+func toWebhookEndpoints(endpoints []*models.Endpoint) []webhookEndpoint {
+	result := make([]webhookEndpoint, 0, len(endpoints))
+
+	for _, endpoint := range endpoints {
+		result = append(result, toWebhookEndpoint(endpoint))
+	}
+
+	return result
+}
+
+type webhookWorkflowData struct {
+	Endpoints []webhookEndpoint `json:"endpoints"`
+}
+
+type webhookCodeData struct {
+	Endpoint webhookEndpoint `json:"endpoint"`
+	Files    []string        `json:"files"`
+}
+
+func newWebhookCodeData(endpoint *models.Endpoint, code *aa.CodeGeneration) webhookCodeData {
+	files := make([]string, 0, len(code.FileContents))
+
+	for _, file := range code.FileContents {
+		files = append(files, file.Filename)
+	}
+
+	return webhookCodeData{
+		Endpoint: toWebhookEndpoint(endpoint),
+		Files:    files,
+	}
+}
+
+type webhookDeployData struct {
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type webhookCrashData struct {
+	ContainerID string    `json:"container_id"`
+	ExitCode    int       `json:"exit_code"`
+	OOMKilled   bool      `json:"oom_killed"`
+	CrashedAt   time.Time `json:"crashed_at"`
+}
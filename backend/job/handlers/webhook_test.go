@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWebhookDispatcher_Dispatch(t *testing.T) {
+	type testCase struct {
+		name         string
+		mockFn       func(s *store.Store)
+		wantEnqueued int
+	}
+
+	tests := []testCase{
+		{
+			name: "should record and enqueue a delivery for every subscribed webhook",
+			mockFn: func(s *store.Store) {
+				wr, _ := s.WebhookRepo.(*mocks.MockWebhookRepository)
+				wr.EXPECT().FindWebhooksByEvent(gomock.Any(), testProjectID, webhook.EventDeployFailed).Times(1).Return([]*models.Webhook{{ID: "webhook-1"}, {ID: "webhook-2"}}, nil)
+
+				var payloadID string
+
+				dr, _ := s.WebhookDeliveryRepo.(*mocks.MockWebhookDeliveryRepository)
+				dr.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, delivery *models.WebhookDelivery) error {
+					require.Equal(t, models.WebhookDeliveryStatusPending, delivery.Status)
+					require.Equal(t, webhook.EventDeployFailed, delivery.Event)
+
+					var payload webhook.Payload
+					require.NoError(t, json.Unmarshal(delivery.Payload, &payload))
+
+					// every webhook receives the same event
+					if payloadID != "" {
+						require.Equal(t, payloadID, payload.ID)
+					}
+
+					payloadID = payload.ID
+
+					return nil
+				})
+			},
+			wantEnqueued: 2,
+		},
+		{
+			name: "should dispatch nothing without a subscribed webhook",
+			mockFn: func(s *store.Store) {
+				wr, _ := s.WebhookRepo.(*mocks.MockWebhookRepository)
+				wr.EXPECT().FindWebhooksByEvent(gomock.Any(), testProjectID, webhook.EventDeployFailed).Times(1).Return(nil, nil)
+			},
+		},
+		{
+			name: "should skip a delivery that can't be recorded",
+			mockFn: func(s *store.Store) {
+				wr, _ := s.WebhookRepo.(*mocks.MockWebhookRepository)
+				wr.EXPECT().FindWebhooksByEvent(gomock.Any(), testProjectID, webhook.EventDeployFailed).Times(1).Return([]*models.Webhook{{ID: "webhook-1"}, {ID: "webhook-2"}}, nil)
+
+				dr, _ := s.WebhookDeliveryRepo.(*mocks.MockWebhookDeliveryRepository)
+				gomock.InOrder(
+					dr.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("database error")),
+					dr.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(nil),
+				)
+			},
+			wantEnqueued: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := newTestStore(ctrl)
+
+			if tt.mockFn != nil {
+				tt.mockFn(s)
+			}
+
+			var enqueued []string
+
+			dispatcher := NewWebhookDispatcher(s, func(_ context.Context, deliveryID string) error {
+				enqueued = append(enqueued, deliveryID)
+				return nil
+			})
+
+			// a failed deploy is dispatched once even when its failure is reported again
+			events := &deployEvents{Streamer: newHandlerDeps(t, ctrl).event, webhooks: dispatcher, projectID: testProjectID}
+			events.fail(context.Background(), errors.New("deploy failed"))
+			events.fail(context.Background(), errors.New("deploy failed"))
+
+			require.Len(t, enqueued, tt.wantEnqueued)
+		})
+	}
+}
+
+func Test_recordWebhookAttempt(t *testing.T) {
+	tests := []struct {
+		name        string
+		res         *webhook.Response
+		sendErr     error
+		lastAttempt bool
+		wantStatus  models.WebhookDeliveryStatus
+		wantErr     bool
+	}{
+		{
+			name:       "a successful response succeeds the delivery",
+			res:        &webhook.Response{StatusCode: http.StatusNoContent},
+			wantStatus: models.WebhookDeliveryStatusSucceeded,
+		},
+		{
+			name:       "a failed response keeps the delivery pending",
+			res:        &webhook.Response{StatusCode: http.StatusBadGateway, Body: "bad gateway"},
+			wantStatus: models.WebhookDeliveryStatusPending,
+			wantErr:    true,
+		},
+		{
+			name:       "an unreachable webhook keeps the delivery pending",
+			sendErr:    errors.New("connection refused"),
+			wantStatus: models.WebhookDeliveryStatusPending,
+			wantErr:    true,
+		},
+		{
+			name:        "the last failed attempt fails the delivery",
+			res:         &webhook.Response{StatusCode: http.StatusInternalServerError},
+			lastAttempt: true,
+			wantStatus:  models.WebhookDeliveryStatusFailed,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := &models.WebhookDelivery{ID: "delivery-id", Status: models.WebhookDeliveryStatusPending, Attempts: 1}
+
+			err := recordWebhookAttempt(delivery, tt.res, tt.sendErr, tt.lastAttempt)
+
+			require.Equal(t, tt.wantStatus, delivery.Status)
+			require.Equal(t, 2, delivery.Attempts)
+			require.True(t, delivery.DeliveredAt.Valid)
+
+			if tt.res != nil {
+				require.EqualValues(t, tt.res.StatusCode, delivery.ResponseCode.Int64)
+				require.Equal(t, tt.res.Body, delivery.ResponseBody)
+			}
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Equal(t, err.Error(), delivery.Error)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Empty(t, delivery.Error)
+		})
+	}
+}
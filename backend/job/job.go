@@ -7,6 +7,7 @@ import (
 	"github.com/mujhtech/b0/config"
 	"github.com/mujhtech/b0/database/store"
 	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/internal/pkg/artifact"
 	"github.com/mujhtech/b0/internal/pkg/container"
 	"github.com/mujhtech/b0/internal/pkg/encrypt"
 	secretmanager "github.com/mujhtech/b0/internal/pkg/secret_manager"
@@ -21,9 +22,11 @@ type Job struct {
 	Executor  *Executor
 	Scheduler *Scheduler
 	aesCfb    encrypt.Encrypt
+	appCtx    context.Context
+	cfg       *config.Config
 }
 
-func NewJob(cfg *config.Config, appCtx context.Context, redis *redis.Redis) (*Job, error) {
+func NewJob(cfg *config.Config, appCtx context.Context, redis *redis.Redis, store *store.Store) (*Job, error) {
 
 	var c asynq.RedisConnOpt
 	var _ = redis.MakeRedisClient().(rdsv9.UniversalClient)
@@ -37,17 +40,50 @@ func NewJob(cfg *config.Config, appCtx context.Context, redis *redis.Redis) (*Jo
 
 	return &Job{
 		aesCfb:    aesCfb,
-		Client:    NewClient(c, aesCfb),
-		Executor:  NewExecutor(cfg, appCtx, c),
+		appCtx:    appCtx,
+		cfg:       cfg,
+		Client:    NewClient(c, aesCfb, store.JobRepo),
+		Executor:  NewExecutor(cfg, appCtx, c, store.JobRepo),
 		Scheduler: NewScheduler(cfg, c),
 	}, nil
 }
 
-func (j *Job) RegisterAndStart(cfg *config.Config, store *store.Store, agent *agent.Agent, sse sse.Streamer, container *container.Container, secretManager secretmanager.SecretManager) error {
-	j.Executor.RegisterJobHandler(JobNameWorkflowCreate, asynq.HandlerFunc(handlers.HandleCreateWorkflow(j.aesCfb, store, agent, sse)))
-	j.Executor.RegisterJobHandler(JobNameWorkflowUpdate, asynq.HandlerFunc(handlers.HandleUpdateWorkflow(j.aesCfb, store, agent, sse)))
-	j.Executor.RegisterJobHandler(JobNameWebhook, asynq.HandlerFunc(handlers.HandleWebhook(j.aesCfb, store)))
-	j.Executor.RegisterJobHandler(JobNameProjectDeploy, asynq.HandlerFunc(handlers.HandleDeployProject(j.aesCfb, cfg, store, agent, sse, container, secretManager)))
+func (j *Job) RegisterAndStart(cfg *config.Config, store *store.Store, agent *agent.Agent, sse sse.Streamer, container *container.Container, secretManager secretmanager.SecretManager, artifacts artifact.Store) error {
+	webhooks := handlers.NewWebhookDispatcher(store, func(ctx context.Context, deliveryID string) error {
+		_, err := j.EnqueueWebhookDelivery(ctx, deliveryID)
+		return err
+	})
+
+	j.Executor.RegisterJobHandler(JobNameWorkflowCreate, asynq.HandlerFunc(handlers.HandleCreateWorkflow(j.aesCfb, cfg, store, agent, sse, webhooks)))
+	j.Executor.RegisterJobHandler(JobNameWorkflowUpdate, asynq.HandlerFunc(handlers.HandleUpdateWorkflow(j.aesCfb, cfg, store, agent, sse, webhooks)))
+	j.Executor.RegisterJobHandler(JobNameWebhook, asynq.HandlerFunc(handlers.HandleWebhook(j.aesCfb, cfg, store)))
+	j.Executor.RegisterJobHandler(JobNameProjectDeploy, asynq.HandlerFunc(handlers.HandleDeployProject(j.aesCfb, cfg, store, agent, sse, container, secretManager, webhooks)))
+	j.Executor.RegisterJobHandler(JobNameProjectExport, asynq.HandlerFunc(handlers.HandleExportProject(j.aesCfb, cfg, store, sse, secretManager, artifacts)))
+	j.Executor.RegisterJobHandler(JobNameProjectPush, asynq.HandlerFunc(handlers.HandlePushProject(j.aesCfb, cfg, store, sse, secretManager)))
+	j.Executor.RegisterJobHandler(JobNameAIUsagePayloadPrune, asynq.HandlerFunc(handlers.HandlePruneAIUsagePayloads(store)))
+	j.Executor.RegisterJobHandler(JobNameArtifactPrune, asynq.HandlerFunc(handlers.HandlePruneArtifacts(cfg, artifacts)))
+
+	if err := j.Scheduler.Register("@hourly", QueueNameDefault, JobNameAIUsagePayloadPrune); err != nil {
+		return err
+	}
+
+	if err := j.Scheduler.Register("@hourly", QueueNameDefault, JobNameArtifactPrune); err != nil {
+		return err
+	}
+
+	if err := j.Scheduler.Start(); err != nil {
+		return err
+	}
+
+	go handlers.WatchContainerCrashes(j.appCtx, container, webhooks)
 
 	return j.Executor.Start()
 }
+
+// EnqueueWebhookDelivery enqueues an attempt of a recorded webhook delivery, it is retried until the retries run out
+func (j *Job) EnqueueWebhookDelivery(ctx context.Context, deliveryID string) (string, error) {
+	return j.Client.Enqueue(ctx, QueueNameDefault, JobNameWebhook, &ClientPayload{
+		Data:     []byte(deliveryID),
+		MaxRetry: j.cfg.Webhook.MaxRetries,
+	})
+}
@@ -17,6 +17,12 @@ func NewScheduler(cfg *config.Config, opts asynq.RedisConnOpt) *Scheduler {
 	}
 }
 
+// Register enqueues the job on the queue every time the cron spec matches
+func (s *Scheduler) Register(cronspec string, queue QueueName, job JobName) error {
+	_, err := s.scheduler.Register(cronspec, asynq.NewTask(string(job), nil, asynq.Queue(string(queue))))
+	return err
+}
+
 func (s *Scheduler) Start() error {
 	return s.scheduler.Start()
 }
@@ -0,0 +1,71 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/job/handlers"
+	"github.com/rs/zerolog"
+)
+
+// trackJobs records the attempts of the jobs and the outcome their handlers report.
+// A task enqueued without a job, like a scheduled task, runs untracked.
+func trackJobs(jobs store.JobRepository) asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			id, _ := asynq.GetTaskID(ctx)
+
+			record, err := jobs.FindJobByID(ctx, id)
+
+			if err != nil {
+				if !errors.Is(err, store.ErrNotFound) {
+					zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to find job %s", id)
+				}
+
+				return next.ProcessTask(ctx, t)
+			}
+
+			record.Attempts++
+			record.Transition(models.JobStateRunning, time.Now(), "")
+			saveJob(ctx, jobs, record)
+
+			reportCtx, report := handlers.WithJobReport(ctx)
+
+			err = next.ProcessTask(reportCtx, t)
+
+			retried, _ := asynq.GetRetryCount(ctx)
+			maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+			finishJob(record, report, err, retried < maxRetry, time.Now())
+			saveJob(ctx, jobs, record)
+
+			return err
+		})
+	}
+}
+
+// finishJob moves the job to the outcome of its attempt, a failed attempt is retried while retries are left
+func finishJob(record *models.Job, report *handlers.JobReport, err error, retriesLeft bool, at time.Time) {
+	record.Result = report.Result()
+
+	switch {
+	case err != nil && retriesLeft && !errors.Is(err, asynq.SkipRetry):
+		record.Transition(models.JobStateRetrying, at, err.Error())
+	case err != nil:
+		record.Transition(models.JobStateFailed, at, err.Error())
+	case report.Failure() != "":
+		record.Transition(models.JobStateFailed, at, report.Failure())
+	default:
+		record.Transition(models.JobStateSucceeded, at, "")
+	}
+}
+
+func saveJob(ctx context.Context, jobs store.JobRepository, record *models.Job) {
+	if err := jobs.UpdateJob(ctx, record); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to record the state of job %s", record.ID)
+	}
+}
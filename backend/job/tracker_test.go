@@ -0,0 +1,73 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/job/handlers"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_finishJob(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		err         error
+		retriesLeft bool
+		wantState   models.JobState
+		wantError   string
+		wantFinish  bool
+	}{
+		{
+			name:       "a job without error succeeds",
+			wantState:  models.JobStateSucceeded,
+			wantFinish: true,
+		},
+		{
+			name:        "a failed attempt is retried while retries are left",
+			err:         errors.New("agent unavailable"),
+			retriesLeft: true,
+			wantState:   models.JobStateRetrying,
+			wantError:   "agent unavailable",
+		},
+		{
+			name:       "a failed attempt fails the job when the retries ran out",
+			err:        errors.New("agent unavailable"),
+			wantState:  models.JobStateFailed,
+			wantError:  "agent unavailable",
+			wantFinish: true,
+		},
+		{
+			name:        "a failed attempt that skips the retries fails the job",
+			err:         fmt.Errorf("invalid payload: %w", asynq.SkipRetry),
+			retriesLeft: true,
+			wantState:   models.JobStateFailed,
+			wantError:   "invalid payload: skip retry for the task",
+			wantFinish:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &models.Job{ID: "job-id", Name: string(JobNameProjectDeploy)}
+			record.Transition(models.JobStateQueued, at, "")
+			record.Transition(models.JobStateRunning, at.Add(time.Second), "")
+
+			_, report := handlers.WithJobReport(context.Background())
+
+			finishJob(record, report, tt.err, tt.retriesLeft, at.Add(time.Minute))
+
+			require.Equal(t, tt.wantState, record.State)
+			require.Equal(t, tt.wantError, record.Error)
+			require.Len(t, record.Transitions, 3)
+			require.Equal(t, at.Add(time.Second), record.StartedAt.Time)
+			require.Equal(t, tt.wantFinish, record.FinishedAt.Valid)
+		})
+	}
+}
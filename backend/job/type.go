@@ -11,6 +11,10 @@ const (
 	JobNameWorkflowUpdate JobName = "workflow.update"
 	JobNameProjectDeploy  JobName = "project.project"
 	JobNameProjectExport  JobName = "project.export"
+	JobNameProjectPush    JobName = "project.push"
+
+	JobNameAIUsagePayloadPrune JobName = "ai_usage_payload.prune"
+	JobNameArtifactPrune       JobName = "artifact.prune"
 
 	QueueNameDefault QueueName = "default"
 )
@@ -18,4 +22,8 @@ const (
 type ClientPayload struct {
 	Data  []byte        `json:"data"`
 	Delay time.Duration `json:"delay"`
+	// MaxRetry is how many times a failed job is retried, the default of asynq when zero
+	MaxRetry int `json:"max_retry"`
+	// ProjectID is the project the job works on, the job is listed in the job history of the project
+	ProjectID string `json:"project_id"`
 }
@@ -5,6 +5,8 @@ import (
 	"reflect"
 
 	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/webhook"
 	"go.uber.org/mock/gomock"
 )
 
@@ -297,7 +299,7 @@ func (mr *MockUserRepositoryMockRecorder) UpdateUser(arg0, arg1 any) *gomock.Cal
 }
 
 // FindUserByEmail mocks base method
-func (m *MockUserRepository) FindUserByEmail(arg0 context.Context, arg1 any) (*models.User, error) {
+func (m *MockUserRepository) FindUserByEmail(arg0 context.Context, arg1 string) (*models.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindUserByEmail", arg0, arg1)
 	ret0, _ := ret[0].(*models.User)
@@ -312,7 +314,7 @@ func (mr *MockUserRepositoryMockRecorder) FindUserByEmail(arg0, arg1 any) *gomoc
 }
 
 // FindUserByID mocks base method
-func (m *MockUserRepository) FindUserByID(arg0 context.Context, arg1 any) (*models.User, error) {
+func (m *MockUserRepository) FindUserByID(arg0 context.Context, arg1 string) (*models.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindUserByID", arg0, arg1)
 	ret0, _ := ret[0].(*models.User)
@@ -325,3 +327,644 @@ func (mr *MockUserRepositoryMockRecorder) FindUserByID(arg0, arg1 any) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByID", reflect.TypeOf((*MockUserRepository)(nil).FindUserByID), arg0, arg1)
 }
+
+// MockAIUsageRepository is a mock of AIUsageRepository interface
+type MockAIUsageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAIUsageRepositoryMockRecorder
+}
+
+// MockAIUsageRepositoryMockRecorder is the mock recorder for MockAIUsageRepository
+type MockAIUsageRepositoryMockRecorder struct {
+	mock *MockAIUsageRepository
+}
+
+// NewMockAIUsageRepository creates a new mock instance
+func NewMockAIUsageRepository(ctrl *gomock.Controller) *MockAIUsageRepository {
+	mock := &MockAIUsageRepository{ctrl: ctrl}
+	mock.recorder = &MockAIUsageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAIUsageRepository) EXPECT() *MockAIUsageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAIUsage mocks base method
+func (m *MockAIUsageRepository) CreateAIUsage(arg0 context.Context, arg1 *models.AIUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAIUsage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAIUsage indicates an expected call of CreateAIUsage.
+func (mr *MockAIUsageRepositoryMockRecorder) CreateAIUsage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAIUsage", reflect.TypeOf((*MockAIUsageRepository)(nil).CreateAIUsage), arg0, arg1)
+}
+
+// UpdateAIUsage mocks base method
+func (m *MockAIUsageRepository) UpdateAIUsage(arg0 context.Context, arg1 *models.AIUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAIUsage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAIUsage indicates an expected call of UpdateAIUsage.
+func (mr *MockAIUsageRepositoryMockRecorder) UpdateAIUsage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAIUsage", reflect.TypeOf((*MockAIUsageRepository)(nil).UpdateAIUsage), arg0, arg1)
+}
+
+// DeleteAIUsage mocks base method
+func (m *MockAIUsageRepository) DeleteAIUsage(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAIUsage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAIUsage indicates an expected call of DeleteAIUsage.
+func (mr *MockAIUsageRepositoryMockRecorder) DeleteAIUsage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAIUsage", reflect.TypeOf((*MockAIUsageRepository)(nil).DeleteAIUsage), arg0, arg1)
+}
+
+// FindAIUsageByID mocks base method
+func (m *MockAIUsageRepository) FindAIUsageByID(arg0 context.Context, arg1 string) (*models.AIUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAIUsageByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.AIUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAIUsageByID indicates an expected call of FindAIUsageByID.
+func (mr *MockAIUsageRepositoryMockRecorder) FindAIUsageByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAIUsageByID", reflect.TypeOf((*MockAIUsageRepository)(nil).FindAIUsageByID), arg0, arg1)
+}
+
+// FindAIUsageByProjectID mocks base method
+func (m *MockAIUsageRepository) FindAIUsageByProjectID(arg0 context.Context, arg1 string) ([]*models.AIUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAIUsageByProjectID", arg0, arg1)
+	ret0, _ := ret[0].([]*models.AIUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAIUsageByProjectID indicates an expected call of FindAIUsageByProjectID.
+func (mr *MockAIUsageRepositoryMockRecorder) FindAIUsageByProjectID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAIUsageByProjectID", reflect.TypeOf((*MockAIUsageRepository)(nil).FindAIUsageByProjectID), arg0, arg1)
+}
+
+// GetTotalUsage mocks base method
+func (m *MockAIUsageRepository) GetTotalUsage(arg0 context.Context, arg1 store.TotalAIUsageFilter) (*store.TotalAIUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalUsage", arg0, arg1)
+	ret0, _ := ret[0].(*store.TotalAIUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotalUsage indicates an expected call of GetTotalUsage.
+func (mr *MockAIUsageRepositoryMockRecorder) GetTotalUsage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalUsage", reflect.TypeOf((*MockAIUsageRepository)(nil).GetTotalUsage), arg0, arg1)
+}
+
+// MockAIUsagePayloadRepository is a mock of AIUsagePayloadRepository interface
+type MockAIUsagePayloadRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAIUsagePayloadRepositoryMockRecorder
+}
+
+// MockAIUsagePayloadRepositoryMockRecorder is the mock recorder for MockAIUsagePayloadRepository
+type MockAIUsagePayloadRepositoryMockRecorder struct {
+	mock *MockAIUsagePayloadRepository
+}
+
+// NewMockAIUsagePayloadRepository creates a new mock instance
+func NewMockAIUsagePayloadRepository(ctrl *gomock.Controller) *MockAIUsagePayloadRepository {
+	mock := &MockAIUsagePayloadRepository{ctrl: ctrl}
+	mock.recorder = &MockAIUsagePayloadRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAIUsagePayloadRepository) EXPECT() *MockAIUsagePayloadRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAIUsagePayload mocks base method
+func (m *MockAIUsagePayloadRepository) CreateAIUsagePayload(arg0 context.Context, arg1 *models.AIUsagePayload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAIUsagePayload", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAIUsagePayload indicates an expected call of CreateAIUsagePayload.
+func (mr *MockAIUsagePayloadRepositoryMockRecorder) CreateAIUsagePayload(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAIUsagePayload", reflect.TypeOf((*MockAIUsagePayloadRepository)(nil).CreateAIUsagePayload), arg0, arg1)
+}
+
+// FindAIUsagePayloadByUsageID mocks base method
+func (m *MockAIUsagePayloadRepository) FindAIUsagePayloadByUsageID(arg0 context.Context, arg1 string) (*models.AIUsagePayload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAIUsagePayloadByUsageID", arg0, arg1)
+	ret0, _ := ret[0].(*models.AIUsagePayload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAIUsagePayloadByUsageID indicates an expected call of FindAIUsagePayloadByUsageID.
+func (mr *MockAIUsagePayloadRepositoryMockRecorder) FindAIUsagePayloadByUsageID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAIUsagePayloadByUsageID", reflect.TypeOf((*MockAIUsagePayloadRepository)(nil).FindAIUsagePayloadByUsageID), arg0, arg1)
+}
+
+// DeleteExpiredAIUsagePayloads mocks base method
+func (m *MockAIUsagePayloadRepository) DeleteExpiredAIUsagePayloads(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredAIUsagePayloads", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredAIUsagePayloads indicates an expected call of DeleteExpiredAIUsagePayloads.
+func (mr *MockAIUsagePayloadRepositoryMockRecorder) DeleteExpiredAIUsagePayloads(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredAIUsagePayloads", reflect.TypeOf((*MockAIUsagePayloadRepository)(nil).DeleteExpiredAIUsagePayloads), arg0)
+}
+
+// MockChatMessageRepository is a mock of ChatMessageRepository interface
+type MockChatMessageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockChatMessageRepositoryMockRecorder
+}
+
+// MockChatMessageRepositoryMockRecorder is the mock recorder for MockChatMessageRepository
+type MockChatMessageRepositoryMockRecorder struct {
+	mock *MockChatMessageRepository
+}
+
+// NewMockChatMessageRepository creates a new mock instance
+func NewMockChatMessageRepository(ctrl *gomock.Controller) *MockChatMessageRepository {
+	mock := &MockChatMessageRepository{ctrl: ctrl}
+	mock.recorder = &MockChatMessageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockChatMessageRepository) EXPECT() *MockChatMessageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateChatMessage mocks base method
+func (m *MockChatMessageRepository) CreateChatMessage(arg0 context.Context, arg1 *models.ChatMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChatMessage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChatMessage indicates an expected call of CreateChatMessage.
+func (mr *MockChatMessageRepositoryMockRecorder) CreateChatMessage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChatMessage", reflect.TypeOf((*MockChatMessageRepository)(nil).CreateChatMessage), arg0, arg1)
+}
+
+// DeleteChatMessage mocks base method
+func (m *MockChatMessageRepository) DeleteChatMessage(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChatMessage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChatMessage indicates an expected call of DeleteChatMessage.
+func (mr *MockChatMessageRepositoryMockRecorder) DeleteChatMessage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChatMessage", reflect.TypeOf((*MockChatMessageRepository)(nil).DeleteChatMessage), arg0, arg1)
+}
+
+// FindChatMessageByID mocks base method
+func (m *MockChatMessageRepository) FindChatMessageByID(arg0 context.Context, arg1 string) (*models.ChatMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindChatMessageByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindChatMessageByID indicates an expected call of FindChatMessageByID.
+func (mr *MockChatMessageRepositoryMockRecorder) FindChatMessageByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindChatMessageByID", reflect.TypeOf((*MockChatMessageRepository)(nil).FindChatMessageByID), arg0, arg1)
+}
+
+// FindChatMessages mocks base method
+func (m *MockChatMessageRepository) FindChatMessages(arg0 context.Context, arg1 store.ChatMessageFilter) ([]*models.ChatMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindChatMessages", arg0, arg1)
+	ret0, _ := ret[0].([]*models.ChatMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindChatMessages indicates an expected call of FindChatMessages.
+func (mr *MockChatMessageRepositoryMockRecorder) FindChatMessages(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindChatMessages", reflect.TypeOf((*MockChatMessageRepository)(nil).FindChatMessages), arg0, arg1)
+}
+
+// MockCodeVersionRepository is a mock of CodeVersionRepository interface
+type MockCodeVersionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCodeVersionRepositoryMockRecorder
+}
+
+// MockCodeVersionRepositoryMockRecorder is the mock recorder for MockCodeVersionRepository
+type MockCodeVersionRepositoryMockRecorder struct {
+	mock *MockCodeVersionRepository
+}
+
+// NewMockCodeVersionRepository creates a new mock instance
+func NewMockCodeVersionRepository(ctrl *gomock.Controller) *MockCodeVersionRepository {
+	mock := &MockCodeVersionRepository{ctrl: ctrl}
+	mock.recorder = &MockCodeVersionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCodeVersionRepository) EXPECT() *MockCodeVersionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateCodeVersion mocks base method
+func (m *MockCodeVersionRepository) CreateCodeVersion(arg0 context.Context, arg1 *models.CodeVersion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCodeVersion", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCodeVersion indicates an expected call of CreateCodeVersion.
+func (mr *MockCodeVersionRepositoryMockRecorder) CreateCodeVersion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCodeVersion", reflect.TypeOf((*MockCodeVersionRepository)(nil).CreateCodeVersion), arg0, arg1)
+}
+
+// FindCodeVersionByID mocks base method
+func (m *MockCodeVersionRepository) FindCodeVersionByID(arg0 context.Context, arg1 string) (*models.CodeVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCodeVersionByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.CodeVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCodeVersionByID indicates an expected call of FindCodeVersionByID.
+func (mr *MockCodeVersionRepositoryMockRecorder) FindCodeVersionByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCodeVersionByID", reflect.TypeOf((*MockCodeVersionRepository)(nil).FindCodeVersionByID), arg0, arg1)
+}
+
+// FindLatestCodeVersion mocks base method
+func (m *MockCodeVersionRepository) FindLatestCodeVersion(arg0 context.Context, arg1 string) (*models.CodeVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindLatestCodeVersion", arg0, arg1)
+	ret0, _ := ret[0].(*models.CodeVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLatestCodeVersion indicates an expected call of FindLatestCodeVersion.
+func (mr *MockCodeVersionRepositoryMockRecorder) FindLatestCodeVersion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLatestCodeVersion", reflect.TypeOf((*MockCodeVersionRepository)(nil).FindLatestCodeVersion), arg0, arg1)
+}
+
+// FindCodeVersions mocks base method
+func (m *MockCodeVersionRepository) FindCodeVersions(arg0 context.Context, arg1 store.CodeVersionFilter) ([]*models.CodeVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCodeVersions", arg0, arg1)
+	ret0, _ := ret[0].([]*models.CodeVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCodeVersions indicates an expected call of FindCodeVersions.
+func (mr *MockCodeVersionRepositoryMockRecorder) FindCodeVersions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCodeVersions", reflect.TypeOf((*MockCodeVersionRepository)(nil).FindCodeVersions), arg0, arg1)
+}
+
+// FindProjectCodeVersions mocks base method
+func (m *MockCodeVersionRepository) FindProjectCodeVersions(arg0 context.Context, arg1 string) ([]*models.CodeVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProjectCodeVersions", arg0, arg1)
+	ret0, _ := ret[0].([]*models.CodeVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProjectCodeVersions indicates an expected call of FindProjectCodeVersions.
+func (mr *MockCodeVersionRepositoryMockRecorder) FindProjectCodeVersions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProjectCodeVersions", reflect.TypeOf((*MockCodeVersionRepository)(nil).FindProjectCodeVersions), arg0, arg1)
+}
+
+// UpdateCodeVersionCommit mocks base method
+func (m *MockCodeVersionRepository) UpdateCodeVersionCommit(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCodeVersionCommit", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCodeVersionCommit indicates an expected call of UpdateCodeVersionCommit.
+func (mr *MockCodeVersionRepositoryMockRecorder) UpdateCodeVersionCommit(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCodeVersionCommit", reflect.TypeOf((*MockCodeVersionRepository)(nil).UpdateCodeVersionCommit), arg0, arg1, arg2, arg3)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhook mocks base method
+func (m *MockWebhookRepository) CreateWebhook(arg0 context.Context, arg1 *models.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockWebhookRepositoryMockRecorder) CreateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookRepository)(nil).CreateWebhook), arg0, arg1)
+}
+
+// FindWebhookByID mocks base method
+func (m *MockWebhookRepository) FindWebhookByID(arg0 context.Context, arg1 string) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhookByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhookByID indicates an expected call of FindWebhookByID.
+func (mr *MockWebhookRepositoryMockRecorder) FindWebhookByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhookByID", reflect.TypeOf((*MockWebhookRepository)(nil).FindWebhookByID), arg0, arg1)
+}
+
+// FindWebhooksByProjectID mocks base method
+func (m *MockWebhookRepository) FindWebhooksByProjectID(arg0 context.Context, arg1 string) ([]*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhooksByProjectID", arg0, arg1)
+	ret0, _ := ret[0].([]*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhooksByProjectID indicates an expected call of FindWebhooksByProjectID.
+func (mr *MockWebhookRepositoryMockRecorder) FindWebhooksByProjectID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhooksByProjectID", reflect.TypeOf((*MockWebhookRepository)(nil).FindWebhooksByProjectID), arg0, arg1)
+}
+
+// FindWebhooksByEvent mocks base method
+func (m *MockWebhookRepository) FindWebhooksByEvent(arg0 context.Context, arg1 string, arg2 webhook.EventType) ([]*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhooksByEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhooksByEvent indicates an expected call of FindWebhooksByEvent.
+func (mr *MockWebhookRepositoryMockRecorder) FindWebhooksByEvent(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhooksByEvent", reflect.TypeOf((*MockWebhookRepository)(nil).FindWebhooksByEvent), arg0, arg1, arg2)
+}
+
+// UpdateWebhook mocks base method
+func (m *MockWebhookRepository) UpdateWebhook(arg0 context.Context, arg1 *models.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockWebhookRepositoryMockRecorder) UpdateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockWebhookRepository)(nil).UpdateWebhook), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method
+func (m *MockWebhookRepository) DeleteWebhook(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookRepositoryMockRecorder) DeleteWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookRepository)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// MockWebhookDeliveryRepository is a mock of WebhookDeliveryRepository interface
+type MockWebhookDeliveryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookDeliveryRepositoryMockRecorder
+}
+
+// MockWebhookDeliveryRepositoryMockRecorder is the mock recorder for MockWebhookDeliveryRepository
+type MockWebhookDeliveryRepositoryMockRecorder struct {
+	mock *MockWebhookDeliveryRepository
+}
+
+// NewMockWebhookDeliveryRepository creates a new mock instance
+func NewMockWebhookDeliveryRepository(ctrl *gomock.Controller) *MockWebhookDeliveryRepository {
+	mock := &MockWebhookDeliveryRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookDeliveryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWebhookDeliveryRepository) EXPECT() *MockWebhookDeliveryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhookDelivery mocks base method
+func (m *MockWebhookDeliveryRepository) CreateWebhookDelivery(arg0 context.Context, arg1 *models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) CreateWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).CreateWebhookDelivery), arg0, arg1)
+}
+
+// FindWebhookDeliveryByID mocks base method
+func (m *MockWebhookDeliveryRepository) FindWebhookDeliveryByID(arg0 context.Context, arg1 string) (*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhookDeliveryByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhookDeliveryByID indicates an expected call of FindWebhookDeliveryByID.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) FindWebhookDeliveryByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhookDeliveryByID", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).FindWebhookDeliveryByID), arg0, arg1)
+}
+
+// FindWebhookDeliveries mocks base method
+func (m *MockWebhookDeliveryRepository) FindWebhookDeliveries(arg0 context.Context, arg1 store.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhookDeliveries indicates an expected call of FindWebhookDeliveries.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) FindWebhookDeliveries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhookDeliveries", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).FindWebhookDeliveries), arg0, arg1)
+}
+
+// UpdateWebhookDelivery mocks base method
+func (m *MockWebhookDeliveryRepository) UpdateWebhookDelivery(arg0 context.Context, arg1 *models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) UpdateWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).UpdateWebhookDelivery), arg0, arg1)
+}
+
+// MockJobRepository is a mock of JobRepository interface
+type MockJobRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRepositoryMockRecorder
+}
+
+// MockJobRepositoryMockRecorder is the mock recorder for MockJobRepository
+type MockJobRepositoryMockRecorder struct {
+	mock *MockJobRepository
+}
+
+// NewMockJobRepository creates a new mock instance
+func NewMockJobRepository(ctrl *gomock.Controller) *MockJobRepository {
+	mock := &MockJobRepository{ctrl: ctrl}
+	mock.recorder = &MockJobRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockJobRepository) EXPECT() *MockJobRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateJob mocks base method
+func (m *MockJobRepository) CreateJob(arg0 context.Context, arg1 *models.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockJobRepositoryMockRecorder) CreateJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockJobRepository)(nil).CreateJob), arg0, arg1)
+}
+
+// FindJobByID mocks base method
+func (m *MockJobRepository) FindJobByID(arg0 context.Context, arg1 string) (*models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindJobByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindJobByID indicates an expected call of FindJobByID.
+func (mr *MockJobRepositoryMockRecorder) FindJobByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindJobByID", reflect.TypeOf((*MockJobRepository)(nil).FindJobByID), arg0, arg1)
+}
+
+// FindJobs mocks base method
+func (m *MockJobRepository) FindJobs(arg0 context.Context, arg1 store.JobFilter) ([]*models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindJobs", arg0, arg1)
+	ret0, _ := ret[0].([]*models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindJobs indicates an expected call of FindJobs.
+func (mr *MockJobRepositoryMockRecorder) FindJobs(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindJobs", reflect.TypeOf((*MockJobRepository)(nil).FindJobs), arg0, arg1)
+}
+
+// UpdateJob mocks base method
+func (m *MockJobRepository) UpdateJob(arg0 context.Context, arg1 *models.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateJob", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateJob indicates an expected call of UpdateJob.
+func (mr *MockJobRepositoryMockRecorder) UpdateJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateJob", reflect.TypeOf((*MockJobRepository)(nil).UpdateJob), arg0, arg1)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/rs/zerolog"
+)
+
+const AIUsageTypeRepair = "repair"
+
+type CreateAIUsageService struct {
+	AIUsageRepo        store.AIUsageRepository
+	AIUsagePayloadRepo store.AIUsagePayloadRepository
+	// Usage carries the owner, project, endpoint and usage type shared by every recorded row, its model and tier
+	// are only used for the tokens that don't name the model that answered
+	Usage      *models.AIUsage
+	AgentToken *agent.AgentToken
+	// PromptRetention is how long the prompt and response are kept, 0 disables storing them
+	PromptRetention time.Duration
+}
+
+// Run records the token usage of the primary completion and one "repair" row per repair turn,
+// the tokens were spent whether the agent call succeeded or not
+func (c *CreateAIUsageService) Run(ctx context.Context) ([]*models.AIUsage, error) {
+	aiUsages := []*models.AIUsage{}
+
+	if c.AgentToken == nil {
+		return aiUsages, nil
+	}
+
+	aiUsage, err := c.create(ctx, c.Usage.UsageType, c.Usage.Metadata, c.AgentToken)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aiUsages = append(aiUsages, aiUsage)
+
+	for _, repair := range c.AgentToken.Repairs {
+		aiUsage, err := c.create(ctx, AIUsageTypeRepair, map[string]interface{}{
+			"repair_of": c.Usage.UsageType,
+		}, repair)
+
+		if err != nil {
+			return nil, err
+		}
+
+		aiUsages = append(aiUsages, aiUsage)
+	}
+
+	return aiUsages, nil
+}
+
+func (c *CreateAIUsageService) create(ctx context.Context, usageType string, metadata interface{}, agentToken *agent.AgentToken) (*models.AIUsage, error) {
+	// the model that actually answered and its tier, they differ from the requested one after a fallback
+	model, isPremium := c.Usage.Model, c.Usage.IsPremium
+
+	if agentToken.Model != "" {
+		model, isPremium = agentToken.Model, agentToken.IsPremium
+	}
+
+	aiUsage := &models.AIUsage{
+		ID:               uuid.New().String(),
+		OwnerID:          c.Usage.OwnerID,
+		ProjectID:        c.Usage.ProjectID,
+		EndpointID:       c.Usage.EndpointID,
+		PromptTokens:     agentToken.Usage.PromptTokens,
+		CompletionTokens: agentToken.Usage.CompletionTokens,
+		CachedTokens:     agentToken.Usage.CachedTokens,
+		Model:            model,
+		UsageType:        usageType,
+		IsPremium:        isPremium,
+		Metadata:         metadata,
+	}
+
+	if err := c.AIUsageRepo.CreateAIUsage(ctx, aiUsage); err != nil {
+		return nil, err
+	}
+
+	if c.PromptRetention <= 0 {
+		return aiUsage, nil
+	}
+
+	// the payload is optional, failing to store it must not fail the usage
+	if err := c.AIUsagePayloadRepo.CreateAIUsagePayload(ctx, &models.AIUsagePayload{
+		ID:        uuid.New().String(),
+		AIUsageID: aiUsage.ID,
+		OwnerID:   aiUsage.OwnerID,
+		Input:     agentToken.Input,
+		Output:    agentToken.Output,
+		ExpiresAt: time.Now().Add(c.PromptRetention),
+	}); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create AI usage payload")
+	}
+
+	return aiUsage, nil
+}
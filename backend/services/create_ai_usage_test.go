@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateAIUsageService_Run(t *testing.T) {
+	type args struct {
+		ctx             context.Context
+		agentToken      *agent.AgentToken
+		promptRetention time.Duration
+	}
+
+	type testCase struct {
+		name       string
+		args       args
+		mockFn     func(s *CreateAIUsageService)
+		wantUsages []*models.AIUsage
+		wantErr    error
+	}
+
+	agentToken := &agent.AgentToken{
+		Input:  "prompt",
+		Output: "response",
+		Usage:  agent.Usage{PromptTokens: 100, CompletionTokens: 50, CachedTokens: 20},
+		Repairs: []*agent.AgentToken{
+			{Input: "fix it", Output: "fixed", Usage: agent.Usage{PromptTokens: 160, CompletionTokens: 40}},
+		},
+	}
+
+	tests := []testCase{
+		{
+			name: "should record primary and repair usages with payloads",
+			args: args{
+				ctx:             context.Background(),
+				agentToken:      agentToken,
+				promptRetention: 24 * time.Hour,
+			},
+			mockFn: func(s *CreateAIUsageService) {
+				ur, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ur.EXPECT().
+					CreateAIUsage(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(nil)
+
+				pr, _ := s.AIUsagePayloadRepo.(*mocks.MockAIUsagePayloadRepository)
+				pr.EXPECT().
+					CreateAIUsagePayload(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(nil)
+			},
+			wantUsages: []*models.AIUsage{
+				{UsageType: "workflow", PromptTokens: 100, CompletionTokens: 50, CachedTokens: 20},
+				{UsageType: AIUsageTypeRepair, PromptTokens: 160, CompletionTokens: 40},
+			},
+		},
+		{
+			name: "should record usages without payloads",
+			args: args{
+				ctx:        context.Background(),
+				agentToken: agentToken,
+			},
+			mockFn: func(s *CreateAIUsageService) {
+				ur, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ur.EXPECT().
+					CreateAIUsage(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(nil)
+			},
+			wantUsages: []*models.AIUsage{
+				{UsageType: "workflow", PromptTokens: 100, CompletionTokens: 50, CachedTokens: 20},
+				{UsageType: AIUsageTypeRepair, PromptTokens: 160, CompletionTokens: 40},
+			},
+		},
+		{
+			name: "should not fail when the payload can't be stored",
+			args: args{
+				ctx:             context.Background(),
+				agentToken:      &agent.AgentToken{Usage: agent.Usage{PromptTokens: 10, CompletionTokens: 5}},
+				promptRetention: time.Hour,
+			},
+			mockFn: func(s *CreateAIUsageService) {
+				ur, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ur.EXPECT().
+					CreateAIUsage(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+
+				pr, _ := s.AIUsagePayloadRepo.(*mocks.MockAIUsagePayloadRepository)
+				pr.EXPECT().
+					CreateAIUsagePayload(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("failed to create ai usage payload"))
+			},
+			wantUsages: []*models.AIUsage{
+				{UsageType: "workflow", PromptTokens: 10, CompletionTokens: 5},
+			},
+		},
+		{
+			name: "should bill usages at the tier of the model that answered",
+			args: args{
+				ctx: context.Background(),
+				agentToken: &agent.AgentToken{
+					Model:     "gpt-3.5-turbo",
+					IsPremium: false,
+					Usage:     agent.Usage{PromptTokens: 10, CompletionTokens: 5},
+					Repairs: []*agent.AgentToken{
+						{Model: "claude-3-opus", IsPremium: true, Usage: agent.Usage{PromptTokens: 20, CompletionTokens: 8}},
+					},
+				},
+			},
+			mockFn: func(s *CreateAIUsageService) {
+				s.Usage.IsPremium = true
+
+				ur, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ur.EXPECT().
+					CreateAIUsage(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(nil)
+			},
+			wantUsages: []*models.AIUsage{
+				{UsageType: "workflow", Model: "gpt-3.5-turbo", IsPremium: false, PromptTokens: 10, CompletionTokens: 5},
+				{UsageType: AIUsageTypeRepair, Model: "claude-3-opus", IsPremium: true, PromptTokens: 20, CompletionTokens: 8},
+			},
+		},
+		{
+			name: "should return error when usage can't be stored",
+			args: args{
+				ctx:        context.Background(),
+				agentToken: agentToken,
+			},
+			mockFn: func(s *CreateAIUsageService) {
+				ur, _ := s.AIUsageRepo.(*mocks.MockAIUsageRepository)
+				ur.EXPECT().
+					CreateAIUsage(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("failed to create ai usage"))
+			},
+			wantErr: errors.New("failed to create ai usage"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := &CreateAIUsageService{
+				AIUsageRepo:        mocks.NewMockAIUsageRepository(ctrl),
+				AIUsagePayloadRepo: mocks.NewMockAIUsagePayloadRepository(ctrl),
+				Usage: &models.AIUsage{
+					OwnerID:   "user-id",
+					ProjectID: "project-id",
+					Model:     "gpt-4o",
+					UsageType: "workflow",
+				},
+				AgentToken:      tt.args.agentToken,
+				PromptRetention: tt.args.promptRetention,
+			}
+
+			if tt.mockFn != nil {
+				tt.mockFn(service)
+			}
+
+			aiUsages, err := service.Run(tt.args.ctx)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				require.Nil(t, aiUsages)
+				require.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, aiUsages, len(tt.wantUsages))
+
+			for i, want := range tt.wantUsages {
+				require.Equal(t, want.UsageType, aiUsages[i].UsageType)
+				require.Equal(t, want.PromptTokens, aiUsages[i].PromptTokens)
+				require.Equal(t, want.CompletionTokens, aiUsages[i].CompletionTokens)
+				require.Equal(t, want.CachedTokens, aiUsages[i].CachedTokens)
+				require.Equal(t, "project-id", aiUsages[i].ProjectID)
+				require.Equal(t, want.IsPremium, aiUsages[i].IsPremium)
+
+				if want.Model != "" {
+					require.Equal(t, want.Model, aiUsages[i].Model)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+)
+
+type CreateChatMessageService struct {
+	ChatMessageRepo store.ChatMessageRepository
+	User            *models.User
+	ProjectID       string
+	// EndpointID is empty when the message isn't about an endpoint
+	EndpointID string
+	Content    string
+}
+
+// Run stores the prompt of the user, the created_at set by the database orders the conversation
+func (c *CreateChatMessageService) Run(ctx context.Context) (*models.ChatMessage, error) {
+	message := &models.ChatMessage{
+		ID:         uuid.New().String(),
+		OwnerID:    c.User.ID,
+		ProjectID:  c.ProjectID,
+		EndpointID: null.NewString(c.EndpointID, c.EndpointID != ""),
+		Role:       models.ChatMessageRoleUser,
+		Content:    c.Content,
+	}
+
+	if err := c.ChatMessageRepo.CreateChatMessage(ctx, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+)
+
+// maxCommitMsgLength is the length of the subject line of a git commit
+const maxCommitMsgLength = 72
+
+type CreateCodeVersionService struct {
+	CodeVersionRepo store.CodeVersionRepository
+	Endpoint        *models.Endpoint
+	Code            *agent.CodeGeneration
+	// Message is the first line of the prompt or of the description of the change
+	Message string
+}
+
+// Run saves the code as the next version of the endpoint.
+// Nothing is saved when the code is the latest version, the latest version is returned instead.
+func (c *CreateCodeVersionService) Run(ctx context.Context) (*models.CodeVersion, error) {
+	hash := c.Code.Hash()
+
+	next := 1
+
+	latest, err := c.CodeVersionRepo.FindLatestCodeVersion(ctx, c.Endpoint.ID)
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return nil, err
+	case latest.ContentHash == hash:
+		return latest, nil
+	default:
+		version, err := strconv.Atoi(latest.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		next = version + 1
+	}
+
+	version := &models.CodeVersion{
+		ID:          uuid.New().String(),
+		OwnerID:     c.Endpoint.OwnerID,
+		ProjectID:   c.Endpoint.ProjectID,
+		EndpointID:  null.NewString(c.Endpoint.ID, true),
+		Version:     strconv.Itoa(next),
+		Branch:      models.CodeVersionBranchDefault,
+		CommitMsg:   CommitMessage(c.Message),
+		ContentHash: hash,
+		Content:     c.Code,
+	}
+
+	if err := c.CodeVersionRepo.CreateCodeVersion(ctx, version); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// CommitMessage returns the first line of the message cut to the length of a commit subject line
+func CommitMessage(message string) string {
+	message = strings.TrimSpace(message)
+
+	if line, _, found := strings.Cut(message, "\n"); found {
+		message = strings.TrimSpace(line)
+	}
+
+	if message == "" {
+		return "Update code"
+	}
+
+	if runes := []rune(message); len(runes) > maxCommitMsgLength {
+		message = strings.TrimSpace(string(runes[:maxCommitMsgLength-3])) + "..."
+	}
+
+	return message
+}
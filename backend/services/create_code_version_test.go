@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateCodeVersionService_Run(t *testing.T) {
+	code := &agent.CodeGeneration{FileContents: []agent.FileContent{{Filename: "main.go", Content: "package main"}}}
+
+	type testCase struct {
+		name        string
+		message     string
+		mockFn      func(s *CreateCodeVersionService)
+		wantVersion string
+		wantMsg     string
+	}
+
+	tests := []testCase{
+		{
+			name:    "should save the first version",
+			message: "Create a todo api\nwith a list endpoint",
+			mockFn: func(s *CreateCodeVersionService) {
+				cr, _ := s.CodeVersionRepo.(*mocks.MockCodeVersionRepository)
+				cr.EXPECT().FindLatestCodeVersion(gomock.Any(), "endpoint-id").Times(1).Return(nil, store.ErrNotFound)
+				cr.EXPECT().CreateCodeVersion(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			wantVersion: "1",
+			wantMsg:     "Create a todo api",
+		},
+		{
+			name:    "should save the next version",
+			message: "",
+			mockFn: func(s *CreateCodeVersionService) {
+				cr, _ := s.CodeVersionRepo.(*mocks.MockCodeVersionRepository)
+				cr.EXPECT().FindLatestCodeVersion(gomock.Any(), "endpoint-id").Times(1).Return(&models.CodeVersion{ID: "version-9", Version: "9", ContentHash: "other"}, nil)
+				cr.EXPECT().CreateCodeVersion(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			wantVersion: "10",
+			wantMsg:     "Update code",
+		},
+		{
+			name:    "should not save the code of the latest version again",
+			message: "Create a todo api",
+			mockFn: func(s *CreateCodeVersionService) {
+				cr, _ := s.CodeVersionRepo.(*mocks.MockCodeVersionRepository)
+				cr.EXPECT().FindLatestCodeVersion(gomock.Any(), "endpoint-id").Times(1).Return(&models.CodeVersion{ID: "version-3", Version: "3", ContentHash: code.Hash(), CommitMsg: "Earlier prompt"}, nil)
+			},
+			wantVersion: "3",
+			wantMsg:     "Earlier prompt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := &CreateCodeVersionService{
+				CodeVersionRepo: mocks.NewMockCodeVersionRepository(ctrl),
+				Endpoint:        &models.Endpoint{ID: "endpoint-id", ProjectID: "project-id", OwnerID: "user-id"},
+				Code:            code,
+				Message:         tt.message,
+			}
+
+			if tt.mockFn != nil {
+				tt.mockFn(service)
+			}
+
+			version, err := service.Run(context.Background())
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantVersion, version.Version)
+			require.Equal(t, tt.wantMsg, version.CommitMsg)
+			require.Equal(t, code.Hash(), version.ContentHash)
+		})
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	long := strings.Repeat("a", 100)
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "first_line", message: "  Add a search endpoint\n\nfiltered by title", want: "Add a search endpoint"},
+		{name: "empty", message: " \n", want: "Update code"},
+		{name: "long", message: long, want: strings.Repeat("a", 69) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, CommitMessage(tt.message))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mujhtech/b0/api/dto"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+)
+
+type DiffCodeVersionsService struct {
+	EndpointRepo    store.EndpointRepository
+	CodeVersionRepo store.CodeVersionRepository
+	User            *models.User
+	EndpointID      string
+	Query           dto.GetCodeVersionDiffQuery
+}
+
+// Run returns the files that changed from a version of the code of the endpoint to another, the versions are returned without their content
+func (d *DiffCodeVersionsService) Run(ctx context.Context) (*dto.CodeVersionDiffResponseDto, error) {
+	findEndpointService := FindEndpointService{
+		EndpointRepo: d.EndpointRepo,
+		User:         d.User,
+		EndpointID:   d.EndpointID,
+	}
+
+	endpoint, err := findEndpointService.Run(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := findEndpointCodeVersion(ctx, d.CodeVersionRepo, endpoint, d.Query.From)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var to *models.CodeVersion
+
+	if d.Query.To != "" {
+		to, err = findEndpointCodeVersion(ctx, d.CodeVersionRepo, endpoint, d.Query.To)
+	} else {
+		to, err = d.CodeVersionRepo.FindLatestCodeVersion(ctx, endpoint.ID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := from.Content.DiffFiles(to.Content)
+
+	from.Content, to.Content = nil, nil
+
+	return &dto.CodeVersionDiffResponseDto{
+		From:  from,
+		To:    to,
+		Files: files,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/openapi"
+)
+
+type ExportOpenAPIService struct {
+	ProjectRepo  store.ProjectRepository
+	EndpointRepo store.EndpointRepository
+	User         *models.User
+	ProjectID    string
+}
+
+func (e *ExportOpenAPIService) Run(ctx context.Context) (*openapi.Document, error) {
+
+	findProjectService := FindProjectService{
+		ProjectRepo: e.ProjectRepo,
+		User:        e.User,
+		ProjectID:   e.ProjectID,
+	}
+
+	project, err := findProjectService.Run(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := e.EndpointRepo.FindEndpointByProjectID(ctx, project.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	documented := make([]openapi.Endpoint, 0, len(endpoints))
+
+	for _, endpoint := range endpoints {
+		// inactive endpoints aren't served
+		if endpoint.Status == models.EndpointStatusInactive {
+			continue
+		}
+
+		documented = append(documented, openapi.Endpoint{
+			Name:        endpoint.Name,
+			Path:        endpoint.Path,
+			Method:      string(endpoint.Method),
+			Description: endpoint.Description.String,
+			IsPublic:    endpoint.IsPublic,
+			Workflows:   endpoint.Workflows,
+		})
+	}
+
+	return openapi.Build(openapi.Info{
+		Title:       project.Name,
+		Description: project.Description.String,
+	}, project.ServerUrl.String, documented)
+}
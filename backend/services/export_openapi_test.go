@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/errors"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportOpenAPIService_Run(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		user      *models.User
+		projectID string
+	}
+
+	type testCase struct {
+		name      string
+		args      args
+		mockFn    func(s *ExportOpenAPIService)
+		wantPaths []string
+		wantErr   error
+	}
+
+	workflows := []*agent.Workflow{{Type: agent.WorkflowTypeRequest, Url: "/todos"}}
+
+	tests := []testCase{
+		{
+			name: "should document the active and draft endpoints of the project",
+			args: args{
+				ctx:       context.Background(),
+				user:      &models.User{ID: "user-id"},
+				projectID: "project-id",
+			},
+			mockFn: func(s *ExportOpenAPIService) {
+				pr, _ := s.ProjectRepo.(*mocks.MockProjectRepository)
+				pr.EXPECT().
+					FindProjectByID(gomock.Any(), "project-id").
+					Times(1).
+					Return(&models.Project{ID: "project-id", OwnerID: "user-id", Name: "Todo", ServerUrl: null.NewString("https://todo.b0.dev", true)}, nil)
+
+				em, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				em.EXPECT().
+					FindEndpointByProjectID(gomock.Any(), "project-id").
+					Times(1).
+					Return([]*models.Endpoint{
+						{ID: "endpoint-1", Name: "List todos", Path: "/todos", Method: models.EndpointMethodGet, Status: models.EndpointStatusActive, Workflows: workflows},
+						{ID: "endpoint-2", Name: "Get todo", Path: "/todos/:id", Method: models.EndpointMethodGet, Status: models.EndpointStatusDraft, Workflows: workflows},
+						{ID: "endpoint-3", Name: "Old todos", Path: "/v0/todos", Method: models.EndpointMethodGet, Status: models.EndpointStatusInactive, Workflows: workflows},
+					}, nil)
+			},
+			wantPaths: []string{"/todos", "/todos/{id}"},
+		},
+		{
+			name: "should not export a project of another user",
+			args: args{
+				ctx:       context.Background(),
+				user:      &models.User{ID: "user-id"},
+				projectID: "project-id",
+			},
+			mockFn: func(s *ExportOpenAPIService) {
+				pr, _ := s.ProjectRepo.(*mocks.MockProjectRepository)
+				pr.EXPECT().
+					FindProjectByID(gomock.Any(), "project-id").
+					Times(1).
+					Return(&models.Project{ID: "project-id", OwnerID: "another-user-id"}, nil)
+			},
+			wantErr: errors.ErrNotAuthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := &ExportOpenAPIService{
+				ProjectRepo:  mocks.NewMockProjectRepository(ctrl),
+				EndpointRepo: mocks.NewMockEndpointRepository(ctrl),
+				User:         tt.args.user,
+				ProjectID:    tt.args.projectID,
+			}
+
+			if tt.mockFn != nil {
+				tt.mockFn(service)
+			}
+
+			doc, err := service.Run(tt.args.ctx)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				require.Nil(t, doc)
+				require.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "Todo", doc.Info.Title)
+			require.Equal(t, "https://todo.b0.dev", doc.Servers[0].URL)
+
+			paths := make([]string, 0, len(doc.Paths))
+
+			for path := range doc.Paths {
+				paths = append(paths, path)
+			}
+
+			require.ElementsMatch(t, tt.wantPaths, paths)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mujhtech/b0/api/dto"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/errors"
+)
+
+type FindChatMessagesService struct {
+	ChatMessageRepo store.ChatMessageRepository
+	ProjectRepo     store.ProjectRepository
+	User            *models.User
+	Query           dto.GetChatMessagesQuery
+}
+
+// Run returns a page of the conversation about the project or one of its endpoints, oldest first
+func (f *FindChatMessagesService) Run(ctx context.Context) (*dto.ChatMessagesResponseDto, error) {
+
+	project, err := f.ProjectRepo.FindProjectByID(ctx, f.Query.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if project.OwnerID != f.User.ID {
+		return nil, errors.ErrNotAuthorized
+	}
+
+	messages, err := f.ChatMessageRepo.FindChatMessages(ctx, store.ChatMessageFilter{
+		ProjectID:  project.ID,
+		EndpointID: f.Query.EndpointID,
+		Before:     f.Query.Before,
+		Limit:      uint64(f.Query.Limit),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.ChatMessagesResponseDto{
+		Messages: messages,
+	}
+
+	if f.Query.Limit > 0 && len(messages) == f.Query.Limit {
+		result.Before = &messages[0].CreatedAt
+	}
+
+	return result, nil
+}
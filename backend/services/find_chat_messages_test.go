@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mujhtech/b0/api/dto"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/errors"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFindChatMessagesService_Run(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		user  *models.User
+		query dto.GetChatMessagesQuery
+	}
+
+	type testCase struct {
+		name       string
+		args       args
+		mockFn     func(s *FindChatMessagesService)
+		wantLen    int
+		wantBefore *time.Time
+		wantErr    error
+	}
+
+	first := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	before := time.Date(2025, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name: "should find a full page of the endpoint chat",
+			args: args{
+				ctx:   context.Background(),
+				user:  &models.User{ID: "user-id"},
+				query: dto.GetChatMessagesQuery{ProjectID: "project-id", EndpointID: "endpoint-id", Before: before, Limit: 2},
+			},
+			mockFn: func(s *FindChatMessagesService) {
+				pr, _ := s.ProjectRepo.(*mocks.MockProjectRepository)
+				pr.EXPECT().FindProjectByID(gomock.Any(), "project-id").Times(1).Return(&models.Project{ID: "project-id", OwnerID: "user-id"}, nil)
+
+				cr, _ := s.ChatMessageRepo.(*mocks.MockChatMessageRepository)
+				cr.EXPECT().
+					FindChatMessages(gomock.Any(), store.ChatMessageFilter{ProjectID: "project-id", EndpointID: "endpoint-id", Before: before, Limit: 2}).
+					Times(1).
+					Return([]*models.ChatMessage{
+						{ID: "message-1", Role: models.ChatMessageRoleUser, CreatedAt: first},
+						{ID: "message-2", Role: models.ChatMessageRoleAssistant, CreatedAt: first.Add(time.Minute)},
+					}, nil)
+			},
+			wantLen:    2,
+			wantBefore: &first,
+		},
+		{
+			name: "should not return a cursor on the last page",
+			args: args{
+				ctx:   context.Background(),
+				user:  &models.User{ID: "user-id"},
+				query: dto.GetChatMessagesQuery{ProjectID: "project-id", Limit: 10},
+			},
+			mockFn: func(s *FindChatMessagesService) {
+				pr, _ := s.ProjectRepo.(*mocks.MockProjectRepository)
+				pr.EXPECT().FindProjectByID(gomock.Any(), "project-id").Times(1).Return(&models.Project{ID: "project-id", OwnerID: "user-id"}, nil)
+
+				cr, _ := s.ChatMessageRepo.(*mocks.MockChatMessageRepository)
+				cr.EXPECT().
+					FindChatMessages(gomock.Any(), store.ChatMessageFilter{ProjectID: "project-id", Limit: 10}).
+					Times(1).
+					Return([]*models.ChatMessage{{ID: "message-1", Role: models.ChatMessageRoleUser, CreatedAt: first}}, nil)
+			},
+			wantLen: 1,
+		},
+		{
+			name: "should not find the chat of another user's project",
+			args: args{
+				ctx:   context.Background(),
+				user:  &models.User{ID: "user-id"},
+				query: dto.GetChatMessagesQuery{ProjectID: "project-id", Limit: 10},
+			},
+			mockFn: func(s *FindChatMessagesService) {
+				pr, _ := s.ProjectRepo.(*mocks.MockProjectRepository)
+				pr.EXPECT().FindProjectByID(gomock.Any(), "project-id").Times(1).Return(&models.Project{ID: "project-id", OwnerID: "other-user-id"}, nil)
+			},
+			wantErr: errors.ErrNotAuthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := &FindChatMessagesService{
+				ChatMessageRepo: mocks.NewMockChatMessageRepository(ctrl),
+				ProjectRepo:     mocks.NewMockProjectRepository(ctrl),
+				User:            tt.args.user,
+				Query:           tt.args.query,
+			}
+
+			if tt.mockFn != nil {
+				tt.mockFn(service)
+			}
+
+			result, err := service.Run(tt.args.ctx)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				require.Nil(t, result)
+				require.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, result.Messages, tt.wantLen)
+			require.Equal(t, tt.wantBefore, result.Before)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+)
+
+type FindCodeVersionsService struct {
+	EndpointRepo    store.EndpointRepository
+	CodeVersionRepo store.CodeVersionRepository
+	User            *models.User
+	EndpointID      string
+	Limit           uint64
+}
+
+// Run returns the latest versions of the code of the endpoint newest first, without their content
+func (f *FindCodeVersionsService) Run(ctx context.Context) ([]*models.CodeVersion, error) {
+	findEndpointService := FindEndpointService{
+		EndpointRepo: f.EndpointRepo,
+		User:         f.User,
+		EndpointID:   f.EndpointID,
+	}
+
+	endpoint, err := findEndpointService.Run(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.CodeVersionRepo.FindCodeVersions(ctx, store.CodeVersionFilter{
+		EndpointID: endpoint.ID,
+		Limit:      f.Limit,
+	})
+}
+
+// findEndpointCodeVersion returns the version of the code of the endpoint, a version of another endpoint isn't found
+func findEndpointCodeVersion(ctx context.Context, repo store.CodeVersionRepository, endpoint *models.Endpoint, versionID string) (*models.CodeVersion, error) {
+	version, err := repo.FindCodeVersionByID(ctx, versionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if version.EndpointID.String != endpoint.ID {
+		return nil, store.ErrNotFound
+	}
+
+	return version, nil
+}
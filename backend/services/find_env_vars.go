@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mujhtech/b0/api/dto"
+	secretmanager "github.com/mujhtech/b0/internal/pkg/secret_manager"
+	"github.com/mujhtech/b0/internal/util"
+)
+
+// FindEnvVarsService returns the environment variables of the project, those of the endpoint when EndpointID is set
+type FindEnvVarsService struct {
+	SecretManager secretmanager.SecretManager
+	ProjectID     string
+	EndpointID    string
+}
+
+func (f *FindEnvVarsService) Run(ctx context.Context) ([]*dto.Secret, error) {
+
+	secrets := []*dto.Secret{}
+
+	secretId := f.ProjectID
+
+	if f.EndpointID != "" {
+		secretId = fmt.Sprintf("%s_%s", secretId, f.EndpointID)
+	}
+
+	secretName := fmt.Sprintf("projects/b0/%s/env-variables", secretId)
+
+	secret, err := f.SecretManager.GetSecret(ctx, secretName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if secret != nil {
+		if err := util.UnmarshalJSON(secret, &secrets); err != nil {
+			return nil, err
+		}
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/openapi"
+)
+
+type ImportOpenAPIService struct {
+	ProjectRepo  store.ProjectRepository
+	EndpointRepo store.EndpointRepository
+	User         *models.User
+	ProjectID    string
+	Document     *openapi.Document
+}
+
+// Run creates an endpoint per operation of the document, operations the project already serves are skipped
+func (i *ImportOpenAPIService) Run(ctx context.Context) ([]*models.Endpoint, error) {
+
+	findProjectService := FindProjectService{
+		ProjectRepo: i.ProjectRepo,
+		User:        i.User,
+		ProjectID:   i.ProjectID,
+	}
+
+	project, err := findProjectService.Run(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := i.EndpointRepo.FindEndpointByProjectID(ctx, project.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	served := map[string]bool{}
+
+	for _, endpoint := range existing {
+		served[string(endpoint.Method)+" "+openapi.ImportPath(endpoint.Path)] = true
+	}
+
+	endpoints := []*models.Endpoint{}
+
+	for _, imported := range openapi.Import(i.Document) {
+		if served[imported.Method+" "+imported.Path] {
+			continue
+		}
+
+		endpoint := &models.Endpoint{
+			ID:          uuid.New().String(),
+			OwnerID:     i.User.ID,
+			ProjectID:   project.ID,
+			Name:        imported.Name,
+			Description: null.NewString(imported.Description, imported.Description != ""),
+			Path:        imported.Path,
+			Method:      models.EndpointMethod(imported.Method),
+			Metadata:    null.NewString("{}", true),
+			Workflows:   imported.Workflows,
+			IsPublic:    false,
+			Status:      models.EndpointStatusDraft,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err = i.EndpointRepo.CreateEndpoint(ctx, endpoint); err != nil {
+			return nil, err
+		}
+
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/errors"
+	"github.com/mujhtech/b0/internal/pkg/openapi"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestImportOpenAPIService_Run(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		user      *models.User
+		projectID string
+	}
+
+	type testCase struct {
+		name          string
+		args          args
+		mockFn        func(s *ImportOpenAPIService)
+		wantEndpoints []string
+		wantErr       error
+	}
+
+	doc, err := openapi.Parse([]byte(`{
+		"openapi": "3.0.3",
+		"info": {"title": "Todo", "version": "1.0.0"},
+		"paths": {
+			"/todos": {
+				"get": {"summary": "List todos", "responses": {"200": {"description": "The todos"}}},
+				"post": {"summary": "Create todo", "responses": {"201": {"description": "Created"}}}
+			},
+			"/todos/{id}": {
+				"get": {"summary": "Get todo", "responses": {"200": {"description": "The todo"}}}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	tests := []testCase{
+		{
+			name: "should create the endpoints the project does not serve yet",
+			args: args{
+				ctx:       context.Background(),
+				user:      &models.User{ID: "user-id"},
+				projectID: "project-id",
+			},
+			mockFn: func(s *ImportOpenAPIService) {
+				pr, _ := s.ProjectRepo.(*mocks.MockProjectRepository)
+				pr.EXPECT().
+					FindProjectByID(gomock.Any(), "project-id").
+					Times(1).
+					Return(&models.Project{ID: "project-id", OwnerID: "user-id"}, nil)
+
+				em, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				em.EXPECT().
+					FindEndpointByProjectID(gomock.Any(), "project-id").
+					Times(1).
+					Return([]*models.Endpoint{
+						{ID: "endpoint-1", Name: "List todos", Path: "/todos", Method: models.EndpointMethodGet},
+					}, nil)
+				em.EXPECT().
+					CreateEndpoint(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(nil)
+			},
+			wantEndpoints: []string{"POST /todos", "GET /todos/:id"},
+		},
+		{
+			name: "should not import into a project of another user",
+			args: args{
+				ctx:       context.Background(),
+				user:      &models.User{ID: "user-id"},
+				projectID: "project-id",
+			},
+			mockFn: func(s *ImportOpenAPIService) {
+				pr, _ := s.ProjectRepo.(*mocks.MockProjectRepository)
+				pr.EXPECT().
+					FindProjectByID(gomock.Any(), "project-id").
+					Times(1).
+					Return(&models.Project{ID: "project-id", OwnerID: "another-user-id"}, nil)
+			},
+			wantErr: errors.ErrNotAuthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := &ImportOpenAPIService{
+				ProjectRepo:  mocks.NewMockProjectRepository(ctrl),
+				EndpointRepo: mocks.NewMockEndpointRepository(ctrl),
+				User:         tt.args.user,
+				ProjectID:    tt.args.projectID,
+				Document:     doc,
+			}
+
+			if tt.mockFn != nil {
+				tt.mockFn(service)
+			}
+
+			endpoints, err := service.Run(tt.args.ctx)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				require.Nil(t, endpoints)
+				require.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			imported := make([]string, 0, len(endpoints))
+
+			for _, endpoint := range endpoints {
+				require.Equal(t, "project-id", endpoint.ProjectID)
+				require.Equal(t, models.EndpointStatusDraft, endpoint.Status)
+				require.NotEmpty(t, endpoint.Workflows)
+
+				imported = append(imported, string(endpoint.Method)+" "+endpoint.Path)
+			}
+
+			require.Equal(t, tt.wantEndpoints, imported)
+		})
+	}
+}
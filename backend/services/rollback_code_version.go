@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+)
+
+type RollbackCodeVersionService struct {
+	EndpointRepo    store.EndpointRepository
+	CodeVersionRepo store.CodeVersionRepository
+	User            *models.User
+	EndpointID      string
+	VersionID       string
+}
+
+// Run restores the code of the version and the workflows it was generated from, the restored code is saved as the next version
+func (r *RollbackCodeVersionService) Run(ctx context.Context) (*models.CodeVersion, error) {
+	findEndpointService := FindEndpointService{
+		EndpointRepo: r.EndpointRepo,
+		User:         r.User,
+		EndpointID:   r.EndpointID,
+	}
+
+	endpoint, err := findEndpointService.Run(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := findEndpointCodeVersion(ctx, r.CodeVersionRepo, endpoint, r.VersionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if version.Content == nil || len(version.Content.FileContents) == 0 {
+		return nil, ErrNoCode
+	}
+
+	// without the workflows of the version the next deploy would regenerate the code
+	if err := r.EndpointRepo.UpdateEndpoint(ctx, endpoint.ID, &models.Endpoint{
+		Workflows:      version.Content.Workflows,
+		CodeGeneration: version.Content,
+	}); err != nil {
+		return nil, err
+	}
+
+	createCodeVersionService := CreateCodeVersionService{
+		CodeVersionRepo: r.CodeVersionRepo,
+		Endpoint:        endpoint,
+		Code:            version.Content,
+		Message:         fmt.Sprintf("Roll back to version %s", version.Version),
+	}
+
+	return createCodeVersionService.Run(ctx)
+}
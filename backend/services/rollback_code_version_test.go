@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/errors"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+	"github.com/mujhtech/b0/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRollbackCodeVersionService_Run(t *testing.T) {
+	workflows := []*agent.Workflow{{Type: "request", Method: "GET", Url: "/todos"}}
+
+	code := &agent.CodeGeneration{
+		FileContents: []agent.FileContent{{Filename: "main.go", Content: "package main"}},
+		Workflows:    workflows,
+	}
+
+	type testCase struct {
+		name    string
+		user    *models.User
+		mockFn  func(s *RollbackCodeVersionService)
+		wantErr error
+	}
+
+	tests := []testCase{
+		{
+			name: "should restore the code and workflows of the version",
+			user: &models.User{ID: "user-id"},
+			mockFn: func(s *RollbackCodeVersionService) {
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().FindEndpointByID(gomock.Any(), "endpoint-id").Times(1).Return(&models.Endpoint{ID: "endpoint-id", OwnerID: "user-id", ProjectID: "project-id"}, nil)
+				er.EXPECT().UpdateEndpoint(gomock.Any(), "endpoint-id", &models.Endpoint{Workflows: workflows, CodeGeneration: code}).Times(1).Return(nil)
+
+				cr, _ := s.CodeVersionRepo.(*mocks.MockCodeVersionRepository)
+				cr.EXPECT().FindCodeVersionByID(gomock.Any(), "version-id").Times(1).Return(&models.CodeVersion{ID: "version-id", EndpointID: null.StringFrom("endpoint-id"), Version: "2", Content: code}, nil)
+				cr.EXPECT().FindLatestCodeVersion(gomock.Any(), "endpoint-id").Times(1).Return(&models.CodeVersion{Version: "5", ContentHash: "latest"}, nil)
+				cr.EXPECT().CreateCodeVersion(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, version *models.CodeVersion) error {
+					require.Equal(t, "6", version.Version)
+					require.Equal(t, "Roll back to version 2", version.CommitMsg)
+					return nil
+				})
+			},
+		},
+		{
+			name: "should not find the version of another endpoint",
+			user: &models.User{ID: "user-id"},
+			mockFn: func(s *RollbackCodeVersionService) {
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().FindEndpointByID(gomock.Any(), "endpoint-id").Times(1).Return(&models.Endpoint{ID: "endpoint-id", OwnerID: "user-id"}, nil)
+
+				cr, _ := s.CodeVersionRepo.(*mocks.MockCodeVersionRepository)
+				cr.EXPECT().FindCodeVersionByID(gomock.Any(), "version-id").Times(1).Return(&models.CodeVersion{ID: "version-id", EndpointID: null.StringFrom("other-endpoint-id"), Content: code}, nil)
+			},
+			wantErr: store.ErrNotFound,
+		},
+		{
+			name: "should not roll back the endpoint of another user",
+			user: &models.User{ID: "other-user-id"},
+			mockFn: func(s *RollbackCodeVersionService) {
+				er, _ := s.EndpointRepo.(*mocks.MockEndpointRepository)
+				er.EXPECT().FindEndpointByID(gomock.Any(), "endpoint-id").Times(1).Return(&models.Endpoint{ID: "endpoint-id", OwnerID: "user-id"}, nil)
+			},
+			wantErr: errors.ErrNotAuthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := &RollbackCodeVersionService{
+				EndpointRepo:    mocks.NewMockEndpointRepository(ctrl),
+				CodeVersionRepo: mocks.NewMockCodeVersionRepository(ctrl),
+				User:            tt.user,
+				EndpointID:      "endpoint-id",
+				VersionID:       "version-id",
+			}
+
+			if tt.mockFn != nil {
+				tt.mockFn(service)
+			}
+
+			version, err := service.Run(context.Background())
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, version)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, code.Hash(), version.ContentHash)
+		})
+	}
+}
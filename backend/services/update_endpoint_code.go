@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mujhtech/b0/api/dto"
+	"github.com/mujhtech/b0/database/models"
+	"github.com/mujhtech/b0/database/store"
+	"github.com/mujhtech/b0/internal/pkg/agent"
+)
+
+var (
+	ErrNoCode = errors.New("the endpoint has no generated code yet")
+)
+
+type UpdateEndpointCodeService struct {
+	EndpointRepo    store.EndpointRepository
+	CodeVersionRepo store.CodeVersionRepository
+	User            *models.User
+	EndpointID      string
+	Body            *dto.UpdateEndpointCodeRequestDto
+}
+
+// Run applies the edit to the generated code of the endpoint and saves it as a version.
+// The edited code keeps the workflows it was generated from so the next deploy doesn't regenerate it.
+func (u *UpdateEndpointCodeService) Run(ctx context.Context) (*models.CodeVersion, error) {
+	findEndpointService := FindEndpointService{
+		EndpointRepo: u.EndpointRepo,
+		User:         u.User,
+		EndpointID:   u.EndpointID,
+	}
+
+	endpoint, err := findEndpointService.Run(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.CodeGeneration == nil || len(endpoint.CodeGeneration.FileContents) == 0 {
+		return nil, ErrNoCode
+	}
+
+	code := endpoint.CodeGeneration.Apply(&agent.CodeGeneration{
+		FileContents: u.Body.Files,
+	})
+
+	code.FileContents = slices.DeleteFunc(code.FileContents, func(file agent.FileContent) bool {
+		return slices.Contains(u.Body.DeletedFiles, file.Filename)
+	})
+
+	if err := u.EndpointRepo.UpdateEndpoint(ctx, endpoint.ID, &models.Endpoint{
+		CodeGeneration: code,
+	}); err != nil {
+		return nil, err
+	}
+
+	message := u.Body.Message
+
+	if strings.TrimSpace(message) == "" {
+		message = editMessage(u.Body)
+	}
+
+	createCodeVersionService := CreateCodeVersionService{
+		CodeVersionRepo: u.CodeVersionRepo,
+		Endpoint:        endpoint,
+		Code:            code,
+		Message:         message,
+	}
+
+	return createCodeVersionService.Run(ctx)
+}
+
+// editMessage describes the edit by the files it changes
+func editMessage(edit *dto.UpdateEndpointCodeRequestDto) string {
+	files := []string{}
+
+	for _, file := range edit.Files {
+		files = append(files, file.Filename)
+	}
+
+	files = append(files, edit.DeletedFiles...)
+
+	return fmt.Sprintf("Edit %s", strings.Join(files, ", "))
+}